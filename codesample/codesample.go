@@ -0,0 +1,139 @@
+// Package codesample generates ready to copy request snippets of API methods in several languages.
+package codesample
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// Sample is a request snippet of a method in one language.
+type Sample struct {
+	Lang   string // Lang identifies the language, see Generator
+	Label  string // Label names the language for display
+	Source string
+}
+
+// Generator writes the snippet making a Request in one language.
+type Generator interface {
+	// Lang identifies the language, matched case insensitively against the lang of the code samples
+	// declared by an operation.
+	Lang() string
+	Label() string
+	Generate(req *Request) string
+}
+
+var generators []Generator
+
+// aliases maps the lang commonly given to declared code samples onto that of a built-in generator.
+var aliases = map[string]string{
+	"shell":  "curl",
+	"sh":     "curl",
+	"bash":   "curl",
+	"golang": "go",
+	"js":     "javascript",
+	"node":   "javascript",
+	"py":     "python",
+}
+
+func init() {
+	Register(curl{})
+	Register(golang{})
+	Register(python{})
+	Register(javascript{})
+}
+
+// Register adds a generator, replacing any registered generator of the same language in place.
+func Register(g Generator) {
+	for i, r := range generators {
+		if langKey(r.Lang()) == langKey(g.Lang()) {
+			generators[i] = g
+
+			return
+		}
+	}
+
+	generators = append(generators, g)
+}
+
+// Generators returns the registered generators, in the order they were registered.
+func Generators() []Generator {
+	return append([]Generator(nil), generators...)
+}
+
+// Samples returns the snippets of a method of an API, one per registered generator in order. A code
+// sample declared by the operation replaces the snippet generated for its language, and those of other
+// languages follow the generated snippets.
+func Samples(api spec.APIGroup, method spec.Method) []Sample {
+	declared := make(map[string]spec.CodeSample, len(method.CodeSamples))
+	for _, cs := range method.CodeSamples {
+		declared[langKey(cs.Lang)] = cs
+	}
+
+	req := NewRequest(api, method)
+
+	samples := make([]Sample, 0, len(generators)+len(declared))
+
+	for _, g := range generators {
+		key := langKey(g.Lang())
+
+		if cs, ok := declared[key]; ok {
+			delete(declared, key)
+
+			samples = append(samples, declaredSample(cs, g.Label()))
+
+			continue
+		}
+
+		samples = append(samples, Sample{Lang: g.Lang(), Label: g.Label(), Source: g.Generate(req)})
+	}
+
+	// Keep the declaration order of the samples of languages without a generator.
+	for _, cs := range method.CodeSamples {
+		if _, ok := declared[langKey(cs.Lang)]; ok {
+			delete(declared, langKey(cs.Lang))
+
+			samples = append(samples, declaredSample(cs, cs.Lang))
+		}
+	}
+
+	return samples
+}
+
+func declaredSample(cs spec.CodeSample, label string) Sample {
+	if cs.Label != "" {
+		label = cs.Label
+	}
+
+	return Sample{Lang: cs.Lang, Label: label, Source: cs.Source}
+}
+
+func langKey(lang string) string {
+	key := strings.ToLower(lang)
+	if alias, ok := aliases[key]; ok {
+		return alias
+	}
+
+	return key
+}
+
+// quote returns s as a double quoted string literal, valid in Go, Python and JavaScript.
+func quote(s string) string {
+	var b strings.Builder
+
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(s); err != nil {
+		return strconv.Quote(s)
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// indent prefixes every line of s but the first.
+func indent(s, prefix string) string {
+	return strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
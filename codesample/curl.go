@@ -0,0 +1,45 @@
+package codesample
+
+import (
+	"strings"
+)
+
+// curl generates shell commands running curl.
+type curl struct{}
+
+func (curl) Lang() string  { return "curl" }
+func (curl) Label() string { return "curl" }
+
+func (curl) Generate(req *Request) string {
+	command := "curl "
+	if req.Method != "GET" {
+		command += "-X " + req.Method + " "
+	}
+
+	args := []string{command + shellQuote(req.URL)}
+
+	for _, h := range req.Headers {
+		args = append(args, "-H "+shellQuote(h.Name+": "+h.Value))
+	}
+
+	for _, f := range req.Form {
+		switch {
+		case f.IsFile:
+			args = append(args, "-F "+shellQuote(f.Name+"=@"+f.Value))
+		case req.Multipart:
+			args = append(args, "-F "+shellQuote(f.Name+"="+f.Value))
+		default:
+			args = append(args, "--data-urlencode "+shellQuote(f.Name+"="+f.Value))
+		}
+	}
+
+	if req.Body != "" {
+		args = append(args, "--data-raw "+shellQuote(req.Body))
+	}
+
+	return strings.Join(args, " \\\n  ")
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
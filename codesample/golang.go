@@ -0,0 +1,138 @@
+package codesample
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// golang generates programs using the net/http package of the Go standard library.
+type golang struct{}
+
+// httpMethods maps the HTTP methods onto their constants in the net/http package.
+var httpMethods = map[string]string{
+	"GET":     "http.MethodGet",
+	"HEAD":    "http.MethodHead",
+	"POST":    "http.MethodPost",
+	"PUT":     "http.MethodPut",
+	"PATCH":   "http.MethodPatch",
+	"DELETE":  "http.MethodDelete",
+	"OPTIONS": "http.MethodOptions",
+}
+
+const goCheckErr = "\tif err != nil {\n\t\tpanic(err)\n\t}\n"
+
+func (golang) Lang() string  { return "go" }
+func (golang) Label() string { return "Go" }
+
+func (golang) Generate(req *Request) string {
+	imports := map[string]bool{"fmt": true, "io": true, "net/http": true}
+
+	var b strings.Builder
+
+	body := "nil"
+
+	switch {
+	case req.Body != "":
+		imports["strings"] = true
+		body = "body"
+
+		fmt.Fprintf(&b, "\tbody := strings.NewReader(%s)\n\n", goString(req.Body))
+	case req.Multipart:
+		imports["bytes"] = true
+		imports["mime/multipart"] = true
+		body = "body"
+
+		b.WriteString("\tbody := &bytes.Buffer{}\n\tform := multipart.NewWriter(body)\n\n")
+
+		files := 0
+
+		for _, f := range req.Form {
+			if !f.IsFile {
+				fmt.Fprintf(&b, "\tif err := form.WriteField(%s, %s); err != nil {\n\t\tpanic(err)\n\t}\n\n", quote(f.Name), quote(f.Value))
+
+				continue
+			}
+
+			imports["os"] = true
+			files++
+
+			file, part := "file", "part"
+			if files > 1 {
+				file, part = fmt.Sprintf("file%d", files), fmt.Sprintf("part%d", files)
+			}
+
+			fmt.Fprintf(&b, "\t%s, err := os.Open(%s)\n%s\tdefer %s.Close()\n\n", file, quote(f.Value), goCheckErr, file)
+			fmt.Fprintf(&b, "\t%s, err := form.CreateFormFile(%s, %s.Name())\n%s\n", part, quote(f.Name), file, goCheckErr)
+			fmt.Fprintf(&b, "\tif _, err := io.Copy(%s, %s); err != nil {\n\t\tpanic(err)\n\t}\n\n", part, file)
+		}
+
+		b.WriteString("\tif err := form.Close(); err != nil {\n\t\tpanic(err)\n\t}\n\n")
+	case len(req.Form) > 0:
+		imports["net/url"] = true
+		imports["strings"] = true
+		body = "strings.NewReader(form.Encode())"
+
+		b.WriteString("\tform := url.Values{}\n")
+
+		for _, f := range req.Form {
+			fmt.Fprintf(&b, "\tform.Set(%s, %s)\n", quote(f.Name), quote(f.Value))
+		}
+
+		b.WriteString("\n")
+	}
+
+	method, ok := httpMethods[req.Method]
+	if !ok {
+		method = quote(req.Method)
+	}
+
+	fmt.Fprintf(&b, "\treq, err := http.NewRequest(%s, %s, %s)\n%s\n", method, quote(req.URL), body, goCheckErr)
+
+	for _, h := range req.Headers {
+		fmt.Fprintf(&b, "\treq.Header.Set(%s, %s)\n", quote(h.Name), quote(h.Value))
+	}
+
+	switch {
+	case req.Multipart:
+		b.WriteString("\treq.Header.Set(\"Content-Type\", form.FormDataContentType())\n")
+	case len(req.Form) > 0:
+		fmt.Fprintf(&b, "\treq.Header.Set(\"Content-Type\", %s)\n", quote(formURLEncoded))
+	}
+
+	if len(req.Headers) > 0 || len(req.Form) > 0 {
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\tresp, err := http.DefaultClient.Do(req)\n" + goCheckErr + "\tdefer resp.Body.Close()\n\n")
+	b.WriteString("\tout, err := io.ReadAll(resp.Body)\n" + goCheckErr + "\n")
+	b.WriteString("\tfmt.Println(resp.Status, string(out))\n")
+
+	packages := make([]string, 0, len(imports))
+	for p := range imports {
+		packages = append(packages, p)
+	}
+
+	sort.Strings(packages)
+
+	var src strings.Builder
+
+	src.WriteString("package main\n\nimport (\n")
+
+	for _, p := range packages {
+		fmt.Fprintf(&src, "\t%q\n", p)
+	}
+
+	src.WriteString(")\n\nfunc main() {\n" + b.String() + "}\n")
+
+	return src.String()
+}
+
+// goString returns s as a raw string literal, unless it holds a backquote.
+func goString(s string) string {
+	if strings.Contains(s, "`") {
+		return quote(s)
+	}
+
+	return "`" + s + "`"
+}
@@ -0,0 +1,68 @@
+package codesample
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// javascript generates scripts using the Fetch API.
+type javascript struct{}
+
+func (javascript) Lang() string  { return "javascript" }
+func (javascript) Label() string { return "JavaScript" }
+
+func (javascript) Generate(req *Request) string {
+	var b strings.Builder
+
+	options := []string{}
+
+	if req.Method != "GET" {
+		options = append(options, "method: "+quote(req.Method))
+	}
+
+	if len(req.Headers) > 0 {
+		headers := make([]string, 0, len(req.Headers))
+		for _, h := range req.Headers {
+			headers = append(headers, "    "+quote(h.Name)+": "+quote(h.Value))
+		}
+
+		options = append(options, "headers: {\n"+strings.Join(headers, ",\n")+"\n  }")
+	}
+
+	switch {
+	case req.Body != "" && req.IsJSON() && json.Valid([]byte(req.Body)):
+		// A JSON example is a valid object literal.
+		options = append(options, "body: JSON.stringify("+indent(req.Body, "  ")+")")
+	case req.Body != "":
+		options = append(options, "body: "+quote(req.Body))
+	case len(req.Form) > 0:
+		if req.Multipart {
+			b.WriteString("const form = new FormData();\n")
+		} else {
+			b.WriteString("const form = new URLSearchParams();\n")
+		}
+
+		for _, f := range req.Form {
+			if f.IsFile {
+				fmt.Fprintf(&b, "form.append(%s, fileInput.files[0]); // fileInput is an <input type=\"file\"> element\n", quote(f.Name))
+			} else {
+				fmt.Fprintf(&b, "form.append(%s, %s);\n", quote(f.Name), quote(f.Value))
+			}
+		}
+
+		b.WriteString("\n")
+
+		options = append(options, "body: form")
+	}
+
+	fmt.Fprintf(&b, "const response = await fetch(%s", quote(req.URL))
+
+	if len(options) > 0 {
+		b.WriteString(", {\n  " + strings.Join(options, ",\n  ") + "\n}")
+	}
+
+	b.WriteString(");\n\nconsole.log(response.status, await response.text());\n")
+
+	return b.String()
+}
@@ -0,0 +1,79 @@
+package codesample
+
+import (
+	"fmt"
+	"strings"
+)
+
+// python generates scripts using the requests library.
+type python struct{}
+
+func (python) Lang() string  { return "python" }
+func (python) Label() string { return "Python" }
+
+func (python) Generate(req *Request) string {
+	var b strings.Builder
+
+	b.WriteString("import requests\n\n")
+	fmt.Fprintf(&b, "url = %s\n", quote(req.URL))
+
+	args := []string{quote(req.Method), "url"}
+
+	if len(req.Headers) > 0 {
+		b.WriteString("headers = {\n")
+
+		for _, h := range req.Headers {
+			fmt.Fprintf(&b, "    %s: %s,\n", quote(h.Name), quote(h.Value))
+		}
+
+		b.WriteString("}\n")
+
+		args = append(args, "headers=headers")
+	}
+
+	switch {
+	case req.Body != "":
+		fmt.Fprintf(&b, "data = %s\n", pythonString(req.Body))
+
+		args = append(args, "data=data")
+	case req.Multipart:
+		// A field without a file name is sent as a plain multipart field.
+		b.WriteString("files = {\n")
+
+		for _, f := range req.Form {
+			if f.IsFile {
+				fmt.Fprintf(&b, "    %s: open(%s, \"rb\"),\n", quote(f.Name), quote(f.Value))
+			} else {
+				fmt.Fprintf(&b, "    %s: (None, %s),\n", quote(f.Name), quote(f.Value))
+			}
+		}
+
+		b.WriteString("}\n")
+
+		args = append(args, "files=files")
+	case len(req.Form) > 0:
+		b.WriteString("data = {\n")
+
+		for _, f := range req.Form {
+			fmt.Fprintf(&b, "    %s: %s,\n", quote(f.Name), quote(f.Value))
+		}
+
+		b.WriteString("}\n")
+
+		args = append(args, "data=data")
+	}
+
+	fmt.Fprintf(&b, "\nresponse = requests.request(%s)\n", strings.Join(args, ", "))
+	b.WriteString("print(response.status_code, response.text)\n")
+
+	return b.String()
+}
+
+// pythonString returns s as a raw triple quoted string literal, unless it holds triple quotes.
+func pythonString(s string) string {
+	if strings.Contains(s, "'''") || strings.HasSuffix(s, "'") || strings.HasSuffix(s, `\`) {
+		return quote(s)
+	}
+
+	return "r'''" + s + "'''"
+}
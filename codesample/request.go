@@ -0,0 +1,258 @@
+package codesample
+
+import (
+	"encoding/base64"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// Placeholder credentials given by the snippets of methods requiring authorisation.
+const (
+	APIKeyPlaceholder      = "YOUR_API_KEY"
+	AccessTokenPlaceholder = "YOUR_ACCESS_TOKEN"
+	UsernamePlaceholder    = "YOUR_USERNAME"
+	PasswordPlaceholder    = "YOUR_PASSWORD"
+	FilePlaceholder        = "path/to/file"
+)
+
+const (
+	formURLEncoded = "application/x-www-form-urlencoded"
+	formMultipart  = "multipart/form-data"
+	jsonMediaType  = "application/json"
+)
+
+// Header is a request header.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Field is a form field, holding the path of the file to upload when IsFile is set.
+type Field struct {
+	Name   string
+	Value  string
+	IsFile bool
+}
+
+// Request is the HTTP request made by the snippets of a method. Required parameters are given
+// placeholder values, derived from their type and name, and credentials are placeholders too.
+type Request struct {
+	Method    string // Method is the upper case HTTP method
+	URL       string // URL includes the path and query parameters
+	Headers   []Header
+	Body      string  // Body is the example of the body parameter, if any
+	Form      []Field // Form holds the form parameters, if any
+	Multipart bool    // Multipart is set when Form is sent as multipart/form-data
+}
+
+// NewRequest builds the request made by the snippets of a method of an API.
+func NewRequest(api spec.APIGroup, method spec.Method) *Request {
+	req := &Request{Method: strings.ToUpper(method.Method)}
+
+	path := method.Path
+	for _, p := range method.PathParams {
+		path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(Placeholder(p)))
+	}
+
+	var (
+		query   []string
+		cookies []string
+	)
+
+	for _, p := range required(method.QueryParams) {
+		query = append(query, url.QueryEscape(p.Name)+"="+url.QueryEscape(Placeholder(p)))
+	}
+
+	if len(method.Produces) > 0 {
+		req.Headers = append(req.Headers, Header{Name: "Accept", Value: method.Produces[0]})
+	}
+
+	for _, p := range required(method.HeaderParams) {
+		req.Headers = append(req.Headers, Header{Name: p.Name, Value: Placeholder(p)})
+	}
+
+	for _, p := range required(method.CookieParams) {
+		cookies = append(cookies, p.Name+"="+Placeholder(p))
+	}
+
+	// Security requirements are keyed by scheme type, sorted for stable snippets.
+	types := make([]string, 0, len(method.Security))
+	for t := range method.Security {
+		types = append(types, t)
+	}
+
+	sort.Strings(types)
+
+	authorised := false
+
+	for _, t := range types {
+		scheme := method.Security[t].Scheme
+		if scheme == nil {
+			continue
+		}
+
+		switch {
+		case scheme.IsAPIKey && scheme.ParamLocation == "query":
+			query = append(query, url.QueryEscape(scheme.ParamName)+"="+APIKeyPlaceholder)
+		case scheme.IsAPIKey && scheme.ParamLocation == "cookie":
+			cookies = append(cookies, scheme.ParamName+"="+APIKeyPlaceholder)
+		case scheme.IsAPIKey:
+			req.Headers = append(req.Headers, Header{Name: scheme.ParamName, Value: APIKeyPlaceholder})
+		case authorised:
+			// Only one scheme can use the Authorization header.
+		case scheme.IsBasic:
+			credentials := base64.StdEncoding.EncodeToString([]byte(UsernamePlaceholder + ":" + PasswordPlaceholder))
+			req.Headers = append(req.Headers, Header{Name: "Authorization", Value: "Basic " + credentials})
+			authorised = true
+		case scheme.IsBearer, scheme.IsOAuth2:
+			req.Headers = append(req.Headers, Header{Name: "Authorization", Value: "Bearer " + AccessTokenPlaceholder})
+			authorised = true
+		}
+	}
+
+	if len(cookies) > 0 {
+		req.Headers = append(req.Headers, Header{Name: "Cookie", Value: strings.Join(cookies, "; ")})
+	}
+
+	switch {
+	case method.BodyParam != nil:
+		req.Headers = append(req.Headers, Header{Name: "Content-Type", Value: BodyMediaType(method.Consumes)})
+		req.Body = BodyExample(method.BodyParam)
+	case len(method.FormParams) > 0:
+		req.Multipart = contains(method.Consumes, formMultipart)
+
+		for _, p := range required(method.FormParams) {
+			if IsFile(p) {
+				req.Multipart = true
+				req.Form = append(req.Form, Field{Name: p.Name, Value: FilePlaceholder, IsFile: true})
+
+				continue
+			}
+
+			req.Form = append(req.Form, Field{Name: p.Name, Value: Placeholder(p)})
+		}
+	}
+
+	req.URL = path
+	if api.URL != nil {
+		req.URL = api.URL.String() + path
+	}
+
+	if len(query) > 0 {
+		req.URL += "?" + strings.Join(query, "&")
+	}
+
+	return req
+}
+
+// IsJSON returns whether the body of the request is sent as JSON.
+func (r *Request) IsJSON() bool {
+	for _, h := range r.Headers {
+		if h.Name == "Content-Type" {
+			return strings.Contains(h.Value, "json")
+		}
+	}
+
+	return false
+}
+
+// required returns the required parameters, the only ones given in snippets.
+func required(params []spec.Parameter) []spec.Parameter {
+	var list []spec.Parameter
+
+	for _, p := range params {
+		if p.Required {
+			list = append(list, p)
+		}
+	}
+
+	return list
+}
+
+// Placeholder returns the value given to a parameter: its first enum value, a value of its type, or
+// its name in upper case.
+func Placeholder(p spec.Parameter) string {
+	if len(p.Enum) > 0 {
+		return p.Enum[0]
+	}
+
+	kind := ""
+	if len(p.Type) > 0 {
+		kind = p.Type[len(p.Type)-1] // the type of the items of an array
+	}
+
+	switch kind {
+	case "integer", "int32", "int64":
+		return "1"
+	case "number", "float", "double":
+		return "1.5"
+	case "boolean":
+		return "true"
+	}
+
+	return upperSnake(p.Name)
+}
+
+func upperSnake(name string) string {
+	var b strings.Builder
+
+	prev := rune(0)
+
+	for _, r := range name {
+		switch {
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			b.WriteRune('_')
+			b.WriteRune(r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToUpper(r))
+		default:
+			r = '_'
+			b.WriteRune(r)
+		}
+
+		prev = r
+	}
+
+	return b.String()
+}
+
+// BodyExample returns the example sent as a body parameter, built from the schema of its resource.
+func BodyExample(p *spec.Parameter) string {
+	if p.Resource == nil || p.Resource.Schema == "" {
+		return Placeholder(*p)
+	}
+
+	if p.IsArray {
+		return "[\n" + p.Resource.Schema + "\n]"
+	}
+
+	return p.Resource.Schema
+}
+
+// BodyMediaType returns the media type a body is sent as, JSON when a method consumes it.
+func BodyMediaType(consumes []string) string {
+	if len(consumes) == 0 || contains(consumes, jsonMediaType) {
+		return jsonMediaType
+	}
+
+	return consumes[0]
+}
+
+// IsFile returns whether a form parameter uploads a file.
+func IsFile(p spec.Parameter) bool {
+	return len(p.Type) > 0 && p.Type[0] == "file"
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
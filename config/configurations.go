@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -40,18 +41,60 @@ const (
 	SpecGroupings   = "spec.groupings"
 	ForceSpecList   = "force-specification-list"
 
+	// localization.
+	Locales = "locales"
+
+	// hot reload.
+	Watch         = "watch"
+	WatchDebounce = "watch.debounce"
+
 	// auto-discovery configs.
 	DiscoveryEnabled            = "discovery.enabled"
+	DiscoveryBackend            = "discovery.backend"
 	DiscoveryDomain             = "discovery.domain"
 	DiscoveryNamespace          = "discovery.namespace"
 	DiscoverySuffix             = "discovery.suffix"
 	DiscoveryInterval           = "discovery.interval"
 	DiscoveryInitialDelay       = "discovery.delay.initial"
 	DiscoverySpecLoadTimeout    = "discovery.spec.timeout"
+	DiscoverySpecPaths          = "discovery.spec.paths"
 	DiscoveryPeriodTime         = "discovery.periodtime"
 	DiscoveryServiceIgnoreList  = "discovery.ignorelist.services"
 	DiscoveryGroupingKey        = "discovery.grouping.key"
 	DiscoveryGroupingConverters = "discovery.grouping.converters"
+
+	// kubernetes discovery.
+	DiscoveryKubeconfig        = "discovery.kubeconfig"
+	DiscoveryKubeContext       = "discovery.context"
+	DiscoveryClusters          = "discovery.clusters"
+	DiscoveryNamespaces        = "discovery.namespaces"
+	DiscoveryNamespaceSelector = "discovery.namespaceselector"
+
+	// consul discovery.
+	DiscoveryConsulAddress    = "discovery.consul.address"
+	DiscoveryConsulToken      = "discovery.consul.token"
+	DiscoveryConsulDatacenter = "discovery.consul.datacenter"
+	DiscoveryConsulTag        = "discovery.consul.tag"
+	DiscoveryConsulMeta       = "discovery.consul.meta"
+	DiscoveryConsulWait       = "discovery.consul.wait"
+
+	// registry discovery.
+	DiscoveryRegistryFile = "discovery.registry.file"
+
+	// lint.
+	LintFormat = "lint-format"
+	LintRules  = "lint.rules"
+
+	// diff.
+	DiffFormat = "diff-format"
+
+	// robots.
+	RobotsDisallow = "robots.disallow"
+
+	// export.
+	ExportDir      = "export-dir"
+	ExportBasePath = "export-base-path"
+	ExportFormat   = "export-format"
 )
 
 var defaultConfigPaths = []string{
@@ -59,40 +102,46 @@ var defaultConfigPaths = []string{
 	"./",
 }
 
-// C holds a reference to struct instance for configurations used within template files.
-var C config
+// RegisterFlags defines the command line flags of the configurations on flags, such as
+// pflag.CommandLine, to be bound to the global configuration once parsed.
+func RegisterFlags(flags *pflag.FlagSet) {
+	flags.String(cfgDirKey, "", "Directory of config file")
+	flags.String(LogLevel, "info", "Logging level ('error', 'warn', 'info', 'debug', 'trace')")
+	flags.BoolP(Version, "V", false, "Display version")
 
-type config struct {
-	ShowAssets bool `mapstructure:"author-show-assets"`
-}
+	flags.String(BindAddr, "localhost:3123", "Bind address")
+	flags.String(TLSCert, "", "The fully qualified path to the TLS certificate file. For HTTP over TLS (HTTPS) both a certificate and a key must be provided")
+	flags.String(TLSKey, "", "The fully qualified path to the TLS private key file. For HTTP over TLS (HTTPS) both a certificate and a key must be provided")
+	flags.String(SiteURL, "http://localhost:3123/", "Public URL of the documentation service")
 
-func init() {
-	pflag.String(cfgDirKey, "", "Directory of config file")
-	pflag.String(LogLevel, "info", "Logging level ('error', 'warn', 'info', 'debug', 'trace')")
-	pflag.BoolP(Version, "V", false, "Display version")
+	flags.String(DefaultAssetsDir, "assets", "Default assets directory")
+	flags.String(AssetsDir, "", "Assets to serve. Effectively the document root")
+	flags.Bool(ShowAssets, false, "Display at the foot of each page the overlay asset paths, in priority order, to check before rendering")
 
-	pflag.String(BindAddr, "localhost:3123", "Bind address")
-	pflag.String(TLSCert, "", "The fully qualified path to the TLS certificate file. For HTTP over TLS (HTTPS) both a certificate and a key must be provided")
-	pflag.String(TLSKey, "", "The fully qualified path to the TLS private key file. For HTTP over TLS (HTTPS) both a certificate and a key must be provided")
-	pflag.String(SiteURL, "http://localhost:3123/", "Public URL of the documentation service")
+	flags.String(Theme, "default", "Theme to render documentation")
+	flags.String(ThemeDir, "", "Directory containing installed themes")
 
-	pflag.String(DefaultAssetsDir, "assets", "Default assets directory")
-	pflag.String(AssetsDir, "", "Assets to serve. Effectively the document root")
-	pflag.Bool(ShowAssets, false, "Display at the foot of each page the overlay asset paths, in priority order, to check before rendering")
+	flags.String(SpecDir, "", "OpenAPI specification (swagger) directory")
+	flags.StringSlice(SpecFilename, []string{}, "The filename of the OpenAPI specification file within the spec-dir. May be multiply defined.")
+	flags.Bool(ForceSpecList, false,
+		"Force the homepage to be the summary list of available specifications. The default when serving a single OpenAPI specification is to make the homepage the API summary.")
 
-	pflag.String(Theme, "default", "Theme to render documentation")
-	pflag.String(ThemeDir, "", "Directory containing installed themes")
+	flags.StringSlice(Locales, []string{}, "Locales the documentation is served in, such as en,fr,de. The first is the default locale")
 
-	pflag.String(SpecDir, "", "OpenAPI specification (swagger) directory")
-	pflag.StringSlice(SpecFilename, []string{}, "The filename of the OpenAPI specification file within the spec-dir. May be multiply defined.")
-	pflag.Bool(ForceSpecList, false,
-		"Force the homepage to be the summary list of available specifications. The default when serving a single OpenAPI specification is to make the homepage the API summary.")
+	flags.Bool(Watch, false, "Reload local specifications, assets and theme when their files change")
 
-	initialize()
+	flags.String(LintFormat, "text", "Output format of the lint command ('text', 'json', 'junit')")
+	flags.String(DiffFormat, "markdown", "Output format of the diff command ('markdown', 'json')")
+	flags.String(ExportDir, "site", "Directory the export command writes the documentation to")
+	flags.String(ExportBasePath, "/", "Base path the documentation written by the export command is published under")
+	flags.String(ExportFormat, "site", "Output format of the export command ('site', 'print', 'markdown', 'postman')")
 }
 
-// Init performs the initialization of the configurations via configuration file when found.
+// Init performs the initialization of the global configuration from the defaults, the environment, and
+// the configuration file when found.
 func Init() {
+	initialize()
+
 	viper.SetConfigName("config")
 
 	confDir := viper.GetString(cfgDirKey)
@@ -105,20 +154,27 @@ func Init() {
 	}
 
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Printf("Using config: %s\n", viper.ConfigFileUsed())
+		// Written to stderr so that it never mixes with the output of commands such as lint or diff.
+		fmt.Fprintf(os.Stderr, "Using config: %s\n", viper.ConfigFileUsed())
 	}
+}
 
-	if err := viper.Unmarshal(&C); err != nil {
-		panic(err)
-	}
+// New returns a configuration holding only the defaults, independent of the global one set up
+// from the command line, the environment and the configuration file.
+func New() *viper.Viper {
+	v := viper.New()
+	setDefaults(v)
+
+	return v
 }
 
-// LoadFixture will load test fixture configuration; for testing only!
-func LoadFixture(dir string) error {
-	viper.SetConfigName("config")
-	viper.AddConfigPath(dir)
+// LoadFixture will load test fixture configuration into a new configuration; for testing only!
+func LoadFixture(dir string) (*viper.Viper, error) {
+	v := New()
+	v.SetConfigName("config")
+	v.AddConfigPath(dir)
 
-	return viper.ReadInConfig()
+	return v, v.ReadInConfig()
 }
 
 // Restore will reset viper and re-initialize back to the default configurations
@@ -128,19 +184,32 @@ func Restore() {
 	initialize()
 }
 
-func initialize() {
-	viper.SetDefault(AllowOrigin, []string{"*"})
+func setDefaults(v *viper.Viper) {
+	v.SetDefault(AllowOrigin, []string{"*"})
 
-	viper.SetDefault(SpecFilename, []string{"/swagger.json"})
-	viper.SetDefault(SpecDefaultHost, "127.0.0.1")
+	v.SetDefault(Theme, "default")
 
-	viper.SetDefault(DiscoveryDomain, "svc.cluster.local")
-	viper.SetDefault(DiscoveryNamespace, "default")
-	viper.SetDefault(DiscoverySuffix, "cluster.local")
-	viper.SetDefault(DiscoveryInterval, "10s")
-	viper.SetDefault(DiscoveryInitialDelay, "5s")
-	viper.SetDefault(DiscoverySpecLoadTimeout, "5s")
-	viper.SetDefault(DiscoveryPeriodTime, "30s")
+	v.SetDefault(SpecFilename, []string{"/swagger.json"})
+	v.SetDefault(SpecDefaultHost, "127.0.0.1")
+
+	v.SetDefault(WatchDebounce, "500ms")
+
+	v.SetDefault(DiscoveryBackend, "kubernetes")
+	v.SetDefault(DiscoveryDomain, "svc.cluster.local")
+	v.SetDefault(DiscoveryNamespace, "default")
+	v.SetDefault(DiscoverySuffix, "cluster.local")
+	v.SetDefault(DiscoveryInterval, "10s")
+	v.SetDefault(DiscoveryInitialDelay, "5s")
+	v.SetDefault(DiscoverySpecLoadTimeout, "5s")
+	v.SetDefault(DiscoverySpecPaths, []string{"/swagger.json", "/openapi.json", "/openapi.yaml", "/v3/api-docs", "/v2/api-docs"})
+	v.SetDefault(DiscoveryPeriodTime, "30s")
+
+	v.SetDefault(DiscoveryConsulAddress, "http://127.0.0.1:8500")
+	v.SetDefault(DiscoveryConsulWait, "5m")
+}
+
+func initialize() {
+	setDefaults(viper.GetViper())
 
 	_ = viper.BindEnv(cfgDirKey, "CONFIG_DIR")
 	_ = viper.BindEnv(LogLevel, "LOGLEVEL")
@@ -162,5 +231,30 @@ func initialize() {
 	_ = viper.BindEnv(SpecDefaultHost, "SPEC_DEFAULT_HOST")
 	_ = viper.BindEnv(ForceSpecList, "FORCE_SPECIFICATION_LIST")
 
+	_ = viper.BindEnv(Locales, "LOCALES")
+
+	_ = viper.BindEnv(Watch, "WATCH")
+	_ = viper.BindEnv(WatchDebounce, "WATCH_DEBOUNCE")
+
+	_ = viper.BindEnv(DiscoveryBackend, "DISCOVERY_BACKEND")
 	_ = viper.BindEnv(DiscoveryNamespace, "POD_NAMESPACE")
+	_ = viper.BindEnv(DiscoveryKubeconfig, "KUBECONFIG")
+	_ = viper.BindEnv(DiscoveryNamespaces, "DISCOVERY_NAMESPACES")
+	_ = viper.BindEnv(DiscoveryNamespaceSelector, "DISCOVERY_NAMESPACE_SELECTOR")
+
+	_ = viper.BindEnv(DiscoveryConsulAddress, "CONSUL_HTTP_ADDR")
+	_ = viper.BindEnv(DiscoveryConsulToken, "CONSUL_HTTP_TOKEN")
+
+	_ = viper.BindEnv(DiscoveryRegistryFile, "DISCOVERY_REGISTRY_FILE")
+
+	_ = viper.BindEnv(LintFormat, "LINT_FORMAT")
+	_ = viper.BindEnv(LintRules, "LINT_RULES")
+
+	_ = viper.BindEnv(DiffFormat, "DIFF_FORMAT")
+
+	_ = viper.BindEnv(RobotsDisallow, "ROBOTS_DISALLOW")
+
+	_ = viper.BindEnv(ExportDir, "EXPORT_DIR")
+	_ = viper.BindEnv(ExportBasePath, "EXPORT_BASE_PATH")
+	_ = viper.BindEnv(ExportFormat, "EXPORT_FORMAT")
 }
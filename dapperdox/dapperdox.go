@@ -0,0 +1,299 @@
+// Package dapperdox serves the documentation of OpenAPI specifications from within another Go program,
+// as an http.Handler configured by Options rather than by the command line, environment and
+// configuration file of the dapperdox command.
+package dapperdox
+
+import (
+	"time"
+
+	wraperrors "github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/discover"
+	"github.com/kenjones-cisco/dapperdox/handlers"
+)
+
+// Options configures the documentation served by a Handler. Zero values take the defaults of the
+// dapperdox command.
+type Options struct {
+	// SpecDir is the directory of the local specifications.
+	SpecDir string
+	// SpecFilenames are the local specifications, as files within SpecDir or URLs. Defaults to /swagger.json.
+	SpecFilenames []string
+	// SpecDefaultHost is the host of the APIs whose specification declares none. Defaults to 127.0.0.1.
+	SpecDefaultHost string
+	// SpecRewriteURL maps the URLs to rewrite in the specifications to their replacement, or to SiteURL
+	// when mapped to an empty string.
+	SpecRewriteURL map[string]string
+	// ForceSpecList makes the home page the list of specifications, even when there is only one.
+	ForceSpecList bool
+	// Locales are the locales the documentation is served in, the first being the default locale.
+	// Defaults to the single locale of the theme.
+	Locales []string
+
+	// DefaultAssetsDir is the directory of the assets and themes shipped with dapperdox. Required.
+	DefaultAssetsDir string
+	// AssetsDir is the directory of the assets overlaying the default ones, such as guides.
+	AssetsDir string
+	// DocumentRewriteURL maps the URLs to rewrite in the assets to their replacement.
+	DocumentRewriteURL map[string]string
+	// ShowAssets displays at the foot of each page the overlay asset paths checked before rendering it.
+	ShowAssets bool
+
+	// Theme is the theme the documentation is rendered with. Defaults to default.
+	Theme string
+	// ThemeDir is the directory of the installed themes. Defaults to the themes of DefaultAssetsDir.
+	ThemeDir string
+
+	// SiteURL is the public URL of the documentation, which the sitemap and canonical links start with.
+	SiteURL string
+	// RobotsDisallow are the paths robots.txt disallows. Defaults to none.
+	RobotsDisallow []string
+	// ProxyPaths maps the path prefixes to proxy to their target URL.
+	ProxyPaths map[string]string
+	// AllowOrigins are the origins allowed to make cross-origin requests. Defaults to all.
+	AllowOrigins []string
+	// TLSCertificate and TLSKey are the files the documentation is served over TLS with, by the caller.
+	// When both are set, responses require browsers to keep to HTTPS.
+	TLSCertificate string
+	TLSKey         string
+
+	// Watch reloads the local specifications, assets and theme when their files change.
+	Watch bool
+	// WatchDebounce is the period changes are gathered for before reloading. Defaults to 500ms.
+	WatchDebounce time.Duration
+
+	// Discovery documents the specifications of discovered services instead of the local ones.
+	Discovery DiscoveryOptions
+}
+
+// DiscoveryOptions configures the discovery of the specifications served by services.
+type DiscoveryOptions struct {
+	// Enabled documents the specifications of discovered services.
+	Enabled bool
+	// Manager discovers the specifications, and is run and shut down by the caller. When not set, the
+	// services of the backend are discovered.
+	Manager discover.DiscoveryManager
+	// Backend is the backend the services are discovered from, kubernetes for the Kubernetes cluster
+	// dapperdox runs within, consul for a Consul catalog, or registry for a registry file. Defaults to
+	// kubernetes.
+	Backend string
+	// Consul configures the discovery of the services of a Consul catalog.
+	Consul ConsulOptions
+	// Registry is the YAML or JSON file listing the services discovered by the registry backend.
+	Registry string
+
+	// Namespace is the Kubernetes namespace watched for services. Defaults to default.
+	Namespace string
+	// Namespaces are the Kubernetes namespaces watched instead of Namespace, "*" standing for all of them.
+	// The specs of the services are labelled by their namespace when several are watched.
+	Namespaces []string
+	// NamespaceSelector is the label selector of the Kubernetes namespaces watched, instead of Namespace.
+	NamespaceSelector string
+	// Kubeconfig is the kubeconfig file, or list of files, of the Kubernetes cluster watched, and Context
+	// the context it is accessed with. The cluster dapperdox runs within is watched when neither is set.
+	Kubeconfig string
+	Context    string
+	// Clusters are the Kubernetes clusters watched instead of a single one, the specs of the services of
+	// each being labelled by its name.
+	Clusters []ClusterOptions
+	// DomainSuffix is the domain suffix of the Kubernetes cluster. Defaults to cluster.local.
+	DomainSuffix string
+	// Interval is the period the Kubernetes services are resynchronized at, and the specs of the services
+	// of a registry fetched again at. Defaults to 10s.
+	Interval time.Duration
+	// SpecLoadTimeout is the time allowed to fetch the specification of a service. Defaults to 5s.
+	SpecLoadTimeout time.Duration
+	// SpecPaths are the paths probed, in order, for the specification of a service not giving its path.
+	// Defaults to /swagger.json, /openapi.json, /openapi.yaml, /v3/api-docs and /v2/api-docs.
+	SpecPaths []string
+	// IgnoreServices are the prefixes of the names of the services not to document.
+	IgnoreServices []string
+	// GroupingKey is the extension of the specifications whose value, mapped by GroupingConverters,
+	// groups them on the home page.
+	GroupingKey        string
+	GroupingConverters map[string]string
+	// SpecGroupings maps the tags of the specifications to the group they are listed in.
+	SpecGroupings map[string]string
+	// SpecRewrites is the file of the security, schemes and extensions that replace those of each
+	// discovered specification.
+	SpecRewrites string
+
+	// InitialDelay is the time waited for services to be discovered before the first update of the
+	// documentation. Defaults to 5s.
+	InitialDelay time.Duration
+	// Period is the period the documentation is updated at, when discovered services changed. Defaults to 30s.
+	Period time.Duration
+}
+
+// ClusterOptions configures a Kubernetes cluster watched for services.
+type ClusterOptions struct {
+	// Name labels the services of the cluster, prefixing the IDs and groupings of their specs.
+	Name string `mapstructure:"name"`
+	// Kubeconfig is the kubeconfig file of the cluster. Defaults to the Kubeconfig of the discovery.
+	Kubeconfig string `mapstructure:"kubeconfig"`
+	// Context is the context of the kubeconfig the cluster is accessed with. Defaults to its current
+	// context.
+	Context string `mapstructure:"context"`
+}
+
+// ConsulOptions configures the discovery of the services registered in a Consul catalog.
+type ConsulOptions struct {
+	// Address is the address of the HTTP API of the Consul agent. Defaults to http://127.0.0.1:8500.
+	Address    string
+	Token      string
+	Datacenter string
+	// Tag selects the services carrying it.
+	Tag string
+	// Meta selects the services whose metadata has each of its values.
+	Meta map[string]string
+	// Wait is the longest time the catalog is watched for before querying it again. Defaults to 5m.
+	Wait time.Duration
+}
+
+// Handler is an http.Handler serving the documentation set up by Options, and rebuilding it on Reload.
+type Handler struct {
+	*handlers.LiveRouter
+
+	close func()
+}
+
+// New creates a Handler serving the documentation set up by opts. Its specifications and assets are loaded
+// before New returns, except for discovered specifications, which are documented once discovered.
+func New(opts Options) (*Handler, error) {
+	if opts.DefaultAssetsDir == "" {
+		return nil, wraperrors.New("the default assets directory is required")
+	}
+
+	cfg := opts.config()
+
+	if opts.Discovery.Enabled {
+		if opts.Watch {
+			log().Warn("Watching for changes is only supported for local specifications")
+		}
+
+		return newDiscovered(cfg, opts.Discovery.Manager), nil
+	}
+
+	if opts.Watch {
+		reloader, err := handlers.NewReloader(cfg)
+		if err != nil {
+			return nil, wraperrors.Wrap(err, "unable to watch for changes")
+		}
+
+		return &Handler{LiveRouter: reloader.LiveRouter, close: reloader.Close}, nil
+	}
+
+	return &Handler{LiveRouter: handlers.NewRouterChain(cfg), close: func() {}}, nil
+}
+
+// newDiscovered creates a Handler documenting the specifications discovered by d, or by a discoverer of
+// the Kubernetes services that it runs itself when d is nil.
+func newDiscovered(cfg *viper.Viper, d discover.DiscoveryManager) *Handler {
+	shutdown := func() {}
+
+	if d == nil {
+		var err error
+
+		d, err = discover.NewDiscoverer(cfg)
+		if err != nil {
+			log().Warnf("unable to create Discoverer: %v", err)
+
+			d = discover.NewDefaultDiscoverer()
+		}
+
+		go d.Run()
+
+		shutdown = d.Shutdown
+	}
+
+	updater := handlers.NewAutoDiscoverUpdater(cfg, d)
+
+	return &Handler{
+		LiveRouter: updater.Router(),
+		close: func() {
+			updater.Close()
+			shutdown()
+		},
+	}
+}
+
+// Close stops watching for changes and discovering specifications.
+func (h *Handler) Close() {
+	h.close()
+}
+
+// config returns a configuration holding the options, independent of the global one.
+func (o *Options) config() *viper.Viper {
+	cfg := config.New()
+
+	set := func(key string, value interface{}, isSet bool) {
+		if isSet {
+			cfg.Set(key, value)
+		}
+	}
+
+	set(config.SpecDir, o.SpecDir, o.SpecDir != "")
+	set(config.SpecFilename, o.SpecFilenames, len(o.SpecFilenames) > 0)
+	set(config.SpecDefaultHost, o.SpecDefaultHost, o.SpecDefaultHost != "")
+	set(config.SpecRewriteURL, o.SpecRewriteURL, len(o.SpecRewriteURL) > 0)
+	set(config.ForceSpecList, o.ForceSpecList, o.ForceSpecList)
+	set(config.Locales, o.Locales, len(o.Locales) > 0)
+
+	set(config.DefaultAssetsDir, o.DefaultAssetsDir, true)
+	set(config.AssetsDir, o.AssetsDir, o.AssetsDir != "")
+	set(config.DocumentRewriteURL, o.DocumentRewriteURL, len(o.DocumentRewriteURL) > 0)
+	set(config.ShowAssets, o.ShowAssets, o.ShowAssets)
+
+	set(config.Theme, o.Theme, o.Theme != "")
+	set(config.ThemeDir, o.ThemeDir, o.ThemeDir != "")
+
+	set(config.SiteURL, o.SiteURL, o.SiteURL != "")
+	set(config.RobotsDisallow, o.RobotsDisallow, len(o.RobotsDisallow) > 0)
+	set(config.ProxyPath, o.ProxyPaths, len(o.ProxyPaths) > 0)
+	set(config.AllowOrigin, o.AllowOrigins, len(o.AllowOrigins) > 0)
+	set(config.TLSCert, o.TLSCertificate, o.TLSCertificate != "")
+	set(config.TLSKey, o.TLSKey, o.TLSKey != "")
+
+	set(config.Watch, o.Watch, o.Watch)
+	set(config.WatchDebounce, o.WatchDebounce, o.WatchDebounce > 0)
+
+	d := o.Discovery
+	set(config.DiscoveryEnabled, d.Enabled, d.Enabled)
+	set(config.DiscoveryBackend, d.Backend, d.Backend != "")
+	set(config.DiscoveryRegistryFile, d.Registry, d.Registry != "")
+	set(config.DiscoveryNamespace, d.Namespace, d.Namespace != "")
+	set(config.DiscoveryNamespaces, d.Namespaces, len(d.Namespaces) > 0)
+	set(config.DiscoveryNamespaceSelector, d.NamespaceSelector, d.NamespaceSelector != "")
+	set(config.DiscoveryKubeconfig, d.Kubeconfig, d.Kubeconfig != "")
+	set(config.DiscoveryKubeContext, d.Context, d.Context != "")
+
+	clusters := make([]interface{}, 0, len(d.Clusters))
+	for _, c := range d.Clusters {
+		clusters = append(clusters, map[string]interface{}{"name": c.Name, "kubeconfig": c.Kubeconfig, "context": c.Context})
+	}
+
+	set(config.DiscoveryClusters, clusters, len(clusters) > 0)
+	set(config.DiscoverySuffix, d.DomainSuffix, d.DomainSuffix != "")
+	set(config.DiscoveryInterval, d.Interval, d.Interval > 0)
+	set(config.DiscoverySpecLoadTimeout, d.SpecLoadTimeout, d.SpecLoadTimeout > 0)
+	set(config.DiscoverySpecPaths, d.SpecPaths, len(d.SpecPaths) > 0)
+	set(config.DiscoveryServiceIgnoreList, d.IgnoreServices, len(d.IgnoreServices) > 0)
+	set(config.DiscoveryGroupingKey, d.GroupingKey, d.GroupingKey != "")
+	set(config.DiscoveryGroupingConverters, d.GroupingConverters, len(d.GroupingConverters) > 0)
+	set(config.SpecGroupings, d.SpecGroupings, len(d.SpecGroupings) > 0)
+	set(config.SpecRewrites, d.SpecRewrites, d.SpecRewrites != "")
+	set(config.DiscoveryInitialDelay, d.InitialDelay, d.InitialDelay > 0)
+	set(config.DiscoveryPeriodTime, d.Period, d.Period > 0)
+
+	c := d.Consul
+	set(config.DiscoveryConsulAddress, c.Address, c.Address != "")
+	set(config.DiscoveryConsulToken, c.Token, c.Token != "")
+	set(config.DiscoveryConsulDatacenter, c.Datacenter, c.Datacenter != "")
+	set(config.DiscoveryConsulTag, c.Tag, c.Tag != "")
+	set(config.DiscoveryConsulMeta, c.Meta, len(c.Meta) > 0)
+	set(config.DiscoveryConsulWait, c.Wait, c.Wait > 0)
+
+	return cfg
+}
@@ -0,0 +1,196 @@
+package dapperdox
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	testSpecDir     = "../fixtures/"
+	testAssetsDir   = "../assets"
+	testPetstoreDir = "../examples/specifications/petstore"
+	testOverlayDir  = "../examples/overlay/assets"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      Options
+		wantErr   bool
+		path      string
+		wantCode  int
+		wantMatch string
+	}{
+		{
+			name:    "missing default assets",
+			opts:    Options{SpecDir: testSpecDir, SpecFilenames: []string{"common_api.json"}},
+			wantErr: true,
+		},
+		{
+			name: "local specification",
+			opts: Options{
+				SpecDir:          testSpecDir,
+				SpecFilenames:    []string{"common_api.json"},
+				DefaultAssetsDir: testAssetsDir,
+			},
+			path:      "/aws-service/reference",
+			wantCode:  http.StatusOK,
+			wantMatch: "List Accounts",
+		},
+		{
+			name: "specification list forced",
+			opts: Options{
+				SpecDir:          testSpecDir,
+				SpecFilenames:    []string{"common_api.json"},
+				DefaultAssetsDir: testAssetsDir,
+				ForceSpecList:    true,
+			},
+			path:      "/",
+			wantCode:  http.StatusOK,
+			wantMatch: "AWS Service",
+		},
+		{
+			name: "overlay assets",
+			opts: Options{
+				SpecDir:          testPetstoreDir,
+				DefaultAssetsDir: testAssetsDir,
+				AssetsDir:        testOverlayDir,
+				ShowAssets:       true,
+			},
+			path:      "/swagger-petstore/reference",
+			wantCode:  http.StatusOK,
+			wantMatch: "debug_body",
+		},
+		{
+			name: "specification URLs rewritten",
+			opts: Options{
+				SpecDir:          testPetstoreDir,
+				DefaultAssetsDir: testAssetsDir,
+				SpecRewriteURL:   map[string]string{"petstore.swagger.io": "petstore.example.com"},
+			},
+			path:      "/swagger.json",
+			wantCode:  http.StatusOK,
+			wantMatch: "petstore.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := New(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+			defer h.Close()
+
+			assertServed(t, h, tt.path, tt.wantCode, tt.wantMatch)
+		})
+	}
+}
+
+func TestNew_Independent(t *testing.T) {
+	common, err := New(Options{SpecDir: testSpecDir, SpecFilenames: []string{"common_api.json"}, DefaultAssetsDir: testAssetsDir})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer common.Close()
+
+	petstore, err := New(Options{SpecDir: testPetstoreDir, DefaultAssetsDir: testAssetsDir, ShowAssets: true})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer petstore.Close()
+
+	assertServed(t, common, "/aws-service/reference", http.StatusOK, "List Accounts")
+	assertServed(t, common, "/swagger-petstore/reference", http.StatusNotFound, "")
+	assertServed(t, petstore, "/swagger-petstore/reference", http.StatusOK, "debug_body")
+	assertServed(t, petstore, "/aws-service/reference", http.StatusNotFound, "")
+
+	rec := httptest.NewRecorder()
+	common.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/aws-service/reference", nil))
+
+	if strings.Contains(rec.Body.String(), "debug_body") {
+		t.Error("options of one handler applied to another")
+	}
+}
+
+func TestNew_GlobalState(t *testing.T) {
+	// the flags of an embedding program do not clash with those of the dapperdox command
+	pflag.BoolP("version", "V", false, "Display the version of the embedding program")
+
+	h, err := New(Options{SpecDir: testSpecDir, SpecFilenames: []string{"common_api.json"}, DefaultAssetsDir: testAssetsDir})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer h.Close()
+
+	if keys := viper.AllKeys(); len(keys) > 0 {
+		t.Errorf("New() set the global configuration %v", keys)
+	}
+}
+
+func TestHandler_Reload(t *testing.T) {
+	specDir := t.TempDir()
+
+	spec, err := os.ReadFile(filepath.Join(testSpecDir, "common_api.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeSpec(t, specDir, string(spec))
+
+	h, err := New(Options{SpecDir: specDir, DefaultAssetsDir: testAssetsDir})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer h.Close()
+
+	writeSpec(t, specDir, strings.Replace(string(spec), `"List Accounts"`, `"List Reloaded Accounts"`, 1))
+
+	assertServed(t, h, "/aws-service/reference", http.StatusOK, "List Accounts")
+
+	if err := h.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	assertServed(t, h, "/aws-service/reference", http.StatusOK, "List Reloaded Accounts")
+
+	writeSpec(t, specDir, `{"swagger": `)
+
+	if err := h.Reload(); err == nil {
+		t.Error("Reload() of an invalid specification error = nil, want error")
+	}
+
+	assertServed(t, h, "/aws-service/reference", http.StatusOK, "List Reloaded Accounts")
+}
+
+// assertServed checks the status of the response to a GET of path, and that its body contains wantMatch.
+func assertServed(t *testing.T, h http.Handler, path string, wantCode int, wantMatch string) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	body, _ := io.ReadAll(rec.Body)
+	if rec.Code != wantCode || !strings.Contains(string(body), wantMatch) {
+		t.Errorf("GET %s = %d, want %d containing %q", path, rec.Code, wantCode, wantMatch)
+	}
+}
+
+func writeSpec(t *testing.T, dir, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, "swagger.json"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
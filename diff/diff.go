@@ -0,0 +1,55 @@
+// Package diff provides the diff command, reporting the changes between two revisions of an API specification.
+package diff
+
+import (
+	"io"
+
+	"github.com/spf13/viper"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// Command compares the old and new revisions of a specification, given as file paths or URLs, and
+// writes the changes in the format configured by cfg. It returns the process exit code: 0 if no breaking
+// changes were found, 1 if any were, and 2 if the revisions could not be compared.
+func Command(cfg *viper.Viper, w io.Writer, oldLocation, newLocation string) int {
+	if oldLocation == "" || newLocation == "" {
+		log().Error("diff requires the locations of the old and new revisions of a specification")
+
+		return 2
+	}
+
+	writer, ok := writers[cfg.GetString(config.DiffFormat)]
+	if !ok {
+		log().Errorf("unknown diff format %q", cfg.GetString(config.DiffFormat))
+
+		return 2
+	}
+
+	oldSpec := spec.LoadSpecification(cfg, oldLocation)
+	newSpec := spec.LoadSpecification(cfg, newLocation)
+
+	// Methods that failed to load would be reported as removed or added, so refuse to guess.
+	if len(oldSpec.Errors) > 0 || len(newSpec.Errors) > 0 {
+		for _, err := range append(oldSpec.Errors, newSpec.Errors...) {
+			log().Error(err)
+		}
+
+		return 2
+	}
+
+	d := spec.Compare(oldSpec, newSpec)
+
+	if err := writer(w, d); err != nil {
+		log().Errorf("unable to write diff report: %s", err)
+
+		return 2
+	}
+
+	if len(d.Breaking()) > 0 {
+		return 1
+	}
+
+	return 0
+}
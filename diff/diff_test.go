@@ -0,0 +1,100 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+)
+
+const testSpecDir = "../fixtures/"
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		old    string
+		new    string
+		format string
+		want   int
+		check  func(t *testing.T, out []byte)
+	}{
+		{
+			name:   "markdown report with breaking changes",
+			old:    "diff_v1_api.json",
+			new:    "diff_v2_api.json",
+			format: "markdown",
+			want:   1,
+			check: func(t *testing.T, out []byte) {
+				if !strings.Contains(string(out), "| orders | delete-order |  |  | operation DELETE /v1/orders/{id} was removed |") {
+					t.Errorf("output = %s, want removed operation", out)
+				}
+
+				if !strings.Contains(string(out), "14 breaking change(s), 9 non-breaking change(s)") {
+					t.Errorf("output = %s, want summary", out)
+				}
+			},
+		},
+		{
+			name:   "json report without changes",
+			old:    "diff_v2_api.json",
+			new:    "diff_v2_api.json",
+			format: "json",
+			want:   0,
+			check: func(t *testing.T, out []byte) {
+				var r struct {
+					Changes  []interface{} `json:"changes"`
+					Breaking int           `json:"breaking"`
+				}
+				if err := json.Unmarshal(out, &r); err != nil {
+					t.Fatalf("invalid JSON output: %v", err)
+				}
+
+				if r.Changes == nil || len(r.Changes) != 0 || r.Breaking != 0 {
+					t.Errorf("report = %+v, want no changes", r)
+				}
+			},
+		},
+		{
+			name:   "unknown format",
+			old:    "diff_v1_api.json",
+			new:    "diff_v2_api.json",
+			format: "html",
+			want:   2,
+		},
+		{
+			name:   "missing revision",
+			old:    "diff_v1_api.json",
+			format: "markdown",
+			want:   2,
+		},
+		{
+			name:   "revision with load errors",
+			old:    "diff_v1_api.json",
+			new:    "invalid_api.json",
+			format: "markdown",
+			want:   2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Set(config.DiffFormat, tt.format)
+
+			newLocation := ""
+			if tt.new != "" {
+				newLocation = testSpecDir + tt.new
+			}
+
+			var out bytes.Buffer
+			if got := Command(cfg, &out, testSpecDir+tt.old, newLocation); got != tt.want {
+				t.Fatalf("Command() = %d, want %d", got, tt.want)
+			}
+
+			if tt.check != nil {
+				tt.check(t, out.Bytes())
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// writers holds the report writer of each output format.
+var writers = map[string]func(w io.Writer, d *spec.Diff) error{
+	"markdown": writeMarkdown,
+	"json":     writeJSON,
+}
+
+var cellEscaper = strings.NewReplacer("|", "\\|", "\n", " ")
+
+// writeMarkdown reports the breaking and non-breaking changes as two tables.
+func writeMarkdown(w io.Writer, d *spec.Diff) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Changes from %s to %s\n", d.Old, d.New)
+
+	var breaking, nonBreaking []spec.Change
+
+	for _, c := range d.Changes {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		} else {
+			nonBreaking = append(nonBreaking, c)
+		}
+	}
+
+	writeTable(&b, "Breaking changes", breaking)
+	writeTable(&b, "Non-breaking changes", nonBreaking)
+
+	fmt.Fprintf(&b, "\n%d breaking change(s), %d non-breaking change(s)\n", len(breaking), len(nonBreaking))
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func writeTable(b *strings.Builder, title string, changes []spec.Change) {
+	fmt.Fprintf(b, "\n## %s\n\n", title)
+
+	if len(changes) == 0 {
+		b.WriteString("None.\n")
+
+		return
+	}
+
+	b.WriteString("| API | Method | Resource | Location | Change |\n")
+	b.WriteString("|-----|--------|----------|----------|--------|\n")
+
+	for _, c := range changes {
+		fmt.Fprintf(b, "| %s | %s | %s | %s | %s |\n",
+			cellEscaper.Replace(c.API), cellEscaper.Replace(c.Method), cellEscaper.Replace(c.Resource),
+			cellEscaper.Replace(c.Location), cellEscaper.Replace(c.Message))
+	}
+}
+
+func writeJSON(w io.Writer, d *spec.Diff) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(struct {
+		*spec.Diff
+		Breaking int `json:"breaking"`
+	}{d, len(d.Breaking())})
+}
@@ -2,9 +2,12 @@ package discover
 
 import (
 	"encoding/json"
-	"fmt"
+	"net"
 	"net/url"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-openapi/loads"
 	"github.com/go-openapi/spec"
@@ -13,6 +16,9 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/discover/models"
+	"github.com/kenjones-cisco/dapperdox/i18n"
+	"github.com/kenjones-cisco/dapperdox/spec/openapi3"
 )
 
 const (
@@ -22,8 +28,9 @@ const (
 	groupByDefault = "Common APIs"
 )
 
+// fetchAPISpecs returns the processed specs of the services, nil when they cannot be processed.
 func (d *Discoverer) fetchAPISpecs() map[string][]byte {
-	rwd, err := loadRewritesDoc()
+	rwd, err := loadRewritesDoc(d.cfg.GetString(config.SpecRewrites))
 	if err != nil {
 		log().WithError(err).Error("unable to load rewrites doc")
 
@@ -33,47 +40,55 @@ func (d *Discoverer) fetchAPISpecs() map[string][]byte {
 	newSpecs := make(map[string][]byte)
 
 	for _, service := range d.data.services.List() {
-		if service.Hostname == "" || isIgnoredSvc(service.Hostname) {
+		if service.Hostname == "" || isIgnoredSvc(d.cfg, service.Hostname) {
 			log().Warnf("invalid service %q", service.Hostname)
 
 			continue
 		}
 
-		for _, port := range service.Ports {
-			if !port.Protocol.IsHTTP() {
-				continue
-			}
+		if service.Excluded {
+			log().Debugf("skipping service %q excluded from the documentation", service.Hostname)
+
+			continue
+		}
 
-			hostName := service.Hostname
-			portNum := port.Port
+		// the spec of a service is the first found at the sources probed
+		var lastErr error
 
-			path, data, err := handleSpec(rwd, hostName, portNum)
+		for _, source := range specSources(service, d.cfg.GetStringSlice(config.DiscoverySpecPaths)) {
+			path, data, err := handleSpec(d.cfg, rwd, service, source)
 			if err != nil {
-				log().WithError(err).Errorf("unable to load and process spec from [%s:%d]", hostName, portNum)
+				log().WithError(err).Debugf("no spec loaded from [%s]", source)
+
+				lastErr = err
 
 				continue
 			}
 
 			newSpecs[path] = data
+			lastErr = nil
+
+			break
 		}
-	}
 
-	if len(newSpecs) > 0 {
-		return newSpecs
+		if lastErr != nil {
+			log().WithError(lastErr).Errorf("unable to load and process spec of service %q", service.Hostname)
+		}
 	}
 
-	return nil
+	// no specs are discovered once the last service is removed, which is not an error
+	return newSpecs
 }
 
-func loadRewritesDoc() (*loads.Document, error) {
+func loadRewritesDoc(rewrites string) (*loads.Document, error) {
 	// if there are rewrite configuration defined,
 	// ensure we are able to load them
 	var rewritesDoc *loads.Document
 
-	if viper.GetString(config.SpecRewrites) != "" {
-		log().Info(viper.GetString(config.SpecRewrites))
+	if rewrites != "" {
+		log().Info(rewrites)
 
-		data, err := swag.YAMLDoc(viper.GetString(config.SpecRewrites))
+		data, err := swag.YAMLDoc(rewrites)
 		if err != nil {
 			return rewritesDoc, err
 		}
@@ -87,29 +102,132 @@ func loadRewritesDoc() (*loads.Document, error) {
 	return rewritesDoc, nil
 }
 
-func handleSpec(rwd *loads.Document, hostName string, portNum int) (string, []byte, error) {
-	svcSpec, err := loadSpec(fmt.Sprintf("%s:%d", hostName, portNum))
+// specSources returns the URLs, or local paths, the spec of a service is probed at, in order: its SpecURL
+// when set, or else its SpecPath, or each of the well-known paths when not set, at its SpecPort, or each
+// of its HTTP ports when not set.
+func specSources(service *models.Service, paths []string) []string {
+	if service.SpecURL != "" {
+		return []string{service.SpecURL}
+	}
+
+	if service.SpecPath != "" {
+		paths = []string{service.SpecPath}
+	}
+
+	var sources []string
+
+	for _, port := range service.Ports {
+		if service.SpecPort != "" {
+			if port.Name != service.SpecPort && strconv.Itoa(port.Port) != service.SpecPort {
+				continue
+			}
+		} else if !port.Protocol.IsHTTP() {
+			continue
+		}
+
+		for _, p := range paths {
+			sources = append(sources, specURL(service, port, p))
+		}
+	}
+
+	return sources
+}
+
+// specURL returns the URL of the spec a service serves at the path of a port.
+func specURL(service *models.Service, port *models.Port, specPath string) string {
+	host := service.Hostname
+	if service.SpecHost != "" {
+		host = service.SpecHost
+	}
+
+	scheme := service.SpecScheme
+	if scheme == "" {
+		scheme = "http"
+		if port.Protocol == models.ProtocolHTTPS {
+			scheme = "https"
+		}
+	}
+
+	u := &url.URL{Scheme: scheme, Host: net.JoinHostPort(host, strconv.Itoa(port.Port)), Path: specPath}
+
+	return u.String()
+}
+
+// handleSpec loads the spec from source and processes it as the spec of the service. A spec that
+// cannot be processed is reported as an error, so that it does not prevent the specs of other
+// services being served.
+func handleSpec(cfg *viper.Viper, rwd *loads.Document, service *models.Service, source string) (path string, data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log().WithField("stack", string(debug.Stack())).Errorf("panic processing spec [%s]: %v", source, r)
+
+			err = wraperrors.Errorf("unable to process spec: %v", r)
+		}
+	}()
+
+	var svcSpec *spec.Swagger
+
+	// the spec of a service not reachable directly is fetched by the backend it was discovered by
+	if service.Fetch != nil {
+		svcSpec, err = fetchSpec(source, service.Fetch)
+	} else {
+		svcSpec, err = loadSpec(source, cfg.GetDuration(config.DiscoverySpecLoadTimeout))
+	}
+
 	if err != nil {
 		return "", nil, err
 	}
 
-	return processSpec(hostName, rwd.Spec(), svcSpec)
+	// the rewrites are optional, the document is nil when not set
+	var rewritesSpec *spec.Swagger
+	if rwd != nil {
+		rewritesSpec = rwd.Spec()
+	}
+
+	return processSpec(cfg, service, rewritesSpec, svcSpec)
 }
 
-func loadSpec(location string) (*spec.Swagger, error) {
-	if location == "" {
+// loadSpec loads the spec at the URL, or local path, of source.
+func loadSpec(source string, timeout time.Duration) (*spec.Swagger, error) {
+	if source == "" {
 		return nil, wraperrors.New("api location has no value")
 	}
 
-	u := &url.URL{Host: location, Scheme: "http", Path: "swagger.json"}
+	log().Debugf("apiLoader location: %s", source)
+
+	data, err := swag.LoadFromFileOrHTTPWithTimeout(source, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSpec(data)
+}
 
-	log().Debugf("apiLoader location: %s", u.String())
+// fetchSpec loads the spec at the URL of source with fetch.
+func fetchSpec(source string, fetch func(source string) ([]byte, error)) (*spec.Swagger, error) {
+	log().Debugf("apiLoader location: %s (fetched by the discovery backend)", source)
 
-	data, err := swag.LoadFromFileOrHTTPWithTimeout(u.String(), viper.GetDuration(config.DiscoverySpecLoadTimeout))
+	data, err := fetch(source)
 	if err != nil {
 		return nil, err
 	}
 
+	return parseSpec(data)
+}
+
+// parseSpec parses the spec of data, in JSON or YAML, converting OpenAPI 3 specs.
+func parseSpec(data []byte) (*spec.Swagger, error) {
+	data, err := openapi3.ToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if openapi3.IsOpenAPI3(data) {
+		if data, err = openapi3.Convert(data); err != nil {
+			return nil, err
+		}
+	}
+
 	var doc *loads.Document
 
 	doc, err = loads.Analyzed(json.RawMessage(data), "")
@@ -120,28 +238,41 @@ func loadSpec(location string) (*spec.Swagger, error) {
 	return doc.Spec(), nil
 }
 
-func processSpec(hostname string, rewritesSpec, svcSpec *spec.Swagger) (string, []byte, error) {
+func processSpec(cfg *viper.Viper, service *models.Service, rewritesSpec, svcSpec *spec.Swagger) (string, []byte, error) {
 	if svcSpec == nil {
 		return "", nil, wraperrors.New("service spec should not be nil")
 	}
 
+	// the name the service is displayed by overrides the title of its spec
+	if service.DisplayName != "" {
+		if svcSpec.Info == nil {
+			svcSpec.Info = &spec.Info{}
+		}
+
+		svcSpec.Info.Title = service.DisplayName
+	}
+
 	removePrivateAPIs(svcSpec)
 
 	removePrivateDefinitions(svcSpec)
 
-	applyGrouping(svcSpec)
+	applyGrouping(cfg, svcSpec, service.Group)
 
 	if rewritesSpec != nil {
 		applyRewrites(rewritesSpec, svcSpec)
 	}
 
+	if label := service.Label(); label != "" {
+		labelSpec(svcSpec, label)
+	}
+
 	// using MarshalIndent to maintain formatting for Dapperdox spec download
 	outdata, err := json.MarshalIndent(svcSpec, "", "  ")
 	if err != nil {
 		return "", nil, wraperrors.Wrap(err, "unable to marshal final spec")
 	}
 
-	return hostname, outdata, nil
+	return service.Key(), outdata, nil
 }
 
 func removePrivateAPIs(svcSpec *spec.Swagger) {
@@ -198,7 +329,7 @@ func removePrivateDefinitions(svcSpec *spec.Swagger) {
 	}
 }
 
-func applyGrouping(svcSpec *spec.Swagger) {
+func applyGrouping(cfg *viper.Viper, svcSpec *spec.Swagger, group string) {
 	// create extensions if non exist
 	if svcSpec.Extensions == nil {
 		svcSpec.Extensions = make(map[string]interface{})
@@ -208,9 +339,9 @@ func applyGrouping(svcSpec *spec.Swagger) {
 	// matching the provided grouping key located at the spec's root-level extensions
 	var isGrouped bool
 
-	if gkey := viper.GetString(config.DiscoveryGroupingKey); gkey != "" {
+	if gkey := cfg.GetString(config.DiscoveryGroupingKey); gkey != "" {
 		if gval, ok := svcSpec.Extensions[gkey]; ok {
-			if newgval, ok := viper.GetStringMapString(config.DiscoveryGroupingConverters)[gval.(string)]; ok {
+			if newgval, ok := cfg.GetStringMapString(config.DiscoveryGroupingConverters)[gval.(string)]; ok {
 				svcSpec.Extensions.Add(extKeyGroupBy, newgval)
 
 				isGrouped = true
@@ -224,10 +355,42 @@ func applyGrouping(svcSpec *spec.Swagger) {
 
 	// set custom grouping extension if defined
 	for _, tag := range svcSpec.Tags {
-		if customgroup, ok := viper.GetStringMapString(config.SpecGroupings)[tag.Name]; ok {
+		if customgroup, ok := cfg.GetStringMapString(config.SpecGroupings)[tag.Name]; ok {
 			svcSpec.Extensions.Add(extKeyGroupBy, customgroup)
 		}
 	}
+
+	// the group the service is listed in overrides that of its spec
+	if group != "" {
+		svcSpec.Extensions.Add(extKeyGroupBy, group)
+	}
+}
+
+// labelSpec prefixes the title of a spec, in every locale, and its grouping with the label of the
+// cluster and namespace of its service, so that the specs of services of the same name in several
+// clusters or namespaces have different IDs and are listed apart.
+func labelSpec(svcSpec *spec.Swagger, label string) {
+	prefix := label + ": "
+
+	if svcSpec.Info == nil {
+		svcSpec.Info = &spec.Info{}
+	}
+
+	svcSpec.Info.Title = prefix + svcSpec.Info.Title
+
+	if translations, ok := svcSpec.Info.Extensions[i18n.Extension].(map[string]interface{}); ok {
+		for _, fields := range translations {
+			if fields, ok := fields.(map[string]interface{}); ok {
+				if title, ok := fields["title"].(string); ok {
+					fields["title"] = prefix + title
+				}
+			}
+		}
+	}
+
+	if group, ok := svcSpec.Extensions.GetString(extKeyGroupBy); ok {
+		svcSpec.Extensions.Add(extKeyGroupBy, prefix+group)
+	}
 }
 
 func applyRewrites(rewrites, svcSpec *spec.Swagger) {
@@ -260,8 +423,8 @@ func isPrivate(exts spec.Extensions) bool {
 	return false
 }
 
-func isIgnoredSvc(name string) bool {
-	for _, ignore := range viper.GetStringSlice(config.DiscoveryServiceIgnoreList) {
+func isIgnoredSvc(cfg *viper.Viper, name string) bool {
+	for _, ignore := range cfg.GetStringSlice(config.DiscoveryServiceIgnoreList) {
 		if strings.HasPrefix(name, ignore) {
 			return true
 		}
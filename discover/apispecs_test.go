@@ -3,21 +3,23 @@ package discover
 import (
 	"bytes"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"strconv"
 	"testing"
 
 	"github.com/go-openapi/spec"
 	"github.com/go-openapi/swag"
-	"github.com/spf13/viper"
 
 	"github.com/kenjones-cisco/dapperdox/config"
 	"github.com/kenjones-cisco/dapperdox/discover/models"
 )
 
 func Test_fetchAPISpecs(t *testing.T) {
-	_ = config.LoadFixture("../fixtures")
-	viper.Set(config.SpecDir, "../tmp/specs")
+	cfg, _ := config.LoadFixture("../fixtures")
+	cfg.Set(config.SpecDir, "../tmp/specs")
 
 	type fields struct {
 		invalidrewritespath    bool
@@ -166,6 +168,19 @@ func Test_fetchAPISpecs(t *testing.T) {
 			},
 			want: 1,
 		},
+		{
+			name: "success - process OpenAPI 3.0 specs",
+			fields: fields{
+				invalidrewritespath:    false,
+				invalidrewritesversion: false,
+			},
+			args: args{
+				host:       nil,
+				servicemap: nil,
+				spec:       "../fixtures/openapi3_api.json",
+			},
+			want: 1,
+		},
 	}
 
 	for _, tt := range tests {
@@ -206,6 +221,7 @@ func Test_fetchAPISpecs(t *testing.T) {
 
 		// instantiate a new discoverer instance with servicemap
 		d := &Discoverer{
+			cfg: cfg,
 			data: &state{
 				services: svcMap,
 			},
@@ -214,17 +230,17 @@ func Test_fetchAPISpecs(t *testing.T) {
 
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.fields.invalidrewritespath {
-				prevRewrites := viper.GetString(config.SpecRewrites)
+				prevRewrites := cfg.GetString(config.SpecRewrites)
 
-				viper.Set(config.SpecRewrites, "/fake/path/to/fake/rewrites.yaml")
-				defer viper.Set(config.SpecRewrites, prevRewrites)
+				cfg.Set(config.SpecRewrites, "/fake/path/to/fake/rewrites.yaml")
+				defer cfg.Set(config.SpecRewrites, prevRewrites)
 			}
 
 			if tt.fields.invalidrewritesversion {
-				prevRewrites := viper.GetString(config.SpecRewrites)
+				prevRewrites := cfg.GetString(config.SpecRewrites)
 
-				viper.Set(config.SpecRewrites, "../fixtures/bad_rewrites.yaml")
-				defer viper.Set(config.SpecRewrites, prevRewrites)
+				cfg.Set(config.SpecRewrites, "../fixtures/bad_rewrites.yaml")
+				defer cfg.Set(config.SpecRewrites, prevRewrites)
 			}
 
 			specs := d.fetchAPISpecs()
@@ -236,7 +252,7 @@ func Test_fetchAPISpecs(t *testing.T) {
 }
 
 func Test_apiLoader_load(t *testing.T) {
-	_ = config.LoadFixture("../fixtures")
+	cfg, _ := config.LoadFixture("../fixtures")
 
 	type fields struct {
 		hostoverride *string
@@ -320,6 +336,14 @@ func Test_apiLoader_load(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "success - loads OpenAPI 3.0",
+			fields: fields{
+				hostoverride: nil,
+				spec:         "../fixtures/openapi3_api.json",
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -334,7 +358,12 @@ func Test_apiLoader_load(t *testing.T) {
 				host = *tt.fields.hostoverride
 			}
 
-			_, err := loadSpec(host)
+			var source string
+			if host != "" {
+				source = "http://" + host + "/swagger.json"
+			}
+
+			_, err := loadSpec(source, cfg.GetDuration(config.DiscoverySpecLoadTimeout))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("apiLoader.Load() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -343,8 +372,8 @@ func Test_apiLoader_load(t *testing.T) {
 }
 
 func Test_processSpec(t *testing.T) {
-	_ = config.LoadFixture("../fixtures")
-	viper.Set(config.SpecDir, "../tmp/specs")
+	cfg, _ := config.LoadFixture("../fixtures")
+	cfg.Set(config.SpecDir, "../tmp/specs")
 
 	type args struct {
 		hostname    string
@@ -1189,7 +1218,7 @@ func Test_processSpec(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			path, data, err := processSpec(tt.args.hostname, tt.args.rewriteSpec, tt.args.svcSpec)
+			path, data, err := processSpec(cfg, &models.Service{Hostname: tt.args.hostname}, tt.args.rewriteSpec, tt.args.svcSpec)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("processSpec() error = %v, wantErr %v", err, tt.wantErr)
 
@@ -1212,3 +1241,174 @@ func Test_processSpec(t *testing.T) {
 		})
 	}
 }
+
+func Test_specSources(t *testing.T) {
+	paths := []string{"/swagger.json", "/v3/api-docs"}
+
+	tests := []struct {
+		name    string
+		service *models.Service
+		want    []string
+	}{
+		{
+			name:    "spec url",
+			service: &models.Service{Hostname: "abc", SpecURL: "https://specs.example.com/abc.yaml", Ports: []*models.Port{{Name: "http", Port: 80, Protocol: models.ProtocolHTTP}}},
+			want:    []string{"https://specs.example.com/abc.yaml"},
+		},
+		{
+			name: "well-known paths of http ports",
+			service: &models.Service{Hostname: "abc", Ports: []*models.Port{
+				{Name: "http", Port: 80, Protocol: models.ProtocolHTTP},
+				{Name: "tcp", Port: 90, Protocol: models.ProtocolTCP},
+				{Name: "https", Port: 443, Protocol: models.ProtocolHTTPS},
+			}},
+			want: []string{
+				"http://abc:80/swagger.json", "http://abc:80/v3/api-docs",
+				"https://abc:443/swagger.json", "https://abc:443/v3/api-docs",
+			},
+		},
+		{
+			name: "spec path and port by name",
+			service: &models.Service{Hostname: "abc", SpecPath: "/openapi.yaml", SpecPort: "admin", Ports: []*models.Port{
+				{Name: "http", Port: 80, Protocol: models.ProtocolHTTP},
+				{Name: "admin", Port: 9090, Protocol: models.ProtocolTCP},
+			}},
+			want: []string{"http://abc:9090/openapi.yaml"},
+		},
+		{
+			name: "spec port by number and scheme",
+			service: &models.Service{Hostname: "abc", SpecHost: "10.0.0.1", SpecPort: "8443", SpecScheme: "https", Ports: []*models.Port{
+				{Name: "http", Port: 80, Protocol: models.ProtocolHTTP},
+				{Name: "web", Port: 8443, Protocol: models.ProtocolTCP},
+			}},
+			want: []string{"https://10.0.0.1:8443/swagger.json", "https://10.0.0.1:8443/v3/api-docs"},
+		},
+		{
+			name:    "unknown spec port",
+			service: &models.Service{Hostname: "abc", SpecPort: "admin", Ports: []*models.Port{{Name: "http", Port: 80, Protocol: models.ProtocolHTTP}}},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := specSources(tt.service, paths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("specSources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_fetchAPISpecs_annotations(t *testing.T) {
+	cfg, _ := config.LoadFixture("../fixtures")
+	cfg.Set(config.SpecDir, "../tmp/specs")
+
+	// a service serving its specs at some of the well-known paths
+	mux := http.NewServeMux()
+	mux.Handle("/v3/api-docs", genServerAPI("fixtures/petstore_api.json").Config.Handler)
+	mux.Handle("/openapi.yaml", genServerAPI("fixtures/openapi3_api.yaml").Config.Handler)
+	mux.Handle("/docs/api.json", genServerAPI("fixtures/iam_api.json").Config.Handler)
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	service := func(s models.Service) *models.Service {
+		s.Hostname = "svc"
+		s.SpecHost = u.Hostname()
+		s.Ports = []*models.Port{{Name: "http", Port: port, Protocol: models.ProtocolHTTP}}
+
+		return &s
+	}
+
+	tests := []struct {
+		name      string
+		service   *models.Service
+		wantTitle string
+		wantGroup string
+	}{
+		{
+			name:      "first well-known path serving a spec, in yaml",
+			service:   service(models.Service{}),
+			wantTitle: "Pet Store 3",
+			wantGroup: groupByDefault,
+		},
+		{
+			name:      "spec path",
+			service:   service(models.Service{SpecPath: "/v3/api-docs"}),
+			wantTitle: "Swagger Petstore",
+			wantGroup: groupByDefault,
+		},
+		{
+			name:      "spec path, display name and group",
+			service:   service(models.Service{SpecPath: "/docs/api.json", DisplayName: "Identity", Group: "Security"}),
+			wantTitle: "Identity",
+			wantGroup: "Security",
+		},
+		{
+			name:    "excluded",
+			service: service(models.Service{Excluded: true}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Discoverer{
+				cfg:      cfg,
+				data:     &state{services: models.NewServiceMap(tt.service)},
+				services: &fakeController{},
+			}
+
+			specs := d.fetchAPISpecs()
+
+			if tt.wantTitle == "" {
+				if len(specs) != 0 {
+					t.Errorf("discover.fetchAPISpecs() = %d specs, want none", len(specs))
+				}
+
+				return
+			}
+
+			var got spec.Swagger
+			if err := json.Unmarshal(specs["svc"], &got); err != nil {
+				t.Fatalf("discover.fetchAPISpecs() invalid spec: %v", err)
+			}
+
+			if got.Info.Title != tt.wantTitle {
+				t.Errorf("discover.fetchAPISpecs() title = %q, want %q", got.Info.Title, tt.wantTitle)
+			}
+
+			if group, _ := got.Extensions.GetString(extKeyGroupBy); group != tt.wantGroup {
+				t.Errorf("discover.fetchAPISpecs() group = %q, want %q", group, tt.wantGroup)
+			}
+		})
+	}
+}
+
+func Test_fetchAPISpecs_noRewrites(t *testing.T) {
+	// the default configuration sets no rewrites
+	cfg := config.New()
+
+	srv := genServerAPI("fixtures/petstore_api.json")
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	d := &Discoverer{
+		cfg: cfg,
+		data: &state{services: models.NewServiceMap(&models.Service{
+			Hostname: "petstore",
+			SpecHost: u.Hostname(),
+			SpecPath: "/swagger.json",
+			Ports:    []*models.Port{{Name: "http", Port: port, Protocol: models.ProtocolHTTP}},
+		})},
+		services: &fakeController{},
+	}
+
+	if specs := d.fetchAPISpecs(); len(specs["petstore"]) == 0 {
+		t.Errorf("discover.fetchAPISpecs() = %v, want the spec of petstore", specs)
+	}
+}
@@ -0,0 +1,275 @@
+package discover
+
+import (
+	"net/url"
+	"path/filepath"
+	"time"
+
+	wraperrors "github.com/pkg/errors"
+	"github.com/spf13/viper"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/discover/models"
+)
+
+// clusterOptions stores the configurable attributes of a Kubernetes cluster watched for services.
+type clusterOptions struct {
+	// Name labels the services of the cluster, prefixing the IDs and groupings of their specs.
+	Name string `mapstructure:"name"`
+	// Kubeconfig is the kubeconfig file, or list of files, of the cluster. The cluster dapperdox runs
+	// within is watched when neither Kubeconfig nor Context are set.
+	Kubeconfig string `mapstructure:"kubeconfig"`
+	// Context is the context of the kubeconfig the cluster is accessed with, its current context when
+	// not set.
+	Context string `mapstructure:"context"`
+}
+
+// inCluster checks whether the cluster is the one dapperdox runs within.
+func (c clusterOptions) inCluster() bool {
+	return c.Kubeconfig == "" && c.Context == ""
+}
+
+// newClusters returns the clusters watched as set up by cfg: those listed by discovery.clusters, or
+// else a single unlabelled cluster.
+func newClusters(cfg *viper.Viper) ([]clusterOptions, error) {
+	var clusters []clusterOptions
+
+	if err := cfg.UnmarshalKey(config.DiscoveryClusters, &clusters); err != nil {
+		return nil, wraperrors.Wrapf(err, "invalid %s", config.DiscoveryClusters)
+	}
+
+	if len(clusters) == 0 {
+		return []clusterOptions{{
+			Kubeconfig: cfg.GetString(config.DiscoveryKubeconfig),
+			Context:    cfg.GetString(config.DiscoveryKubeContext),
+		}}, nil
+	}
+
+	names := make(map[string]bool)
+
+	for i := range clusters {
+		c := &clusters[i]
+
+		if c.Name == "" {
+			return nil, wraperrors.Errorf("cluster %d of %s has no name", i, config.DiscoveryClusters)
+		}
+
+		if names[c.Name] {
+			return nil, wraperrors.Errorf("cluster %q of %s is listed more than once", c.Name, config.DiscoveryClusters)
+		}
+
+		names[c.Name] = true
+
+		if c.Kubeconfig == "" {
+			c.Kubeconfig = cfg.GetString(config.DiscoveryKubeconfig)
+		}
+	}
+
+	return clusters, nil
+}
+
+// newClient creates a connection to the Kubernetes API of a cluster.
+func newClient(cluster clusterOptions) (kubernetes.Interface, error) {
+	cfg, err := newRESTConfig(cluster)
+	if err != nil {
+		return nil, wraperrors.Wrapf(err, "unable to configure the client of cluster %q", cluster.Name)
+	}
+
+	return kubernetes.NewForConfig(cfg)
+}
+
+// newProxyClient creates a connection to the Kubernetes API of a cluster the specs of its services are
+// fetched through, each within timeout.
+func newProxyClient(cluster clusterOptions, timeout time.Duration) (kubernetes.Interface, error) {
+	cfg, err := newRESTConfig(cluster)
+	if err != nil {
+		return nil, wraperrors.Wrapf(err, "unable to configure the client of cluster %q", cluster.Name)
+	}
+
+	cfg.Timeout = timeout
+
+	return kubernetes.NewForConfig(cfg)
+}
+
+// proxyFetch returns the function fetching the URLs of a service through the service proxy of the API
+// server of its cluster, as its cluster domain name does not resolve from outside the cluster, or resolves
+// to a service of another cluster.
+func proxyFetch(client kubernetes.Interface, namespace, name string) func(source string) ([]byte, error) {
+	return func(source string) ([]byte, error) {
+		u, err := url.Parse(source)
+		if err != nil {
+			return nil, err
+		}
+
+		return client.CoreV1().Services(namespace).ProxyGet(u.Scheme, name, u.Port(), u.Path, nil).DoRaw()
+	}
+}
+
+// newRESTConfig returns the configuration of the client of a cluster, from its kubeconfig and context,
+// or else from within the cluster.
+func newRESTConfig(cluster clusterOptions) (*rest.Config, error) {
+	if cluster.inCluster() {
+		return rest.InClusterConfig()
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if cluster.Kubeconfig != "" {
+		rules.Precedence = filepath.SplitList(cluster.Kubeconfig)
+	}
+
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: cluster.Context}
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+}
+
+// watchedNamespaces returns the namespaces watched as set up by cfg: those listed by discovery.namespaces,
+// or else discovery.namespace, "*" standing for all namespaces. All namespaces are watched for those
+// selected by discovery.namespaceselector.
+func watchedNamespaces(cfg *viper.Viper) ([]string, error) {
+	namespaces := cfg.GetStringSlice(config.DiscoveryNamespaces)
+
+	if selector := cfg.GetString(config.DiscoveryNamespaceSelector); selector != "" {
+		if len(namespaces) > 0 {
+			return nil, wraperrors.Errorf("only one of %s and %s can be set", config.DiscoveryNamespaces, config.DiscoveryNamespaceSelector)
+		}
+
+		if _, err := labels.Parse(selector); err != nil {
+			return nil, wraperrors.Wrapf(err, "invalid %s", config.DiscoveryNamespaceSelector)
+		}
+
+		return []string{meta_v1.NamespaceAll}, nil
+	}
+
+	if len(namespaces) == 0 {
+		namespaces = []string{cfg.GetString(config.DiscoveryNamespace)}
+	}
+
+	watched := make([]string, 0, len(namespaces))
+	seen := make(map[string]bool)
+
+	for _, ns := range namespaces {
+		if ns == "*" || ns == meta_v1.NamespaceAll {
+			return []string{meta_v1.NamespaceAll}, nil
+		}
+
+		if !seen[ns] {
+			seen[ns] = true
+			watched = append(watched, ns)
+		}
+	}
+
+	return watched, nil
+}
+
+// newKubernetesWatcher returns the watcher of the services of the Kubernetes clusters set up by cfg.
+// Each namespace of each cluster is watched by its own catalog.
+func newKubernetesWatcher(cfg *viper.Viper) (watcher, error) {
+	clusters, err := newClusters(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := watchedNamespaces(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	options := catalogOptions{
+		DomainSuffix:      cfg.GetString(config.DiscoverySuffix),
+		NamespaceSelector: cfg.GetString(config.DiscoveryNamespaceSelector),
+		LabelNamespaces:   len(namespaces) > 1 || namespaces[0] == meta_v1.NamespaceAll,
+		ResyncPeriod:      cfg.GetDuration(config.DiscoveryInterval),
+	}
+
+	watchers := make([]watcher, 0, len(clusters))
+
+	for _, cluster := range clusters {
+		client, err := newClient(cluster)
+		if err != nil {
+			return nil, err
+		}
+
+		// the services of a cluster watched from outside are only reachable through its API server
+		o := options
+
+		if !cluster.inCluster() {
+			if o.SpecProxy, err = newProxyClient(cluster, cfg.GetDuration(config.DiscoverySpecLoadTimeout)); err != nil {
+				return nil, err
+			}
+		}
+
+		catalogs := make([]watcher, 0, len(namespaces))
+
+		for _, ns := range namespaces {
+			o.WatchedNamespace = ns
+
+			catalogs = append(catalogs, newCatalog(client, o))
+		}
+
+		w := newMultiWatcher(catalogs...)
+		if cluster.Name != "" {
+			log().Infof("Service controller watching cluster %q", cluster.Name)
+
+			w = &labelledWatcher{watcher: w, cluster: cluster.Name}
+		}
+
+		watchers = append(watchers, w)
+	}
+
+	return newMultiWatcher(watchers...), nil
+}
+
+// labelledWatcher is a watcher labelling the services of a watcher with the cluster they belong to.
+type labelledWatcher struct {
+	watcher
+	cluster string
+}
+
+// AppendServiceHandler implements a service catalog operation.
+func (w *labelledWatcher) AppendServiceHandler(f func(*models.Service, models.Event)) {
+	w.watcher.AppendServiceHandler(func(svc *models.Service, e models.Event) {
+		svc.Cluster = w.cluster
+		f(svc, e)
+	})
+}
+
+// multiWatcher is a watcher of the services of several watchers, such as those of several clusters or
+// namespaces. The handlers are called from the watcher of each.
+type multiWatcher []watcher
+
+// newMultiWatcher returns a watcher of the services of watchers, the watcher itself when only one.
+func newMultiWatcher(watchers ...watcher) watcher {
+	if len(watchers) == 1 {
+		return watchers[0]
+	}
+
+	return multiWatcher(watchers)
+}
+
+// AppendServiceHandler implements a service catalog operation.
+func (m multiWatcher) AppendServiceHandler(f func(*models.Service, models.Event)) {
+	for _, w := range m {
+		w.AppendServiceHandler(f)
+	}
+}
+
+// AppendDeploymentHandler implements a deployment catalog operation.
+func (m multiWatcher) AppendDeploymentHandler(f func(*models.Deployment, models.Event)) {
+	for _, w := range m {
+		w.AppendDeploymentHandler(f)
+	}
+}
+
+// Run runs each watcher until a signal is received.
+func (m multiWatcher) Run(stop <-chan struct{}) {
+	for _, w := range m {
+		go w.Run(stop)
+	}
+
+	<-stop
+}
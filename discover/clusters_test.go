@@ -0,0 +1,412 @@
+package discover
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/spec"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/discover/models"
+)
+
+func Test_newClusters(t *testing.T) {
+	tests := []struct {
+		name     string
+		clusters interface{}
+		want     []clusterOptions
+		wantErr  bool
+	}{
+		{
+			name: "single cluster",
+			want: []clusterOptions{{Kubeconfig: "testdata/kubeconfig", Context: "staging"}},
+		},
+		{
+			name: "clusters",
+			clusters: []interface{}{
+				map[string]interface{}{"name": "prod", "context": "prod"},
+				map[string]interface{}{"name": "eu", "kubeconfig": "eu.kubeconfig"},
+			},
+			want: []clusterOptions{
+				{Name: "prod", Kubeconfig: "testdata/kubeconfig", Context: "prod"},
+				{Name: "eu", Kubeconfig: "eu.kubeconfig"},
+			},
+		},
+		{
+			name:     "cluster without name",
+			clusters: []interface{}{map[string]interface{}{"context": "prod"}},
+			wantErr:  true,
+		},
+		{
+			name: "duplicate cluster",
+			clusters: []interface{}{
+				map[string]interface{}{"name": "prod", "context": "prod"},
+				map[string]interface{}{"name": "prod", "context": "staging"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Set(config.DiscoveryKubeconfig, "testdata/kubeconfig")
+			cfg.Set(config.DiscoveryKubeContext, "staging")
+
+			if tt.clusters != nil {
+				cfg.Set(config.DiscoveryClusters, tt.clusters)
+			}
+
+			got, err := newClusters(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newClusters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newClusters() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newRESTConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster clusterOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "current context",
+			cluster: clusterOptions{Kubeconfig: "testdata/kubeconfig"},
+			want:    "https://prod.example.com:6443",
+		},
+		{
+			name:    "context",
+			cluster: clusterOptions{Kubeconfig: "testdata/kubeconfig", Context: "staging"},
+			want:    "https://staging.example.com:6443",
+		},
+		{
+			name:    "unknown context",
+			cluster: clusterOptions{Kubeconfig: "testdata/kubeconfig", Context: "dev"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newRESTConfig(tt.cluster)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newRESTConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && got.Host != tt.want {
+				t.Errorf("newRESTConfig() Host = %v, want %v", got.Host, tt.want)
+			}
+		})
+	}
+}
+
+func Test_watchedNamespaces(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespaces []string
+		selector   string
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name: "namespace",
+			want: []string{"billing"},
+		},
+		{
+			name:       "namespaces",
+			namespaces: []string{"billing", "users", "billing"},
+			want:       []string{"billing", "users"},
+		},
+		{
+			name:       "all namespaces",
+			namespaces: []string{"billing", "*"},
+			want:       []string{""},
+		},
+		{
+			name:     "namespace selector",
+			selector: "docs=true",
+			want:     []string{""},
+		},
+		{
+			name:     "invalid namespace selector",
+			selector: "docs in (",
+			wantErr:  true,
+		},
+		{
+			name:       "namespaces and namespace selector",
+			namespaces: []string{"billing"},
+			selector:   "docs=true",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Set(config.DiscoveryNamespace, "billing")
+			cfg.Set(config.DiscoveryNamespaces, tt.namespaces)
+			cfg.Set(config.DiscoveryNamespaceSelector, tt.selector)
+
+			got, err := watchedNamespaces(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("watchedNamespaces() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("watchedNamespaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newKubernetesWatcher(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.DiscoveryKubeconfig, "testdata/kubeconfig")
+
+	w, err := newKubernetesWatcher(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the specs of a cluster watched from a kubeconfig are fetched through its API server
+	if c, ok := w.(*catalog); !ok || c.specProxy == nil {
+		t.Errorf("newKubernetesWatcher() = %T, want %T fetching specs through the API server", w, &catalog{})
+	}
+
+	cfg.Set(config.DiscoveryClusters, []interface{}{
+		map[string]interface{}{"name": "prod", "context": "prod"},
+		map[string]interface{}{"name": "staging", "context": "staging"},
+	})
+
+	w, err = newKubernetesWatcher(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m, ok := w.(multiWatcher); !ok || len(m) != 2 {
+		t.Errorf("newKubernetesWatcher() = %T, want %T of 2 clusters", w, multiWatcher{})
+	}
+
+	cfg.Set(config.DiscoveryNamespaces, []string{"billing", "users"})
+
+	w, err = newKubernetesWatcher(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a catalog per namespace of each cluster
+	if m, ok := w.(multiWatcher); !ok || len(m) != 2 {
+		t.Errorf("newKubernetesWatcher() = %T, want %T of 2 clusters", w, multiWatcher{})
+	} else if l, ok := m[0].(*labelledWatcher); !ok || len(l.watcher.(multiWatcher)) != 2 {
+		t.Errorf("newKubernetesWatcher() cluster = %T, want the %T of 2 namespaces", m[0], multiWatcher{})
+	}
+
+	cfg.Set(config.DiscoveryClusters, []interface{}{map[string]interface{}{"name": "dev", "context": "dev"}})
+
+	if _, err := newKubernetesWatcher(cfg); err == nil {
+		t.Errorf("newKubernetesWatcher() error = %v, wantErr %v", err, true)
+	}
+}
+
+// eventWatcher is a watcher notifying the services it is given.
+type eventWatcher struct {
+	handlers []func(*models.Service, models.Event)
+}
+
+func (w *eventWatcher) AppendServiceHandler(f func(*models.Service, models.Event)) {
+	w.handlers = append(w.handlers, f)
+}
+
+func (w *eventWatcher) AppendDeploymentHandler(f func(*models.Deployment, models.Event)) {}
+
+func (w *eventWatcher) Run(stop <-chan struct{}) {
+	<-stop
+}
+
+func (w *eventWatcher) notify(svc *models.Service, e models.Event) {
+	for _, f := range w.handlers {
+		f(svc, e)
+	}
+}
+
+func TestMultiWatcher(t *testing.T) {
+	prod, staging := &eventWatcher{}, &eventWatcher{}
+
+	d := &Discoverer{cfg: config.New(), data: &state{services: models.NewServiceMap()}, services: &fakeController{}}
+
+	m := multiWatcher{&labelledWatcher{watcher: prod, cluster: "prod"}, &labelledWatcher{watcher: staging, cluster: "staging"}}
+	m.AppendServiceHandler(d.updateServices)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		m.Run(stop)
+		close(done)
+	}()
+
+	// services of the same name in both clusters do not collide
+	prod.notify(&models.Service{Hostname: "petstore"}, models.EventAdd)
+	staging.notify(&models.Service{Hostname: "petstore"}, models.EventAdd)
+	staging.notify(&models.Service{Hostname: "bookings"}, models.EventAdd)
+
+	want := []string{"prod/petstore", "staging/bookings", "staging/petstore"}
+
+	var got []string
+	for _, svc := range d.data.services.List() {
+		got = append(got, svc.Key())
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("multiWatcher services = %v, want %v", got, want)
+	}
+
+	prod.notify(&models.Service{Hostname: "petstore"}, models.EventDelete)
+
+	if d.data.services.Len() != 2 || !d.data.services.Has(&models.Service{Hostname: "petstore", Cluster: "staging"}) {
+		t.Errorf("multiWatcher services = %v, want the petstore of staging", d.data.services.List())
+	}
+
+	close(stop)
+	<-done
+}
+
+func Test_processSpec_cluster(t *testing.T) {
+	cfg := config.New()
+
+	svcSpec := &spec.Swagger{}
+	_ = json.Unmarshal([]byte(`{
+		"swagger": "2.0",
+		"info": {
+			"title": "Swagger Petstore",
+			"version": "1.0.0",
+			"x-i18n": {"fr": {"title": "Animalerie", "description": "Les animaux"}}
+		},
+		"paths": {}
+	}`), svcSpec)
+
+	path, data, err := processSpec(cfg, &models.Service{Hostname: "petstore", Cluster: "prod", Group: "Store"}, nil, svcSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "prod/petstore" {
+		t.Errorf("processSpec() path = %v, want %v", path, "prod/petstore")
+	}
+
+	var got spec.Swagger
+	_ = json.Unmarshal(data, &got)
+
+	if got.Info.Title != "prod: Swagger Petstore" {
+		t.Errorf("processSpec() title = %q, want %q", got.Info.Title, "prod: Swagger Petstore")
+	}
+
+	fr, _ := got.Info.Extensions["x-i18n"].(map[string]interface{})["fr"].(map[string]interface{})
+	if fr["title"] != "prod: Animalerie" || fr["description"] != "Les animaux" {
+		t.Errorf("processSpec() localized info = %v, want the title prefixed", fr)
+	}
+
+	if group, _ := got.Extensions.GetString(extKeyGroupBy); group != "prod: Store" {
+		t.Errorf("processSpec() group = %q, want %q", group, "prod: Store")
+	}
+}
+
+// rawResponse is the response of the API server to a proxied request.
+type rawResponse struct {
+	data []byte
+	err  error
+}
+
+func (r *rawResponse) DoRaw() ([]byte, error) { return r.data, r.err }
+
+func (r *rawResponse) Stream() (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(string(r.data))), r.err
+}
+
+// newProxiedCatalog returns the catalog of a cluster watched from outside, whose petstore service in the
+// default namespace serves the spec of file.
+func newProxiedCatalog(t *testing.T, file string) watcher {
+	t.Helper()
+
+	client := fake.NewSimpleClientset(&v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "petstore", Namespace: defaultVal},
+		Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 8080, Protocol: v1.ProtocolTCP}}},
+	})
+
+	client.AddProxyReactor("services", func(action k8stesting.Action) (bool, rest.ResponseWrapper, error) {
+		a, _ := action.(k8stesting.ProxyGetAction)
+		if a.GetNamespace() != defaultVal || a.GetName() != "petstore" || a.GetScheme() != "http" || a.GetPort() != "8080" || a.GetPath() != "/swagger.json" {
+			return true, &rawResponse{err: errors.New("the server could not find the requested resource")}, nil
+		}
+
+		data, err := os.ReadFile(file)
+
+		return true, &rawResponse{data: data, err: err}, nil
+	})
+
+	return newCatalog(client, catalogOptions{
+		WatchedNamespace: defaultVal,
+		ResyncPeriod:     resync,
+		DomainSuffix:     "cluster.local",
+		SpecProxy:        client,
+	})
+}
+
+func TestKubernetesWatcher_specProxy(t *testing.T) {
+	// services of the same name in two clusters, serving different specs
+	w := multiWatcher{
+		&labelledWatcher{watcher: newProxiedCatalog(t, "fixtures/petstore_api.json"), cluster: "prod"},
+		&labelledWatcher{watcher: newProxiedCatalog(t, "fixtures/iam_api.json"), cluster: "staging"},
+	}
+
+	d := &Discoverer{cfg: config.New(), data: &state{services: models.NewServiceMap()}, services: w, notify: func() {}}
+	w.AppendServiceHandler(d.updateServices)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go w.Run(stop)
+
+	for deadline := time.Now().Add(5 * time.Second); len(d.Specs()) < 2 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	specs := d.Specs()
+
+	// each spec is fetched through the API server of its own cluster
+	want := map[string]string{
+		"prod/petstore":    "prod: Swagger Petstore",
+		"staging/petstore": "staging: Role and Access Management",
+	}
+
+	for key, title := range want {
+		var got spec.Swagger
+		if err := json.Unmarshal(specs[key], &got); err != nil || got.Info == nil {
+			t.Errorf("Discoverer.Specs() %s = %s, want a spec", key, specs[key])
+
+			continue
+		}
+
+		if got.Info.Title != title {
+			t.Errorf("Discoverer.Specs() %s title = %q, want %q", key, got.Info.Title, title)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package discover
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	wraperrors "github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/discover/models"
+)
+
+const (
+	// metaKeySpecPath is the service metadata giving the path of the spec of a Consul service.
+	metaKeySpecPath = "dapperdox-spec-path"
+	// metaKeySpecPort is the service metadata giving the port the spec of a Consul service is served on,
+	// when it is not the port of the service.
+	metaKeySpecPort = "dapperdox-spec-port"
+
+	// consulService is the service of the Consul servers themselves, which serves no spec.
+	consulService = "consul"
+
+	consulIndexHeader = "X-Consul-Index"
+	consulTokenHeader = "X-Consul-Token"
+
+	// consulRetryDelay is the time waited before querying the catalog again after a failed query.
+	consulRetryDelay = 5 * time.Second
+)
+
+// consulOptions stores the configurable attributes of a consulCatalog.
+type consulOptions struct {
+	// Address is the URL of the HTTP API of the Consul agent.
+	Address    string
+	Token      string
+	Datacenter string
+	// Tag selects the services carrying it, when set.
+	Tag string
+	// Meta selects the services whose metadata has each of its values.
+	Meta map[string]string
+	// Wait is the longest time a blocking query waits for the catalog to change.
+	Wait time.Duration
+}
+
+// consulCatalog is a watcher of the services registered in a Consul catalog. The catalog is watched with
+// blocking queries, and the services selected by tag and metadata are notified as they are added,
+// updated or removed. Consul has no deployments, the instances of a service being part of it.
+type consulCatalog struct {
+	client  *http.Client
+	options consulOptions
+	retry   time.Duration
+
+	handlers []func(*models.Service, models.Event)
+	known    map[string]*models.Service // services last notified, by name
+}
+
+// consulServiceEntry is an instance of a service, as listed by the catalog.
+type consulServiceEntry struct {
+	ID             string
+	Node           string
+	Address        string // address of the node
+	ServiceID      string
+	ServiceName    string
+	ServiceAddress string
+	ServicePort    int
+	ServiceTags    []string
+	ServiceMeta    map[string]string
+}
+
+// newConsulOptions returns the options of the Consul catalog set up by cfg.
+func newConsulOptions(cfg *viper.Viper) consulOptions {
+	address := cfg.GetString(config.DiscoveryConsulAddress)
+	// CONSUL_HTTP_ADDR is usually given without scheme.
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	return consulOptions{
+		Address:    strings.TrimSuffix(address, "/"),
+		Token:      cfg.GetString(config.DiscoveryConsulToken),
+		Datacenter: cfg.GetString(config.DiscoveryConsulDatacenter),
+		Tag:        cfg.GetString(config.DiscoveryConsulTag),
+		Meta:       cfg.GetStringMapString(config.DiscoveryConsulMeta),
+		Wait:       cfg.GetDuration(config.DiscoveryConsulWait),
+	}
+}
+
+// newConsulCatalog creates a watcher of the services of the Consul catalog.
+func newConsulCatalog(options consulOptions) watcher {
+	log().Infof("Service controller watching Consul catalog %s", options.Address)
+
+	return &consulCatalog{
+		// the client waits for the blocking queries, which Consul extends by up to a sixteenth
+		client:  &http.Client{Timeout: options.Wait + options.Wait/16 + 10*time.Second},
+		options: options,
+		retry:   consulRetryDelay,
+		known:   make(map[string]*models.Service),
+	}
+}
+
+// AppendServiceHandler implements a service catalog operation.
+func (c *consulCatalog) AppendServiceHandler(f func(*models.Service, models.Event)) {
+	c.handlers = append(c.handlers, f)
+}
+
+// AppendDeploymentHandler does nothing, as Consul has no deployments.
+func (c *consulCatalog) AppendDeploymentHandler(f func(*models.Deployment, models.Event)) {}
+
+// Run watches the catalog until a signal is received.
+func (c *consulCatalog) Run(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	var index uint64
+
+	for {
+		names, next, err := c.listServices(ctx, index)
+		if err != nil {
+			if ctx.Err() != nil {
+				log().Info("watcher terminated")
+
+				return
+			}
+
+			log().WithError(err).Error("unable to query the Consul catalog")
+
+			select {
+			case <-stop:
+				log().Info("watcher terminated")
+
+				return
+			case <-time.After(c.retry):
+			}
+
+			continue
+		}
+
+		if index == 0 || next != index {
+			c.sync(ctx, names)
+		}
+
+		// An index going backwards, such as after a restore of Consul, restarts the blocking queries.
+		if next < index || next == 0 {
+			next = 1
+		}
+
+		index = next
+	}
+}
+
+// listServices returns the names of the services selected by tag, blocking until the catalog changes
+// from index, and the index of the catalog listed.
+func (c *consulCatalog) listServices(ctx context.Context, index uint64) ([]string, uint64, error) {
+	query := url.Values{}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", c.options.Wait.String())
+	}
+
+	var services map[string][]string
+
+	next, err := c.get(ctx, "/v1/catalog/services", query, &services)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	names := make([]string, 0, len(services))
+
+	for name, tags := range services {
+		if name == consulService || (c.options.Tag != "" && !contains(tags, c.options.Tag)) {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names, next, nil
+}
+
+// sync notifies the services added, updated and removed since the services were last notified.
+func (c *consulCatalog) sync(ctx context.Context, names []string) {
+	current := make(map[string]*models.Service)
+
+	for _, name := range names {
+		svc, err := c.service(ctx, name)
+		if err != nil {
+			log().WithError(err).Errorf("unable to query the Consul service %q", name)
+
+			// keep the service as it was, rather than dropping its spec over a failed query
+			if known, ok := c.known[name]; ok {
+				current[name] = known
+			}
+
+			continue
+		}
+
+		if svc != nil {
+			current[name] = svc
+		}
+	}
+
+	for name, svc := range current {
+		known, ok := c.known[name]
+
+		switch {
+		case !ok:
+			c.notify(svc, models.EventAdd)
+		case !reflect.DeepEqual(known, svc):
+			c.notify(svc, models.EventUpdate)
+		}
+	}
+
+	for name, known := range c.known {
+		if _, ok := current[name]; !ok {
+			c.notify(known, models.EventDelete)
+		}
+	}
+
+	c.known = current
+}
+
+func (c *consulCatalog) notify(svc *models.Service, event models.Event) {
+	log().Debugf("Event %s: Consul service %s", event, svc.Hostname)
+
+	for _, f := range c.handlers {
+		f(svc, event)
+	}
+}
+
+// service returns the service of a name, converted from the first of its instances selected by tag
+// and metadata, or nil when none are selected.
+func (c *consulCatalog) service(ctx context.Context, name string) (*models.Service, error) {
+	query := url.Values{}
+	if c.options.Tag != "" {
+		query.Set("tag", c.options.Tag)
+	}
+
+	var entries []consulServiceEntry
+
+	if _, err := c.get(ctx, "/v1/catalog/service/"+url.PathEscape(name), query, &entries); err != nil {
+		return nil, err
+	}
+
+	// the same instance is chosen whatever the order the catalog lists them in
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Node != entries[j].Node {
+			return entries[i].Node < entries[j].Node
+		}
+
+		return entries[i].ServiceID < entries[j].ServiceID
+	})
+
+	for i := range entries {
+		if hasMeta(entries[i].ServiceMeta, c.options.Meta) {
+			return convertConsulService(&entries[i]), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// get decodes the JSON response of a query of the Consul HTTP API into v, returning the index of the
+// response.
+func (c *consulCatalog) get(ctx context.Context, path string, query url.Values, v interface{}) (uint64, error) {
+	if c.options.Datacenter != "" {
+		query.Set("dc", c.options.Datacenter)
+	}
+
+	u := c.options.Address + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	if c.options.Token != "" {
+		req.Header.Set(consulTokenHeader, c.options.Token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, wraperrors.Errorf("unexpected response %q from %s", resp.Status, path)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return 0, wraperrors.Wrapf(err, "unable to decode response from %s", path)
+	}
+
+	index, _ := strconv.ParseUint(resp.Header.Get(consulIndexHeader), 10, 64)
+
+	return index, nil
+}
+
+// convertConsulService converts an instance of a Consul service into the service, whose spec is served
+// at the path and port given by the metadata of the instance.
+func convertConsulService(entry *consulServiceEntry) *models.Service {
+	addr := entry.ServiceAddress
+	if addr == "" {
+		addr = entry.Address
+	}
+
+	port := entry.ServicePort
+	if p, err := strconv.Atoi(entry.ServiceMeta[metaKeySpecPort]); err == nil {
+		port = p
+	}
+
+	return &models.Service{
+		Hostname: entry.ServiceName,
+		Address:  addr,
+		Ports:    []*models.Port{{Name: "http", Port: port, Protocol: models.ProtocolHTTP}},
+		SpecHost: addr,
+		SpecPath: entry.ServiceMeta[metaKeySpecPath],
+	}
+}
+
+// hasMeta returns whether the metadata of a service has each of the values of selector.
+func hasMeta(meta, selector map[string]string) bool {
+	for k, v := range selector {
+		if meta[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,323 @@
+package discover
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/discover/models"
+)
+
+// fakeConsul is a Consul agent serving a catalog, whose blocking queries return when it changes.
+type fakeConsul struct {
+	mu      sync.Mutex
+	index   uint64
+	entries map[string][]consulServiceEntry
+	tokens  []string
+}
+
+func (f *fakeConsul) set(entries map[string][]consulServiceEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.index++
+	f.entries = entries
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	index, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+	wait, _ := time.ParseDuration(r.URL.Query().Get("wait"))
+	deadline := time.Now().Add(wait)
+
+	f.mu.Lock()
+	f.tokens = append(f.tokens, r.Header.Get(consulTokenHeader))
+
+	for index > 0 && f.index <= index && time.Now().Before(deadline) && r.Context().Err() == nil {
+		f.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		f.mu.Lock()
+	}
+
+	defer f.mu.Unlock()
+
+	w.Header().Set(consulIndexHeader, strconv.FormatUint(f.index, 10))
+
+	switch name := r.URL.Path[len("/v1/catalog/service"):]; {
+	case r.URL.Path == "/v1/catalog/services":
+		services := make(map[string][]string)
+		for name, entries := range f.entries {
+			services[name] = []string{}
+			for _, e := range entries {
+				services[name] = append(services[name], e.ServiceTags...)
+			}
+		}
+
+		_ = json.NewEncoder(w).Encode(services)
+	case len(name) > 1:
+		tag := r.URL.Query().Get("tag")
+		entries := []consulServiceEntry{}
+
+		for _, e := range f.entries[name[1:]] {
+			if tag == "" || contains(e.ServiceTags, tag) {
+				entries = append(entries, e)
+			}
+		}
+
+		_ = json.NewEncoder(w).Encode(entries)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func Test_newConsulOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		want    string
+	}{
+		{
+			name:    "default address",
+			address: "",
+			want:    "http://127.0.0.1:8500",
+		},
+		{
+			name:    "address without scheme",
+			address: "consul.service:8500",
+			want:    "http://consul.service:8500",
+		},
+		{
+			name:    "address with scheme",
+			address: "https://consul.example.com/",
+			want:    "https://consul.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			if tt.address != "" {
+				cfg.Set(config.DiscoveryConsulAddress, tt.address)
+			}
+
+			cfg.Set(config.DiscoveryConsulMeta, map[string]string{"docs": "true"})
+
+			got := newConsulOptions(cfg)
+			if got.Address != tt.want {
+				t.Errorf("newConsulOptions() Address = %v, want %v", got.Address, tt.want)
+			}
+
+			if got.Meta["docs"] != "true" {
+				t.Errorf("newConsulOptions() Meta = %v, want %v", got.Meta, map[string]string{"docs": "true"})
+			}
+
+			if got.Wait != 5*time.Minute {
+				t.Errorf("newConsulOptions() Wait = %v, want %v", got.Wait, 5*time.Minute)
+			}
+		})
+	}
+}
+
+func TestConsulCatalog_Run(t *testing.T) {
+	consul := &fakeConsul{}
+	consul.set(map[string][]consulServiceEntry{
+		"consul": {{Node: "n1", Address: "10.0.0.1", ServiceName: "consul", ServicePort: 8300}},
+		"abc": {
+			{Node: "n2", Address: "10.0.0.2", ServiceID: "abc-2", ServiceName: "abc", ServicePort: 80, ServiceTags: []string{"docs"}, ServiceMeta: map[string]string{"team": "core"}},
+			{Node: "n1", Address: "10.0.0.1", ServiceID: "abc-1", ServiceName: "abc", ServiceAddress: "10.1.0.1", ServicePort: 80, ServiceTags: []string{"docs"}, ServiceMeta: map[string]string{"team": "core", metaKeySpecPath: "/v2/api-docs"}},
+		},
+		"bob": {{Node: "n1", Address: "10.0.0.1", ServiceName: "bob", ServicePort: 80, ServiceMeta: map[string]string{"team": "core"}}},
+		"cat": {{Node: "n1", Address: "10.0.0.1", ServiceName: "cat", ServicePort: 80, ServiceTags: []string{"docs"}, ServiceMeta: map[string]string{"team": "edge"}}},
+	})
+
+	srv := httptest.NewServer(consul)
+	defer srv.Close()
+
+	c := newConsulCatalog(consulOptions{
+		Address: srv.URL,
+		Token:   "secret",
+		Tag:     "docs",
+		Meta:    map[string]string{"team": "core"},
+		Wait:    time.Second,
+	}).(*consulCatalog)
+
+	type event struct {
+		svc *models.Service
+		e   models.Event
+	}
+
+	events := make(chan event, 10)
+	c.AppendServiceHandler(func(svc *models.Service, e models.Event) { events <- event{svc, e} })
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		c.Run(stop)
+		close(done)
+	}()
+
+	next := func() event {
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("consulCatalog.Run() no event notified")
+
+			return event{}
+		}
+	}
+
+	// the instance on the first node is selected, with its spec path
+	want := &models.Service{
+		Hostname: "abc",
+		Address:  "10.1.0.1",
+		Ports:    []*models.Port{{Name: "http", Port: 80, Protocol: models.ProtocolHTTP}},
+		SpecHost: "10.1.0.1",
+		SpecPath: "/v2/api-docs",
+	}
+
+	if ev := next(); ev.e != models.EventAdd || ev.svc.Hostname != want.Hostname || ev.svc.SpecHost != want.SpecHost || ev.svc.SpecPath != want.SpecPath {
+		t.Errorf("consulCatalog.Run() = %s %+v, want %s %+v", ev.e, ev.svc, models.EventAdd, want)
+	}
+
+	consul.set(map[string][]consulServiceEntry{
+		"abc": {{Node: "n2", Address: "10.0.0.2", ServiceName: "abc", ServicePort: 80, ServiceTags: []string{"docs"}, ServiceMeta: map[string]string{"team": "core", metaKeySpecPort: "8080"}}},
+	})
+
+	if ev := next(); ev.e != models.EventUpdate || ev.svc.SpecHost != "10.0.0.2" || ev.svc.Ports[0].Port != 8080 {
+		t.Errorf("consulCatalog.Run() = %s %+v, want %s of the spec port", ev.e, ev.svc, models.EventUpdate)
+	}
+
+	consul.set(map[string][]consulServiceEntry{})
+
+	if ev := next(); ev.e != models.EventDelete || ev.svc.Hostname != "abc" {
+		t.Errorf("consulCatalog.Run() = %s %+v, want %s", ev.e, ev.svc, models.EventDelete)
+	}
+
+	close(stop)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("consulCatalog.Run() not terminated")
+	}
+
+	select {
+	case ev := <-events:
+		t.Errorf("consulCatalog.Run() = %s %+v, want no more events", ev.e, ev.svc)
+	default:
+	}
+
+	consul.mu.Lock()
+	defer consul.mu.Unlock()
+
+	for _, token := range consul.tokens {
+		if token != "secret" {
+			t.Errorf("consulCatalog.Run() token = %q, want %q", token, "secret")
+		}
+	}
+}
+
+func TestConsulCatalog_Run_unavailable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	c := newConsulCatalog(consulOptions{Address: srv.URL, Wait: time.Second}).(*consulCatalog)
+	c.retry = 10 * time.Millisecond
+
+	c.AppendServiceHandler(func(svc *models.Service, e models.Event) {
+		t.Errorf("consulCatalog.Run() = %s %+v, want no events", e, svc)
+	})
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		c.Run(stop)
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("consulCatalog.Run() not terminated")
+	}
+}
+
+func Test_fetchAPISpecs_consul(t *testing.T) {
+	cfg, _ := config.LoadFixture("../fixtures")
+	cfg.Set(config.SpecDir, "../tmp/specs")
+
+	// the spec is only served at the path given by the metadata of the service
+	api := genServerAPI("fixtures/petstore_api.json")
+	defer api.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/docs/api.json", api.Config.Handler)
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+
+	tests := []struct {
+		name  string
+		entry consulServiceEntry
+		want  int
+	}{
+		{
+			name: "spec at the path and port of the metadata",
+			entry: consulServiceEntry{
+				ServiceName:    "petstore",
+				ServiceAddress: u.Hostname(),
+				ServicePort:    1,
+				ServiceMeta:    map[string]string{metaKeySpecPath: "/docs/api.json", metaKeySpecPort: u.Port()},
+			},
+			want: 1,
+		},
+		{
+			name: "spec at the node address",
+			entry: consulServiceEntry{
+				ServiceName: "petstore",
+				Address:     u.Hostname(),
+				ServicePort: 1,
+				ServiceMeta: map[string]string{metaKeySpecPath: "/docs/api.json", metaKeySpecPort: u.Port()},
+			},
+			want: 1,
+		},
+		{
+			name: "no spec at the well-known paths",
+			entry: consulServiceEntry{
+				ServiceName:    "petstore",
+				ServiceAddress: u.Hostname(),
+				ServiceMeta:    map[string]string{metaKeySpecPort: u.Port()},
+			},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Discoverer{
+				cfg: cfg,
+				data: &state{
+					services: models.NewServiceMap(convertConsulService(&tt.entry)),
+				},
+				services: &fakeController{},
+			}
+
+			if specs := d.fetchAPISpecs(); len(specs) != tt.want {
+				t.Errorf("discover.fetchAPISpecs() = %v, want %v", len(specs), tt.want)
+			}
+		})
+	}
+}
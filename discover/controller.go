@@ -11,7 +11,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/kenjones-cisco/dapperdox/discover/models"
@@ -44,19 +43,29 @@ type watcher interface {
 type catalogOptions struct {
 	// Namespace the controller watches. If set to meta_v1.NamespaceAll (""), controller watches all namespaces
 	WatchedNamespace string
-	ResyncPeriod     time.Duration
-	DomainSuffix     string
+	// NamespaceSelector is the label selector of the namespaces whose services are notified, all when empty.
+	NamespaceSelector string
+	// LabelNamespaces sets the Namespace of the services notified, as services of several namespaces are.
+	LabelNamespaces bool
+	// SpecProxy is the client of the API server the specs of the services are fetched through, when the
+	// cluster is watched from outside. The specs are fetched directly when nil.
+	SpecProxy    kubernetes.Interface
+	ResyncPeriod time.Duration
+	DomainSuffix string
 }
 
 // catalog is a collection of synchronized resource watchers
 // caches are thread-safe.
 type catalog struct {
-	domainSuffix string
+	domainSuffix    string
+	labelNamespaces bool
+	specProxy       kubernetes.Interface
 
 	client      kubernetes.Interface
 	queue       Queue
 	services    cacheHandler
 	deployments cacheHandler
+	namespaces  cacheHandler
 }
 
 type cacheHandler struct {
@@ -64,27 +73,17 @@ type cacheHandler struct {
 	handler  *ChainHandler
 }
 
-// newClient creates an in cluster connection to Kubernetes API.
-func newClient() (kubernetes.Interface, error) {
-	// creates the in-cluster config
-	config, err := rest.InClusterConfig()
-	if err != nil {
-		return nil, err
-	}
-
-	// creates the clientset
-	return kubernetes.NewForConfig(config)
-}
-
 // newCatalog creates a new Kubernetes controller.
 func newCatalog(client kubernetes.Interface, options catalogOptions) watcher {
 	log().Infof("Service controller watching namespace %q", options.WatchedNamespace)
 
 	// Queue requires a time duration for a retry delay after a handler error
 	out := &catalog{
-		domainSuffix: options.DomainSuffix,
-		client:       client,
-		queue:        NewQueue(1 * time.Second),
+		domainSuffix:    options.DomainSuffix,
+		labelNamespaces: options.LabelNamespaces,
+		specProxy:       options.SpecProxy,
+		client:          client,
+		queue:           NewQueue(1 * time.Second),
 	}
 
 	out.services = out.createInformer(&v1.Service{}, options.ResyncPeriod,
@@ -103,6 +102,24 @@ func newCatalog(client kubernetes.Interface, options catalogOptions) watcher {
 			return client.AppsV1().Deployments(options.WatchedNamespace).Watch(opts)
 		})
 
+	// only the services of the namespaces selected are notified, as they are selected
+	if options.NamespaceSelector != "" {
+		log().Infof("Service controller watching namespaces selected by %q", options.NamespaceSelector)
+
+		out.namespaces = out.createInformer(&v1.Namespace{}, options.ResyncPeriod,
+			func(opts meta_v1.ListOptions) (runtime.Object, error) {
+				opts.LabelSelector = options.NamespaceSelector
+
+				return client.CoreV1().Namespaces().List(opts)
+			},
+			func(opts meta_v1.ListOptions) (watch.Interface, error) {
+				opts.LabelSelector = options.NamespaceSelector
+
+				return client.CoreV1().Namespaces().Watch(opts)
+			})
+		out.namespaces.handler.Append(out.notifyNamespace)
+	}
+
 	return out
 }
 
@@ -118,9 +135,22 @@ func (c *catalog) AppendServiceHandler(f func(*models.Service, models.Event)) {
 			return nil
 		}
 
+		// Do not handle services of namespaces not selected, though they may have been before
+		if event != models.EventDelete && !c.selected(svc.Namespace) {
+			return nil
+		}
+
 		log().Debugf("Handle service %s in namespace %s", svc.Name, svc.Namespace)
 
-		if svcConv := convertService(svc); svcConv != nil {
+		if svcConv := convertService(svc, c.domainSuffix); svcConv != nil {
+			if c.labelNamespaces {
+				svcConv.Namespace = svc.Namespace
+			}
+
+			if c.specProxy != nil {
+				svcConv.Fetch = proxyFetch(c.specProxy, svc.Namespace, svc.Name)
+			}
+
 			f(svcConv, event)
 		}
 
@@ -138,6 +168,10 @@ func (c *catalog) AppendDeploymentHandler(f func(*models.Deployment, models.Even
 			return nil
 		}
 
+		if !c.selected(dpl.Namespace) {
+			return nil
+		}
+
 		log().Debugf("(Handler) Deployment Details: %v", dpl.Status)
 
 		// ensure there is at least one replica in ready state
@@ -167,6 +201,10 @@ func (c *catalog) Run(stop <-chan struct{}) {
 	go c.services.informer.Run(stop)
 	go c.deployments.informer.Run(stop)
 
+	if c.namespaces.informer != nil {
+		go c.namespaces.informer.Run(stop)
+	}
+
 	<-stop
 	log().Info("watcher terminated")
 }
@@ -188,13 +226,50 @@ func (c *catalog) notify(obj interface{}, event models.Event) error {
 	return nil
 }
 
+// selected checks whether the services of a namespace are notified.
+func (c *catalog) selected(namespace string) bool {
+	if c.namespaces.informer == nil {
+		return true
+	}
+
+	_, exists, err := c.namespaces.informer.GetStore().GetByKey(namespace)
+
+	return err == nil && exists
+}
+
+// notifyNamespace notifies the services of a namespace as added when it is selected, and as deleted
+// when it no longer is.
+func (c *catalog) notifyNamespace(obj interface{}, event models.Event) error {
+	if event == models.EventUpdate {
+		return nil
+	}
+
+	namespace, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return nil
+	}
+
+	services, err := c.services.informer.GetIndexer().ByIndex(cache.NamespaceIndex, namespace)
+	if err != nil {
+		return wraperrors.Wrapf(err, "unable to list the services of namespace %s", namespace)
+	}
+
+	log().Debugf("Namespace %s %s, notifying its %d services", namespace, event, len(services))
+
+	for _, svc := range services {
+		c.queue.Push(Task{handler: c.services.handler.Apply, obj: svc, event: event})
+	}
+
+	return nil
+}
+
 func (c *catalog) createInformer(o runtime.Object, resyncPeriod time.Duration, lf cache.ListFunc, wf cache.WatchFunc) cacheHandler {
 	handler := &ChainHandler{funcs: []Handler{c.notify}}
 
 	// TODO: finer-grained index (perf)
 	informer := cache.NewSharedIndexInformer(
 		&cache.ListWatch{ListFunc: lf, WatchFunc: wf}, o,
-		resyncPeriod, cache.Indexers{})
+		resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 
 	informer.AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
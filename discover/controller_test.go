@@ -2,6 +2,8 @@ package discover
 
 import (
 	"os"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -17,8 +19,8 @@ const (
 )
 
 func Test_NewClient(t *testing.T) {
-	if _, err := newClient(); err == nil {
-		t.Errorf("newClient() error = %v, wantErr %v", err, true)
+	if _, err := newClient(clusterOptions{}); err == nil {
+		t.Errorf("newClient(clusterOptions{}) error = %v, wantErr %v", err, true)
 	}
 
 	envVars := []string{"KUBERNETES_SERVICE_HOST", "KUBERNETES_SERVICE_PORT"}
@@ -40,13 +42,13 @@ func Test_NewClient(t *testing.T) {
 	copyKubeToken()
 	copyKubeCert()
 
-	d, err := newClient()
+	d, err := newClient(clusterOptions{})
 	if err != nil {
-		t.Errorf("newClient() error = %v, wantErr %v", err, true)
+		t.Errorf("newClient(clusterOptions{}) error = %v, wantErr %v", err, true)
 	}
 
 	if d == nil {
-		t.Errorf("newClient() = %v, want not nil", d)
+		t.Errorf("newClient(clusterOptions{}) = %v, want not nil", d)
 	}
 }
 
@@ -122,3 +124,97 @@ func createService(t *testing.T, catalog *catalog, name, namespace string, annot
 		t.Errorf("Cannot create service %s in namespace %s (error: %v)", name, namespace, err)
 	}
 }
+
+func TestController_NamespaceSelector(t *testing.T) {
+	namespace := func(name string, labels map[string]string) *v1.Namespace {
+		return &v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	service := func(name, namespace string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80, Protocol: v1.ProtocolTCP}}},
+		}
+	}
+
+	clientSet := fake.NewSimpleClientset(
+		namespace("billing", map[string]string{"docs": "true"}),
+		namespace("users", nil),
+		service("users", "billing"),
+		service("users", "users"),
+	)
+
+	ctlg := newCatalog(clientSet, catalogOptions{
+		WatchedNamespace:  meta_v1.NamespaceAll,
+		NamespaceSelector: "docs=true",
+		LabelNamespaces:   true,
+		ResyncPeriod:      resync,
+		DomainSuffix:      domainSuffix,
+	})
+
+	var mu sync.Mutex
+
+	services := models.NewServiceMap()
+
+	ctlg.AppendServiceHandler(func(svc *models.Service, e models.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if e == models.EventDelete {
+			services.Delete(svc)
+		} else {
+			services.Insert(svc)
+		}
+	})
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go ctlg.Run(stop)
+
+	// services may be notified more than once, as the namespaces are synchronized
+	wait := func(want ...string) {
+		t.Helper()
+
+		var got []string
+
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			mu.Lock()
+			got = got[:0]
+
+			for _, svc := range services.List() {
+				got = append(got, svc.Key())
+			}
+			mu.Unlock()
+
+			if reflect.DeepEqual(got, want) {
+				return
+			}
+		}
+
+		t.Errorf("catalog.Run() services = %v, want %v", got, want)
+	}
+
+	// only the service of the namespace selected is notified, labelled with its namespace
+	wait("billing/users")
+
+	mu.Lock()
+	if svc := services["billing/users"]; svc.SpecHost != "users.billing.svc."+domainSuffix {
+		t.Errorf("catalog.Run() SpecHost = %q, want %q", svc.SpecHost, "users.billing.svc."+domainSuffix)
+	}
+	mu.Unlock()
+
+	// the services of a namespace are notified once it is selected
+	if _, err := clientSet.CoreV1().Namespaces().Update(namespace("users", map[string]string{"docs": "true"})); err != nil {
+		t.Fatal(err)
+	}
+
+	wait("billing/users", "users/users")
+
+	// and deleted once it no longer is
+	if err := clientSet.CoreV1().Namespaces().Delete("billing", &meta_v1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	wait("users/users")
+}
@@ -1,6 +1,7 @@
 package discover
 
 import (
+	"strconv"
 	"strings"
 
 	appv1 "k8s.io/api/apps/v1"
@@ -11,6 +12,14 @@ import (
 
 const (
 	revKeyRef = "deployment.kubernetes.io/revision"
+
+	// annotations of services controlling the discovery of their specs.
+	annotationSpecPath    = "dapperdox.io/spec-path"
+	annotationSpecPort    = "dapperdox.io/spec-port"
+	annotationSpecScheme  = "dapperdox.io/spec-scheme"
+	annotationDisplayName = "dapperdox.io/display-name"
+	annotationGroup       = "dapperdox.io/group"
+	annotationExclude     = "dapperdox.io/exclude"
 )
 
 func convertPort(port *v1.ServicePort) *models.Port {
@@ -21,7 +30,9 @@ func convertPort(port *v1.ServicePort) *models.Port {
 	}
 }
 
-func convertService(svc *v1.Service) *models.Service {
+// convertService converts a Kubernetes service, whose spec is fetched from its fully qualified domain name
+// within the cluster of domainSuffix.
+func convertService(svc *v1.Service, domainSuffix string) *models.Service {
 	addr, external := "", ""
 	if svc.Spec.ClusterIP != "" && svc.Spec.ClusterIP != v1.ClusterIPNone {
 		addr = svc.Spec.ClusterIP
@@ -38,13 +49,35 @@ func convertService(svc *v1.Service) *models.Service {
 
 	loadBalancingDisabled := addr == "" && external == "" // headless services should not be load balanced
 
+	excluded, _ := strconv.ParseBool(svc.Annotations[annotationExclude])
+
 	return &models.Service{
 		Hostname:              svc.Name,
 		Ports:                 ports,
 		Address:               addr,
+		SpecHost:              serviceHostname(svc.Name, svc.Namespace, domainSuffix),
 		ExternalName:          external,
 		LoadBalancingDisabled: loadBalancingDisabled,
+		SpecPath:              svc.Annotations[annotationSpecPath],
+		SpecPort:              svc.Annotations[annotationSpecPort],
+		SpecScheme:            strings.ToLower(svc.Annotations[annotationSpecScheme]),
+		DisplayName:           svc.Annotations[annotationDisplayName],
+		Group:                 svc.Annotations[annotationGroup],
+		Excluded:              excluded,
+	}
+}
+
+// serviceHostname returns the fully qualified domain name of a service, e.g. "users.billing.svc.cluster.local".
+func serviceHostname(name, namespace, domainSuffix string) string {
+	if namespace == "" {
+		return name
+	}
+
+	if domainSuffix == "" {
+		return name + "." + namespace
 	}
+
+	return name + "." + namespace + ".svc." + domainSuffix
 }
 
 func convertDeployment(dpl *appv1.Deployment) *models.Deployment {
@@ -1,6 +1,7 @@
 package discover
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -70,7 +71,7 @@ func TestServiceConversion(t *testing.T) {
 		},
 	}
 
-	service := convertService(&localSvc)
+	service := convertService(&localSvc, domainSuffix)
 	if service == nil {
 		t.Errorf("could not convert service")
 	}
@@ -92,6 +93,67 @@ func TestServiceConversion(t *testing.T) {
 	if service.Address != localSvc.Spec.ClusterIP {
 		t.Errorf("service IP incorrect => %q, want %q", service.Address, localSvc.Spec.ClusterIP)
 	}
+
+	if want := "service1.default.svc.company.com"; service.SpecHost != want {
+		t.Errorf("service spec host incorrect => %q, want %q", service.SpecHost, want)
+	}
+}
+
+func TestServiceConversion_annotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        models.Service
+	}{
+		{
+			name:        "no annotations",
+			annotations: nil,
+			want:        models.Service{},
+		},
+		{
+			name: "all annotations",
+			annotations: map[string]string{
+				annotationSpecPath:    "/v3/api-docs",
+				annotationSpecPort:    "admin",
+				annotationSpecScheme:  "HTTPS",
+				annotationDisplayName: "Bookings",
+				annotationGroup:       "Reservations",
+				annotationExclude:     "false",
+			},
+			want: models.Service{SpecPath: "/v3/api-docs", SpecPort: "admin", SpecScheme: "https", DisplayName: "Bookings", Group: "Reservations"},
+		},
+		{
+			name:        "excluded",
+			annotations: map[string]string{annotationExclude: "true"},
+			want:        models.Service{Excluded: true},
+		},
+		{
+			name:        "invalid exclusion",
+			annotations: map[string]string{annotationExclude: "yes please"},
+			want:        models.Service{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := convertService(&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "service1", Namespace: defaultVal, Annotations: tt.annotations},
+			}, domainSuffix)
+
+			got := models.Service{
+				SpecPath:    svc.SpecPath,
+				SpecPort:    svc.SpecPort,
+				SpecScheme:  svc.SpecScheme,
+				DisplayName: svc.DisplayName,
+				Group:       svc.Group,
+				Excluded:    svc.Excluded,
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("convertService() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
 }
 
 func TestExternalServiceConversion(t *testing.T) {
@@ -116,7 +178,7 @@ func TestExternalServiceConversion(t *testing.T) {
 		},
 	}
 
-	service := convertService(&extSvc)
+	service := convertService(&extSvc, domainSuffix)
 	if service == nil {
 		t.Errorf("could not convert external service")
 	}
@@ -136,6 +198,27 @@ func TestExternalServiceConversion(t *testing.T) {
 	}
 }
 
+func Test_serviceHostname(t *testing.T) {
+	tests := []struct {
+		name         string
+		namespace    string
+		domainSuffix string
+		want         string
+	}{
+		{"fqdn", "billing", "cluster.local", "users.billing.svc.cluster.local"},
+		{"no suffix", "billing", "", "users.billing"},
+		{"no namespace", "", "cluster.local", "users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serviceHostname("users", tt.namespace, tt.domainSuffix); got != tt.want {
+				t.Errorf("serviceHostname() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_convertDeployment(t *testing.T) {
 	ttime := metav1.NewTime(time.Now())
 
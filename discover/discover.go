@@ -1,16 +1,26 @@
 package discover
 
 import (
+	"bytes"
 	"sync"
 
+	wraperrors "github.com/pkg/errors"
 	"github.com/spf13/viper"
 
 	"github.com/kenjones-cisco/dapperdox/config"
 	"github.com/kenjones-cisco/dapperdox/discover/models"
 )
 
+// Discovery backends.
+const (
+	BackendKubernetes = "kubernetes"
+	BackendConsul     = "consul"
+	BackendRegistry   = "registry"
+)
+
 // Discoverer represents the state of the discovery mechanism.
 type Discoverer struct {
+	cfg      *viper.Viper
 	services watcher
 
 	sLock sync.Mutex
@@ -27,23 +37,19 @@ type state struct {
 	services models.ServiceMap
 }
 
-// NewDiscoverer configures a new instance of a Discoverer using Kubernetes client.
-func NewDiscoverer() (DiscoveryManager, error) {
+// NewDiscoverer configures a new instance of a Discoverer, discovering services as set up by cfg from the
+// Kubernetes cluster, Consul catalog or registry file selected as discovery backend.
+func NewDiscoverer(cfg *viper.Viper) (DiscoveryManager, error) {
 	log().Info("initializing new discoverer instance")
 
-	client, err := newClient()
+	services, err := newWatcher(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	options := catalogOptions{
-		DomainSuffix:     viper.GetString(config.DiscoverySuffix),
-		WatchedNamespace: viper.GetString(config.DiscoveryNamespace),
-		ResyncPeriod:     viper.GetDuration(config.DiscoveryInterval),
-	}
-
 	d := &Discoverer{
-		services: newCatalog(client, options),
+		cfg:      cfg,
+		services: services,
 		data: &state{
 			services: models.NewServiceMap(),
 		},
@@ -59,6 +65,20 @@ func NewDiscoverer() (DiscoveryManager, error) {
 	return d, nil
 }
 
+// newWatcher returns the watcher of the services of the discovery backend set up by cfg.
+func newWatcher(cfg *viper.Viper) (watcher, error) {
+	switch backend := cfg.GetString(config.DiscoveryBackend); backend {
+	case BackendKubernetes:
+		return newKubernetesWatcher(cfg)
+	case BackendConsul:
+		return newConsulCatalog(newConsulOptions(cfg)), nil
+	case BackendRegistry:
+		return newRegistryCatalog(newRegistryOptions(cfg))
+	default:
+		return nil, wraperrors.Errorf("unknown discovery backend %q", backend)
+	}
+}
+
 // Shutdown safely stops Discovery process.
 func (d *Discoverer) Shutdown() {
 	close(d.stop)
@@ -97,27 +117,51 @@ func (d *Discoverer) discover() {
 	//  - set necessary extensions for dapperdox
 	//  - rewrite spec details for Schema, Security Definitions, Security
 	specs := d.fetchAPISpecs()
-	if len(specs) == 0 {
+	if specs == nil {
+		// the specs could not be processed, those of the last discovery are kept
 		return
 	}
 
 	log().Infof("successfully processed [%d] API specs", len(specs))
 
+	// the specs are fetched again on every event, notifying them only when they changed
+	if specsEqual(d.specs, specs) {
+		return
+	}
+
 	// update local cache with latest service specs
 	d.specs = specs
 
 	d.notify()
 }
 
+// specsEqual returns whether two sets of specs have the same content.
+func specsEqual(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for k, v := range a {
+		if w, ok := b[k]; !ok || !bytes.Equal(v, w) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (d *Discoverer) updateServices(s *models.Service, e models.Event) {
 	log().Debugf("(Discover Handler) Service: %v Event: %v", s, e)
 
-	if isIgnoredSvc(s.Hostname) {
+	if isIgnoredSvc(d.cfg, s.Hostname) {
 		log().Debugf("(Discover Handler) skipping service is part of ignore list : %s", s.Hostname)
 
 		return
 	}
 
+	// the services are read while fetching their specs, under the same lock
+	d.sLock.Lock()
+
 	switch e {
 	case models.EventAdd, models.EventUpdate:
 		d.data.services.Insert(s)
@@ -125,13 +169,15 @@ func (d *Discoverer) updateServices(s *models.Service, e models.Event) {
 		d.data.services.Delete(s)
 	}
 
+	d.sLock.Unlock()
+
 	d.discover()
 }
 
 func (d *Discoverer) updateDeployments(dpl *models.Deployment, e models.Event) {
 	log().Debugf("(Discover Handler) Deployment: %v Event: %v", *dpl, e)
 
-	if isIgnoredSvc(dpl.Name) {
+	if isIgnoredSvc(d.cfg, dpl.Name) {
 		// if the deployment that triggered the update is on the ignore list; return
 		log().Debugf("(Discover Handler) skipping deployment that is part of ignore list : %+v", *dpl)
 
@@ -1,16 +1,19 @@
 package discover
 
 import (
+	"net/url"
 	"os"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/kenjones-cisco/dapperdox/config"
 	"github.com/kenjones-cisco/dapperdox/discover/models"
 )
 
 func TestNewDiscoverer(t *testing.T) {
-	if _, err := NewDiscoverer(); err == nil {
+	if _, err := NewDiscoverer(config.New()); err == nil {
 		t.Errorf("NewDiscoverer() error = %v, wantErr %v", err, true)
 	}
 
@@ -33,7 +36,7 @@ func TestNewDiscoverer(t *testing.T) {
 	copyKubeToken()
 	copyKubeCert()
 
-	d, err := NewDiscoverer()
+	d, err := NewDiscoverer(config.New())
 	if err != nil {
 		t.Errorf("NewDiscoverer() error = %v, wantErr %v", err, true)
 	}
@@ -43,8 +46,37 @@ func TestNewDiscoverer(t *testing.T) {
 	}
 }
 
+func TestNewDiscoverer_backend(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+		wantErr bool
+	}{
+		{name: "consul", backend: BackendConsul},
+		{name: "unknown", backend: "zookeeper", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Set(config.DiscoveryBackend, tt.backend)
+
+			d, err := NewDiscoverer(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewDiscoverer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr {
+				if _, ok := d.(*Discoverer).services.(*consulCatalog); !ok {
+					t.Errorf("NewDiscoverer() watcher = %T, want %T", d.(*Discoverer).services, &consulCatalog{})
+				}
+			}
+		})
+	}
+}
+
 func TestDiscoverer_run_fake_service(t *testing.T) {
-	d := &Discoverer{data: &state{services: emptyServiceMap}, services: &fakeController{}, stop: make(chan struct{})}
+	d := &Discoverer{cfg: config.New(), data: &state{services: models.NewServiceMap()}, services: &fakeController{}, stop: make(chan struct{})}
 	go d.Run()
 
 	var once sync.Once
@@ -57,7 +89,7 @@ func TestDiscoverer_run_fake_service(t *testing.T) {
 
 func TestDiscoverer_updateServices(t *testing.T) {
 	// handle the initial run and run where data does not change
-	d := &Discoverer{data: &state{services: emptyServiceMap}, services: &fakeController{}, stop: make(chan struct{})}
+	d := &Discoverer{cfg: config.New(), data: &state{services: models.NewServiceMap()}, services: &fakeController{}, stop: make(chan struct{})}
 
 	type args struct {
 		s *models.Service
@@ -89,21 +121,21 @@ func TestDiscoverer_updateServices(t *testing.T) {
 	}
 
 	// trigger failure within discover()
-	d = &Discoverer{data: &state{services: emptyServiceMap}, services: &fakeController{}, stop: make(chan struct{})}
+	d = &Discoverer{cfg: config.New(), data: &state{services: models.NewServiceMap()}, services: &fakeController{}, stop: make(chan struct{})}
 	d.updateServices(testServices[0], models.EventAdd)
 
 	// trigger failure from services call
-	d = &Discoverer{data: &state{services: emptyServiceMap}, services: &fakeController{wantErr: true, wantNil: false}, stop: make(chan struct{})}
+	d = &Discoverer{cfg: config.New(), data: &state{services: models.NewServiceMap()}, services: &fakeController{wantErr: true, wantNil: false}, stop: make(chan struct{})}
 	d.updateServices(testServices[0], models.EventAdd)
 
 	// trigger no data from services call
-	d = &Discoverer{data: &state{services: emptyServiceMap}, services: &fakeController{wantErr: false, wantNil: true}, stop: make(chan struct{})}
+	d = &Discoverer{cfg: config.New(), data: &state{services: models.NewServiceMap()}, services: &fakeController{wantErr: false, wantNil: true}, stop: make(chan struct{})}
 	d.updateServices(testServices[0], models.EventAdd)
 }
 
 func TestDiscoverer_updateDeployments(t *testing.T) {
 	// handle the initial run and run where data does not change
-	d := &Discoverer{data: &state{services: emptyServiceMap}, services: &fakeController{}, stop: make(chan struct{})}
+	d := &Discoverer{cfg: config.New(), data: &state{services: models.NewServiceMap()}, services: &fakeController{}, stop: make(chan struct{})}
 
 	type args struct {
 		dpl *models.Deployment
@@ -135,14 +167,47 @@ func TestDiscoverer_updateDeployments(t *testing.T) {
 	}
 
 	// trigger failure within discover()
-	d = &Discoverer{data: &state{services: emptyServiceMap}, services: &fakeController{}, stop: make(chan struct{})}
+	d = &Discoverer{cfg: config.New(), data: &state{services: models.NewServiceMap()}, services: &fakeController{}, stop: make(chan struct{})}
 	d.updateDeployments(testDeployments[0], models.EventAdd)
 
 	// trigger failure from services call
-	d = &Discoverer{data: &state{services: emptyServiceMap}, services: &fakeController{wantErr: true, wantNil: false}, stop: make(chan struct{})}
+	d = &Discoverer{cfg: config.New(), data: &state{services: models.NewServiceMap()}, services: &fakeController{wantErr: true, wantNil: false}, stop: make(chan struct{})}
 	d.updateDeployments(testDeployments[0], models.EventAdd)
 
 	// trigger no data from services call
-	d = &Discoverer{data: &state{services: emptyServiceMap}, services: &fakeController{wantErr: false, wantNil: true}, stop: make(chan struct{})}
+	d = &Discoverer{cfg: config.New(), data: &state{services: models.NewServiceMap()}, services: &fakeController{wantErr: false, wantNil: true}, stop: make(chan struct{})}
 	d.updateDeployments(testDeployments[0], models.EventAdd)
 }
+
+func TestDiscoverer_updateServices_removed(t *testing.T) {
+	srv := genServerAPI("fixtures/petstore_api.json")
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	svc := &models.Service{
+		Hostname: "petstore",
+		SpecHost: u.Hostname(),
+		SpecPath: "/swagger.json",
+		Ports:    []*models.Port{{Name: "http", Port: port, Protocol: models.ProtocolHTTP}},
+	}
+
+	var notified int
+
+	d := &Discoverer{cfg: config.New(), data: &state{services: models.NewServiceMap()}, services: &fakeController{}, stop: make(chan struct{})}
+	d.RegisterOnChangeFunc(func() { notified++ })
+
+	d.updateServices(svc, models.EventAdd)
+
+	if len(d.Specs()) != 1 || notified != 1 {
+		t.Errorf("Discoverer.updateServices() specs = %d, notified = %d, want 1 and 1", len(d.Specs()), notified)
+	}
+
+	// the spec of the last service removed is no longer served
+	d.updateServices(svc, models.EventDelete)
+
+	if len(d.Specs()) != 0 || notified != 2 {
+		t.Errorf("Discoverer.updateServices() specs = %d, notified = %d, want 0 and 2", len(d.Specs()), notified)
+	}
+}
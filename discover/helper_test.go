@@ -111,7 +111,6 @@ var testIgnoredServices = []*models.Service{
 
 var (
 	testServiceMap    = models.NewServiceMap(testServices...)
-	emptyServiceMap   = models.NewServiceMap()
 	ignoredServiceMap = models.NewServiceMap(testIgnoredServices...)
 )
 
@@ -6,7 +6,7 @@ import (
 	"sort"
 )
 
-// ServiceMap is a set of Service using Hostname as the unique key.
+// ServiceMap is a set of Service using Key as the unique key.
 type ServiceMap map[string]*Service
 
 // NewServiceMap creates a ServiceMap from a list of Service.
@@ -22,8 +22,8 @@ func (m ServiceMap) Insert(items ...*Service) {
 	for _, item := range items {
 		// if the item does not exist already or the value is not the same
 		// then add/update the entry. Otherwise do nothing.
-		if !m.Has(item) || !reflect.DeepEqual(m[item.Hostname], item) {
-			m[item.Hostname] = item
+		if !m.Has(item) || !reflect.DeepEqual(m[item.Key()], item) {
+			m[item.Key()] = item
 		}
 	}
 }
@@ -31,13 +31,13 @@ func (m ServiceMap) Insert(items ...*Service) {
 // Delete removes all items from the set.
 func (m ServiceMap) Delete(items ...*Service) {
 	for _, item := range items {
-		delete(m, item.Hostname)
+		delete(m, item.Key())
 	}
 }
 
 // Has returns true if and only if item is contained in the set.
 func (m ServiceMap) Has(item *Service) bool {
-	_, exists := m[item.Hostname]
+	_, exists := m[item.Key()]
 
 	return exists
 }
@@ -65,15 +65,15 @@ func (m ServiceMap) HasAny(items ...*Service) bool {
 }
 
 // List returns the contents as a sorted Service slice.
-// sorted by service.Hostname.
+// sorted by service.Key.
 func (m ServiceMap) List() []*Service {
 	services := make([]*Service, 0, len(m))
 	for _, v := range m {
 		services = append(services, v)
 	}
 
-	// sort by hostnames
-	sort.SliceStable(services, func(i, j int) bool { return services[i].Hostname < services[j].Hostname })
+	// sort by keys
+	sort.SliceStable(services, func(i, j int) bool { return services[i].Key() < services[j].Key() })
 
 	return services
 }
@@ -18,6 +18,12 @@ type Service struct {
 	// Hostname of the service, e.g. "catalog.mystore.com"
 	Hostname string `json:"hostname"`
 
+	// Cluster is the label of the cluster of the service, when services are discovered from several.
+	Cluster string `json:"cluster,omitempty"`
+
+	// Namespace is the namespace of the service, when services are discovered from several.
+	Namespace string `json:"namespace,omitempty"`
+
 	// Address specifies the service IPv4 address of the load balancer
 	Address string `json:"address,omitempty"`
 
@@ -32,6 +38,61 @@ type Service struct {
 
 	// LoadBalancingDisabled indicates that no load balancing should be done for this service.
 	LoadBalancingDisabled bool `json:"-"`
+
+	// SpecHost is the host the specification of the service is fetched from, the Hostname when empty.
+	SpecHost string `json:"specHost,omitempty"`
+
+	// SpecPath is the path of the specification served by the service. When empty, the well-known paths of
+	// specifications are probed.
+	SpecPath string `json:"specPath,omitempty"`
+
+	// SpecURL is the URL, or local path, of the specification of the service. When set, the specification
+	// is fetched from it rather than from the ports of the service.
+	SpecURL string `json:"specURL,omitempty"`
+
+	// SpecPort is the name, or number, of the port the specification of the service is served on. When
+	// empty, the specification is fetched from the first of its HTTP ports serving one.
+	SpecPort string `json:"specPort,omitempty"`
+
+	// SpecScheme is the scheme the specification of the service is fetched with, https for HTTPS ports
+	// and http otherwise when empty.
+	SpecScheme string `json:"specScheme,omitempty"`
+
+	// DisplayName is the title the specification of the service is documented by, overriding its own.
+	DisplayName string `json:"displayName,omitempty"`
+
+	// Group is the group the specification of the service is listed in, overriding its own grouping.
+	Group string `json:"group,omitempty"`
+
+	// Excluded is set for a service whose specification is not to be documented.
+	Excluded bool `json:"excluded,omitempty"`
+
+	// Fetch fetches the specification at a URL of the service, for a service that cannot be reached
+	// directly, such as one of a Kubernetes cluster watched from outside. When nil, the specification is
+	// fetched from the URL.
+	Fetch func(source string) ([]byte, error) `json:"-"`
+}
+
+// Key identifies the service among those of every cluster and namespace, as its Hostname prefixed by its
+// Label.
+func (s *Service) Key() string {
+	if label := s.Label(); label != "" {
+		return label + "/" + s.Hostname
+	}
+
+	return s.Hostname
+}
+
+// Label is the Cluster and Namespace of the service, those set, separated by a slash.
+func (s *Service) Label() string {
+	switch {
+	case s.Cluster == "":
+		return s.Namespace
+	case s.Namespace == "":
+		return s.Cluster
+	default:
+		return s.Cluster + "/" + s.Namespace
+	}
 }
 
 // External predicate checks whether the service is external.
@@ -111,7 +172,7 @@ func ConvertCaseInsensitiveStringToProtocol(protocolAsString string) Protocol {
 // IsHTTP is true for protocols that use HTTP as transport protocol.
 func (p Protocol) IsHTTP() bool {
 	switch p {
-	case ProtocolHTTP, ProtocolHTTP2, ProtocolGRPC:
+	case ProtocolHTTP, ProtocolHTTPS, ProtocolHTTP2, ProtocolGRPC:
 		return true
 	default:
 		return false
@@ -102,6 +102,10 @@ func TestHTTPProtocol(t *testing.T) {
 	if !ProtocolGRPC.IsHTTP() {
 		t.Errorf("gRPC is HTTP protocol")
 	}
+
+	if !ProtocolHTTPS.IsHTTP() {
+		t.Errorf("HTTPS is HTTP protocol")
+	}
 }
 
 func TestConvertCaseInsensitiveStringToProtocol(t *testing.T) {
@@ -127,3 +131,40 @@ func TestConvertCaseInsensitiveStringToProtocol(t *testing.T) {
 		}
 	}
 }
+
+func TestServiceKey(t *testing.T) {
+	tests := []struct {
+		name string
+		svc  *Service
+		want string
+	}{
+		{
+			name: "hostname",
+			svc:  &Service{Hostname: "users"},
+			want: "users",
+		},
+		{
+			name: "cluster",
+			svc:  &Service{Hostname: "users", Cluster: "prod"},
+			want: "prod/users",
+		},
+		{
+			name: "namespace",
+			svc:  &Service{Hostname: "users", Namespace: "billing"},
+			want: "billing/users",
+		},
+		{
+			name: "cluster and namespace",
+			svc:  &Service{Hostname: "users", Cluster: "prod", Namespace: "billing"},
+			want: "prod/billing/users",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.svc.Key(); got != tt.want {
+				t.Errorf("Service.Key() = %v, expected = %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package discover
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-openapi/swag"
+	wraperrors "github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/discover/models"
+)
+
+// visibilityPrivate is the visibility of the services of a registry that are not documented.
+const visibilityPrivate = "private"
+
+// registryOptions stores the configurable attributes of a registryCatalog.
+type registryOptions struct {
+	// File is the YAML or JSON file of the registry.
+	File string
+	// Refresh is the period the specs of the services are fetched again at.
+	Refresh time.Duration
+	// Debounce is the time waited for the file to stop changing before reading it again.
+	Debounce time.Duration
+}
+
+// registry is the content of a registry file.
+type registry struct {
+	Services []registryEntry `json:"services"`
+}
+
+// registryEntry is a service listed by a registry file.
+type registryEntry struct {
+	Name string `json:"name"`
+	// Spec is the URL of the spec of the service, or its path relative to the registry file.
+	Spec string `json:"spec"`
+	// Group is the group the spec is listed in, instead of the grouping of the spec.
+	Group string `json:"group"`
+	// Visibility is private for a service not to be documented.
+	Visibility string `json:"visibility"`
+}
+
+// registryCatalog is a watcher of the services listed by a registry file, for setups without a service
+// catalog such as docker-compose. The file is read again when it changes, the services added, updated
+// or removed being notified, and the specs are fetched again periodically, which is notified as an
+// update of the deployment of the registry.
+type registryCatalog struct {
+	options registryOptions
+
+	serviceHandlers    []func(*models.Service, models.Event)
+	deploymentHandlers []func(*models.Deployment, models.Event)
+	known              map[string]*models.Service // services last notified, by name
+}
+
+// newRegistryOptions returns the options of the registry set up by cfg.
+func newRegistryOptions(cfg *viper.Viper) registryOptions {
+	return registryOptions{
+		File:     cfg.GetString(config.DiscoveryRegistryFile),
+		Refresh:  cfg.GetDuration(config.DiscoveryInterval),
+		Debounce: cfg.GetDuration(config.WatchDebounce),
+	}
+}
+
+// newRegistryCatalog creates a watcher of the services listed by the registry file.
+func newRegistryCatalog(options registryOptions) (watcher, error) {
+	if options.File == "" {
+		return nil, wraperrors.Errorf("%s is not set", config.DiscoveryRegistryFile)
+	}
+
+	file, err := filepath.Abs(options.File)
+	if err != nil {
+		return nil, wraperrors.Wrap(err, "unable to locate the registry file")
+	}
+
+	options.File = file
+
+	log().Infof("Service controller watching registry %s", options.File)
+
+	return &registryCatalog{
+		options: options,
+		known:   make(map[string]*models.Service),
+	}, nil
+}
+
+// AppendServiceHandler implements a service catalog operation.
+func (c *registryCatalog) AppendServiceHandler(f func(*models.Service, models.Event)) {
+	c.serviceHandlers = append(c.serviceHandlers, f)
+}
+
+// AppendDeploymentHandler implements a deployment catalog operation.
+func (c *registryCatalog) AppendDeploymentHandler(f func(*models.Deployment, models.Event)) {
+	c.deploymentHandlers = append(c.deploymentHandlers, f)
+}
+
+// Run watches the registry file until a signal is received.
+func (c *registryCatalog) Run(stop <-chan struct{}) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		log().WithError(err).Error("unable to create file watcher")
+
+		return
+	}
+	defer fsw.Close()
+
+	// the directory is watched, as editors replace files rather than writing them
+	if err := fsw.Add(filepath.Dir(c.options.File)); err != nil {
+		log().WithError(err).Errorf("unable to watch the registry %s", c.options.File)
+	}
+
+	c.sync()
+
+	var refresh <-chan time.Time
+
+	if c.options.Refresh > 0 {
+		ticker := time.NewTicker(c.options.Refresh)
+		defer ticker.Stop()
+
+		refresh = ticker.C
+	}
+
+	var fire <-chan time.Time
+
+	for {
+		select {
+		case <-stop:
+			log().Info("watcher terminated")
+
+			return
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return
+			}
+
+			if filepath.Clean(event.Name) == c.options.File {
+				fire = time.After(c.options.Debounce)
+			}
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return
+			}
+
+			log().WithError(err).Warn("registry watcher error")
+		case <-fire:
+			fire = nil
+
+			c.sync()
+		case <-refresh:
+			dpl := &models.Deployment{Name: filepath.Base(c.options.File)}
+
+			for _, f := range c.deploymentHandlers {
+				f(dpl, models.EventUpdate)
+			}
+		}
+	}
+}
+
+// sync reads the registry file, notifying the services added, updated and removed since it was last
+// read. The services are kept as they were when the file cannot be read.
+func (c *registryCatalog) sync() {
+	current, err := c.load()
+	if err != nil {
+		log().WithError(err).Errorf("unable to read the registry %s", c.options.File)
+
+		return
+	}
+
+	for name, svc := range current {
+		known, ok := c.known[name]
+
+		switch {
+		case !ok:
+			c.notify(svc, models.EventAdd)
+		case !reflect.DeepEqual(known, svc):
+			c.notify(svc, models.EventUpdate)
+		}
+	}
+
+	for name, known := range c.known {
+		if _, ok := current[name]; !ok {
+			c.notify(known, models.EventDelete)
+		}
+	}
+
+	c.known = current
+}
+
+func (c *registryCatalog) notify(svc *models.Service, event models.Event) {
+	log().Debugf("Event %s: registry service %s", event, svc.Hostname)
+
+	for _, f := range c.serviceHandlers {
+		f(svc, event)
+	}
+}
+
+// load returns the services documented by the registry file, by name.
+func (c *registryCatalog) load() (map[string]*models.Service, error) {
+	data, err := os.ReadFile(c.options.File)
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML being a superset of JSON, both are read as YAML
+	doc, err := swag.BytesToYAMLDoc(data)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := swag.YAMLToJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var reg registry
+	if err := json.Unmarshal(raw, &reg); err != nil {
+		return nil, wraperrors.Wrap(err, "invalid registry")
+	}
+
+	services := make(map[string]*models.Service)
+
+	for _, entry := range reg.Services {
+		switch {
+		case entry.Name == "" || entry.Spec == "":
+			log().Warnf("registry service %q has no name or spec", entry.Name)
+
+			continue
+		case services[entry.Name] != nil:
+			log().Warnf("registry service %q is listed more than once", entry.Name)
+
+			continue
+		case strings.EqualFold(entry.Visibility, visibilityPrivate):
+			continue
+		}
+
+		services[entry.Name] = &models.Service{
+			Hostname: entry.Name,
+			SpecURL:  c.specURL(entry.Spec),
+			Group:    entry.Group,
+		}
+	}
+
+	return services, nil
+}
+
+// specURL returns the URL of a spec, or its path resolved relative to the registry file.
+func (c *registryCatalog) specURL(spec string) string {
+	if strings.Contains(spec, "://") || filepath.IsAbs(spec) {
+		return spec
+	}
+
+	return filepath.Join(filepath.Dir(c.options.File), spec)
+}
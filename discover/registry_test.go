@@ -0,0 +1,285 @@
+package discover
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/discover/models"
+)
+
+func writeRegistry(t *testing.T, file, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_registryCatalog_load(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "registry.yaml")
+
+	tests := []struct {
+		name     string
+		registry string
+		want     map[string]*models.Service
+		wantErr  bool
+	}{
+		{
+			name: "yaml",
+			registry: `
+services:
+  - name: petstore
+    spec: http://petstore:8080/v2/swagger.json
+    group: Store APIs
+  - name: bookings
+    spec: specs/bookings.json
+  - name: /abs
+    spec: /specs/abs.json
+`,
+			want: map[string]*models.Service{
+				"petstore": {Hostname: "petstore", SpecURL: "http://petstore:8080/v2/swagger.json", Group: "Store APIs"},
+				"bookings": {Hostname: "bookings", SpecURL: filepath.Join(dir, "specs/bookings.json")},
+				"/abs":     {Hostname: "/abs", SpecURL: "/specs/abs.json"},
+			},
+		},
+		{
+			name:     "json",
+			registry: `{"services": [{"name": "petstore", "spec": "https://petstore/swagger.json", "visibility": "public"}]}`,
+			want: map[string]*models.Service{
+				"petstore": {Hostname: "petstore", SpecURL: "https://petstore/swagger.json"},
+			},
+		},
+		{
+			name: "private, duplicate and incomplete services",
+			registry: `
+services:
+  - name: petstore
+    spec: petstore.json
+  - name: petstore
+    spec: other.json
+  - name: internal
+    spec: internal.json
+    visibility: Private
+  - name: nospec
+  - spec: noname.json
+`,
+			want: map[string]*models.Service{
+				"petstore": {Hostname: "petstore", SpecURL: filepath.Join(dir, "petstore.json")},
+			},
+		},
+		{
+			name:     "empty",
+			registry: "",
+			want:     map[string]*models.Service{},
+		},
+		{
+			name:     "invalid",
+			registry: "services: {name: petstore}",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeRegistry(t, file, tt.registry)
+
+			w, err := newRegistryCatalog(registryOptions{File: file})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := w.(*registryCatalog).load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("registryCatalog.load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if len(got) != len(tt.want) {
+				t.Errorf("registryCatalog.load() = %v, want %v", got, tt.want)
+			}
+
+			for name, want := range tt.want {
+				if svc := got[name]; !reflect.DeepEqual(svc, want) {
+					t.Errorf("registryCatalog.load()[%q] = %+v, want %+v", name, svc, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_newRegistryCatalog(t *testing.T) {
+	if _, err := newRegistryCatalog(registryOptions{}); err == nil {
+		t.Errorf("newRegistryCatalog() error = %v, wantErr %v", err, true)
+	}
+}
+
+func TestRegistryCatalog_Run(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "registry.yaml")
+	writeRegistry(t, file, "services: [{name: abc, spec: abc.json}, {name: bob, spec: bob.json}]")
+
+	w, _ := newRegistryCatalog(registryOptions{File: file, Debounce: 10 * time.Millisecond})
+
+	type event struct {
+		name string
+		e    models.Event
+	}
+
+	events := make(chan event, 10)
+	w.AppendServiceHandler(func(svc *models.Service, e models.Event) { events <- event{svc.Hostname, e} })
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		w.Run(stop)
+		close(done)
+	}()
+
+	next := func() event {
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("registryCatalog.Run() no event notified")
+
+			return event{}
+		}
+	}
+
+	for _, want := range []event{{"abc", models.EventAdd}, {"bob", models.EventAdd}} {
+		// the services are notified in no particular order
+		if ev := next(); ev.e != want.e || (ev.name != "abc" && ev.name != "bob") {
+			t.Errorf("registryCatalog.Run() = %v, want %v", ev, want)
+		}
+	}
+
+	writeRegistry(t, file, "services: [{name: abc, spec: abc.json, group: ABC}]")
+
+	for _, want := range []event{{"abc", models.EventUpdate}, {"bob", models.EventDelete}} {
+		if ev := next(); ev != want {
+			t.Errorf("registryCatalog.Run() = %v, want %v", ev, want)
+		}
+	}
+
+	// an invalid registry keeps the services
+	writeRegistry(t, file, "services: {")
+
+	time.Sleep(100 * time.Millisecond)
+
+	close(stop)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("registryCatalog.Run() not terminated")
+	}
+
+	select {
+	case ev := <-events:
+		t.Errorf("registryCatalog.Run() = %v, want no more events", ev)
+	default:
+	}
+}
+
+func TestRegistryCatalog_Run_refresh(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "registry.yaml")
+	writeRegistry(t, file, "services: []")
+
+	w, _ := newRegistryCatalog(registryOptions{File: file, Refresh: 10 * time.Millisecond})
+
+	refreshed := make(chan *models.Deployment, 10)
+	w.AppendDeploymentHandler(func(dpl *models.Deployment, e models.Event) {
+		if e == models.EventUpdate {
+			refreshed <- dpl
+		}
+	})
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go w.Run(stop)
+
+	select {
+	case dpl := <-refreshed:
+		if dpl.Name != "registry.yaml" {
+			t.Errorf("registryCatalog.Run() deployment = %v, want %v", dpl.Name, "registry.yaml")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("registryCatalog.Run() specs not refreshed")
+	}
+}
+
+func TestDiscoverer_registry(t *testing.T) {
+	dir := t.TempDir()
+	copyFile("fixtures/iam_api.json", filepath.Join(dir, "iam_api.json"))
+
+	api := genServerAPI("fixtures/petstore_api.json")
+	defer api.Close()
+
+	file := filepath.Join(dir, "registry.yaml")
+	writeRegistry(t, file, `
+services:
+  - name: petstore
+    spec: `+api.URL+`/swagger.json
+    group: Store APIs
+  - name: iam
+    spec: iam_api.json
+  - name: internal
+    spec: internal_api.json
+    visibility: private
+`)
+
+	// the default configuration, without rewrites
+	cfg := config.New()
+	cfg.Set(config.DiscoveryBackend, BackendRegistry)
+	cfg.Set(config.DiscoveryRegistryFile, file)
+
+	dm, err := NewDiscoverer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var notified int32
+
+	dm.RegisterOnChangeFunc(func() { atomic.AddInt32(&notified, 1) })
+
+	go dm.Run()
+	defer dm.Shutdown()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(dm.Specs()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	specs := dm.Specs()
+	if len(specs) != 2 {
+		t.Fatalf("Discoverer.Specs() = %d specs, want %d", len(specs), 2)
+	}
+
+	if !strings.Contains(string(specs["petstore"]), `"x-groupby": "Store APIs"`) {
+		t.Errorf("Discoverer.Specs() petstore not grouped by the registry")
+	}
+
+	if strings.Contains(string(specs["iam"]), `"x-groupby": "Store APIs"`) {
+		t.Errorf("Discoverer.Specs() iam grouped by the registry")
+	}
+
+	// fetching the same specs again is not notified
+	count := atomic.LoadInt32(&notified)
+
+	dm.(*Discoverer).updateDeployments(&models.Deployment{Name: "registry.yaml"}, models.EventUpdate)
+
+	if got := atomic.LoadInt32(&notified); got != count {
+		t.Errorf("Discoverer notified %d times, want %d", got, count)
+	}
+}
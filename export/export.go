@@ -0,0 +1,133 @@
+// Package export provides the export command, writing the documentation served by dapperdox as a static site.
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/spf13/viper"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/discover"
+	"github.com/kenjones-cisco/dapperdox/handlers"
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// exporter writes the documentation of a suite, served by router, to dir for it to be published under basePath.
+type exporter func(router *mux.Router, suite *spec.Suite, dir, basePath string) (int, error)
+
+// exporters are the exporter of each export format.
+var exporters = map[string]exporter{
+	"site":     exportSite,
+	"print":    exportPrint,
+	"markdown": exportMarkdown,
+	"postman":  exportPostman,
+}
+
+// Command renders the documentation of the specifications configured by cfg, local or discovered, and writes it
+// to the export-dir in the export-format, linked from the export-base-path. It returns the process exit code: 0 if the site was
+// exported, 1 if specifications or pages failed to load or render, and 2 if exporting was not possible.
+func Command(cfg *viper.Viper, w io.Writer) int {
+	export, ok := exporters[cfg.GetString(config.ExportFormat)]
+	if !ok {
+		log().Errorf("unknown export format %q", cfg.GetString(config.ExportFormat))
+
+		return 2
+	}
+
+	var d discover.DiscoveryManager
+
+	if cfg.GetBool(config.DiscoveryEnabled) {
+		snapshot, err := discover.NewDiscoverer(cfg)
+		if err != nil {
+			log().Errorf("unable to create Discoverer: %s", err)
+
+			return 2
+		}
+
+		go snapshot.Run()
+		defer snapshot.Shutdown()
+
+		// Give the services time to be discovered, as the server does before its first update.
+		time.Sleep(cfg.GetDuration(config.DiscoveryInitialDelay))
+
+		d = snapshot
+	}
+
+	router, suite, err := handlers.BuildRouter(cfg, d)
+	if err != nil {
+		log().Errorf("unable to load specifications: %s", err)
+
+		return 2
+	}
+
+	if len(suite.Specs) == 0 {
+		log().Error("no specifications to export")
+
+		return 2
+	}
+
+	pages, err := export(router, suite, cfg.GetString(config.ExportDir), cfg.GetString(config.ExportBasePath))
+
+	var renderErrs RenderErrors
+
+	switch {
+	case errors.As(err, &renderErrs):
+		for _, renderErr := range renderErrs {
+			log().Error(renderErr)
+		}
+	case err != nil:
+		log().Errorf("unable to export the documentation: %s", err)
+
+		return 2
+	}
+
+	_, _ = fmt.Fprintf(w, "Exported %d pages to %s\n", pages, cfg.GetString(config.ExportDir))
+
+	loadErrs := suite.Errors()
+	for _, loadErr := range loadErrs {
+		log().Errorf("Specification problem: %s", loadErr)
+	}
+
+	if len(renderErrs) > 0 || len(loadErrs) > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// exportSite writes every page of the documentation.
+func exportSite(router *mux.Router, _ *spec.Suite, dir, basePath string) (int, error) {
+	return Site(router, dir, basePath)
+}
+
+// exportPrint writes the single page documenting each specification, to be printed.
+func exportPrint(router *mux.Router, suite *spec.Suite, dir, basePath string) (int, error) {
+	return Pages(router, dir, basePath, specPaths(suite, "/print")...)
+}
+
+// exportMarkdown writes the reference documentation as markdown files, which link to each other relatively.
+func exportMarkdown(_ *mux.Router, suite *spec.Suite, dir, _ string) (int, error) {
+	return Markdown(suite, dir)
+}
+
+// exportPostman writes the Postman collection of each specification.
+func exportPostman(router *mux.Router, suite *spec.Suite, dir, basePath string) (int, error) {
+	return Pages(router, dir, basePath, specPaths(suite, "/postman.json")...)
+}
+
+// specPaths returns the sorted paths of a page of each specification, given the path within a specification.
+func specPaths(suite *spec.Suite, p string) []string {
+	paths := make([]string, 0, len(suite.Specs))
+	for id := range suite.Specs {
+		paths = append(paths, "/"+id+p)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
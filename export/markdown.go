@@ -0,0 +1,588 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	wraperrors "github.com/pkg/errors"
+
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// latestVersion is the version of the resources documented by their page when none is selected.
+const latestVersion = "latest"
+
+var (
+	cellEscaper        = strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ")
+	frontMatterEscaper = strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", " ")
+)
+
+// markdownFile is a file written by the markdown export, with the front matter it starts with.
+type markdownFile struct {
+	name  string
+	front [][2]string
+	body  strings.Builder
+}
+
+// markdownSpec writes the markdown files documenting a specification.
+type markdownSpec struct {
+	spec  *spec.APISpecification
+	files []*markdownFile
+}
+
+// Markdown writes the reference documentation of each specification of suite to dir as GitHub flavoured
+// markdown files with front matter. The files are laid out as the routes of the pages documenting the
+// API groups, methods and resources, such as <spec>/reference/<api>/<method>.md, and link to each other
+// relatively. Versions other than the one documented by a route are written to <route>/v/<version>.md.
+// It returns the number of files written.
+func Markdown(suite *spec.Suite, dir string) (int, error) {
+	ids := make([]string, 0, len(suite.Specs))
+	for id := range suite.Specs {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	count := 0
+
+	for _, id := range ids {
+		m := &markdownSpec{spec: suite.Specs[id]}
+		m.document()
+
+		for _, f := range m.files {
+			if err := f.write(dir); err != nil {
+				return count, err
+			}
+
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// document adds the files of the summary, API groups, methods and resources of the specification.
+func (m *markdownSpec) document() {
+	m.summary()
+
+	for _, api := range m.spec.APIs {
+		m.apiGroup(api, api.CurrentVersion, api.Methods)
+
+		for _, version := range sortedMethodVersions(api.Versions) {
+			if version != api.CurrentVersion {
+				m.apiGroup(api, version, api.Versions[version])
+			}
+		}
+	}
+
+	versions := make([]string, 0, len(m.spec.ResourceList))
+	for version := range m.spec.ResourceList {
+		versions = append(versions, version)
+	}
+
+	sort.Strings(versions)
+
+	for _, version := range versions {
+		ids := make([]string, 0, len(m.spec.ResourceList[version]))
+		for id := range m.spec.ResourceList[version] {
+			ids = append(ids, id)
+		}
+
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			m.resource(m.spec.ResourceList[version][id], version)
+		}
+	}
+}
+
+func (m *markdownSpec) add(name string, front ...[2]string) *markdownFile {
+	f := &markdownFile{
+		name:  name,
+		front: append([][2]string{{"specification", m.spec.ID}}, front...),
+	}
+	m.files = append(m.files, f)
+
+	return f
+}
+
+// summary documents the specification, listing the methods of each of its API groups.
+func (m *markdownSpec) summary() {
+	f := m.add(m.spec.ID+"/reference.md", [2]string{"title", m.spec.APIInfo.Title + " reference"})
+
+	fmt.Fprintf(&f.body, "# %s reference\n", m.spec.APIInfo.Title)
+
+	if m.spec.APIInfo.Description != "" {
+		fmt.Fprintf(&f.body, "\n%s\n", strings.TrimSpace(m.spec.APIInfo.Description))
+	}
+
+	if len(m.spec.Servers) > 0 {
+		f.body.WriteString("\n## Servers\n\n| URL | Description |\n|-----|-------------|\n")
+
+		for _, server := range m.spec.Servers {
+			fmt.Fprintf(&f.body, "| %s | %s |\n", cellEscaper.Replace(server.URL), cellEscaper.Replace(server.Description))
+		}
+	}
+
+	for _, api := range m.spec.APIs {
+		fmt.Fprintf(&f.body, "\n## [%s](%s)\n\n", api.Name, f.link(m.apiFile(api, api.CurrentVersion)))
+		m.operations(f, api, api.CurrentVersion, api.Methods)
+	}
+}
+
+// apiGroup documents an API group in a version, and each of its methods.
+func (m *markdownSpec) apiGroup(api spec.APIGroup, version string, methods []spec.Method) {
+	f := m.add(m.apiFile(api, version),
+		[2]string{"title", api.Name},
+		[2]string{"api", api.ID},
+		[2]string{"version", version},
+	)
+
+	fmt.Fprintf(&f.body, "# %s\n\n", api.Name)
+	m.operations(f, api, version, methods)
+
+	for i := range methods {
+		m.method(api, version, &methods[i])
+	}
+}
+
+// operations writes the table of the methods of an API group, linking to their files.
+func (m *markdownSpec) operations(f *markdownFile, api spec.APIGroup, version string, methods []spec.Method) {
+	f.body.WriteString("| Operation | HTTP request | Description |\n|-----------|--------------|-------------|\n")
+
+	for _, method := range methods {
+		fmt.Fprintf(&f.body, "| [%s](%s) | `%s %s` | %s |\n",
+			cellEscaper.Replace(method.OperationName), f.link(m.methodFile(api, method.ID, version)),
+			strings.ToUpper(method.Method), cellEscaper.Replace(method.Path), cellEscaper.Replace(method.Name))
+	}
+}
+
+// method documents a method of an API group in a version.
+func (m *markdownSpec) method(api spec.APIGroup, version string, method *spec.Method) {
+	f := m.add(m.methodFile(api, method.ID, version),
+		[2]string{"title", method.Name},
+		[2]string{"api", api.ID},
+		[2]string{"method", method.ID},
+		[2]string{"operation", method.OperationName},
+		[2]string{"version", version},
+	)
+
+	fmt.Fprintf(&f.body, "# %s\n", method.Name)
+
+	if method.Description != "" {
+		fmt.Fprintf(&f.body, "\n%s\n", strings.TrimSpace(method.Description))
+	}
+
+	apiURL := ""
+	if api.URL != nil {
+		apiURL = api.URL.String()
+	}
+
+	fmt.Fprintf(&f.body, "\n## Request\n\n```\n%s %s%s\n```\n", strings.ToUpper(method.Method), apiURL, method.Path)
+
+	for _, params := range []struct {
+		title  string
+		params []spec.Parameter
+	}{
+		{"Path parameters", method.PathParams},
+		{"Query parameters", method.QueryParams},
+		{"Request headers", method.HeaderParams},
+		{"Cookie parameters", method.CookieParams},
+		{"Form parameters", method.FormParams},
+	} {
+		if len(params.params) > 0 {
+			fmt.Fprintf(&f.body, "\n### %s\n\n", params.title)
+			writeParams(f, params.params)
+		}
+	}
+
+	if body := method.BodyParam; body != nil && body.Resource != nil {
+		kind := "a complete"
+		title := body.Resource.Title + " resource"
+
+		if body.IsArray {
+			kind, title = "an array of", title+"s"
+		}
+
+		fmt.Fprintf(&f.body, "\n### Request body\n\nThe request body takes %s [%s](%s), containing the following writable properties:\n\n",
+			kind, title, f.link(m.resourceFile(body.Resource.ID, version)))
+		writeCode(f, body.Resource.Schema)
+		f.body.WriteString("\n")
+		m.properties(f, body.Resource, version)
+
+		if body.Resource.Example != "" {
+			f.body.WriteString("\n#### Example\n\n")
+			writeCode(f, body.Resource.Example)
+		}
+	}
+
+	if len(method.Security) > 0 {
+		f.body.WriteString("\n## Authorisation\n\nThis request requires the use of one of following authorisation methods: ")
+		writeSecurity(f, method.Security)
+	}
+
+	m.responses(f, method, version)
+}
+
+// responses writes the table of the responses of a method.
+func (m *markdownSpec) responses(f *markdownFile, method *spec.Method, version string) {
+	f.body.WriteString("\n## Response\n\nThe following HTTP status codes may be returned, optionally with a response resource.\n\n")
+	f.body.WriteString("| Status code | Description | Resource |\n|-------------|-------------|----------|\n")
+
+	row := func(status string, response *spec.Response) {
+		description := inline(response.Description)
+		if response.StatusDescription != "" {
+			description = response.StatusDescription + " " + description
+		}
+
+		resource := ""
+
+		if response.Resource != nil {
+			title := response.Resource.Title
+			if response.IsArray {
+				title += "[]"
+			}
+
+			resource = fmt.Sprintf("[%s](%s)", cellEscaper.Replace(title), f.link(m.resourceFile(response.Resource.ID, version)))
+		}
+
+		fmt.Fprintf(&f.body, "| %s | %s | %s |\n", status, cellEscaper.Replace(description), resource)
+	}
+
+	codes := make([]int, 0, len(method.Responses))
+	for code := range method.Responses {
+		codes = append(codes, code)
+	}
+
+	sort.Ints(codes)
+
+	for _, code := range codes {
+		response := method.Responses[code]
+		row(fmt.Sprint(code), &response)
+	}
+
+	for _, status := range sortedRanges(method.ResponseRanges) {
+		response := method.ResponseRanges[status]
+		row(status, &response)
+	}
+
+	if method.DefaultResponse != nil {
+		row("default", method.DefaultResponse)
+	}
+}
+
+// resource documents a resource in a version.
+func (m *markdownSpec) resource(resource *spec.Resource, version string) {
+	f := m.add(m.resourceFile(resource.ID, version),
+		[2]string{"title", resource.Title},
+		[2]string{"resource", resource.ID},
+		[2]string{"version", version},
+	)
+
+	fmt.Fprintf(&f.body, "# %s resource\n", resource.Title)
+
+	if resource.Description != "" && resource.Description != resource.Title {
+		fmt.Fprintf(&f.body, "\n%s\n", strings.TrimSpace(resource.Description))
+	}
+
+	if len(resource.Methods) > 0 {
+		f.body.WriteString("\n## Methods\n\n")
+
+		keys := make([]string, 0, len(resource.Methods))
+		for key := range resource.Methods {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			method := resource.Methods[key]
+			if method.APIGroup == nil {
+				continue
+			}
+
+			fmt.Fprintf(&f.body, "- [%s](%s) - %s\n",
+				strings.ToUpper(method.Method), f.link(m.methodFile(*method.APIGroup, method.ID, version)), method.Name)
+		}
+	}
+
+	f.body.WriteString("\n## Resource\n\n")
+	writeCode(f, resource.Schema)
+
+	f.body.WriteString("\n## Properties\n\n")
+	m.properties(f, resource, version)
+
+	for _, variant := range resource.Variants {
+		fmt.Fprintf(&f.body, "\n### Variant [%s](%s)\n\n", variant.Title, f.link(m.resourceFile(variant.ID, version)))
+		writeCode(f, variant.Schema)
+	}
+
+	if resource.Example != "" {
+		f.body.WriteString("\n## Example\n\n")
+		writeCode(f, resource.Example)
+	}
+
+	if len(resource.Examples) > 0 {
+		f.body.WriteString("\n## Examples\n")
+	}
+
+	for _, example := range resource.Examples {
+		name := example.Summary
+		if name == "" {
+			name = example.Name
+		}
+
+		fmt.Fprintf(&f.body, "\n### %s\n\n", name)
+
+		if example.Description != "" {
+			fmt.Fprintf(&f.body, "%s\n\n", example.Description)
+		}
+
+		writeCode(f, example.Value)
+	}
+}
+
+// properties writes the table of the properties of a resource, nested properties following their parent.
+func (m *markdownSpec) properties(f *markdownFile, resource *spec.Resource, version string) {
+	f.body.WriteString("| Name | Type | Description | Additional |\n|------|------|-------------|------------|\n")
+
+	var write func(r *spec.Resource)
+
+	write = func(r *spec.Resource) {
+		names := make([]string, 0, len(r.Properties))
+		for name := range r.Properties {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			property := r.Properties[name]
+
+			fqn := property.ID
+			if len(property.FQNS) > 0 {
+				fqn = strings.Join(property.FQNS, ".") + "." + fqn
+			}
+
+			typ := strings.Join(property.Type, " of ")
+			if property.Recursive != nil {
+				typ += fmt.Sprintf(" ([%s](%s), recursive)", property.Recursive.Title, f.link(m.resourceFile(property.Recursive.ID, version)))
+			}
+
+			description := inline(property.Description)
+			if len(property.Enum) > 0 {
+				description += " Possible values are: " + codeList(property.Enum) + "."
+			}
+
+			fmt.Fprintf(&f.body, "| `%s` | %s | %s | %s |\n",
+				fqn, cellEscaper.Replace(typ), cellEscaper.Replace(strings.TrimSpace(description)), propertyAdditional(property))
+
+			write(property)
+		}
+	}
+
+	write(resource)
+}
+
+func (m *markdownSpec) apiFile(api spec.APIGroup, version string) string {
+	return versionedFile(m.spec.ID+"/reference/"+api.ID, version, api.CurrentVersion)
+}
+
+func (m *markdownSpec) methodFile(api spec.APIGroup, methodID, version string) string {
+	return versionedFile(m.spec.ID+"/reference/"+api.ID+"/"+methodID, version, api.CurrentVersion)
+}
+
+func (m *markdownSpec) resourceFile(resourceID, version string) string {
+	return versionedFile(m.spec.ID+"/resources/"+resourceID, version, latestVersion)
+}
+
+// versionedFile returns the file of a route, for a version other than the one documented by the route
+// under its v directory.
+func versionedFile(route, version, routeVersion string) string {
+	if version == "" || version == routeVersion {
+		return route + ".md"
+	}
+
+	return route + "/" + versionParam + "/" + version + ".md"
+}
+
+// link returns the link to another file, relative to this one.
+func (f *markdownFile) link(file string) string {
+	from := strings.Split(path.Dir(f.name), "/")
+	to := strings.Split(file, "/")
+
+	common := 0
+	for common < len(from) && common < len(to)-1 && from[common] == to[common] {
+		common++
+	}
+
+	rel := strings.Repeat("../", len(from)-common) + strings.Join(to[common:], "/")
+
+	return strings.ReplaceAll(rel, " ", "%20")
+}
+
+// write writes the file under dir, starting with its front matter.
+func (f *markdownFile) write(dir string) error {
+	var b strings.Builder
+
+	b.WriteString("---\n")
+
+	for _, kv := range f.front {
+		fmt.Fprintf(&b, "%s: \"%s\"\n", kv[0], frontMatterEscaper.Replace(kv[1]))
+	}
+
+	b.WriteString("---\n\n")
+	b.WriteString(f.body.String())
+
+	name := filepath.Join(dir, filepath.FromSlash(f.name))
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return wraperrors.Wrapf(err, "unable to create directory for %s", f.name)
+	}
+
+	return wraperrors.Wrapf(os.WriteFile(name, []byte(b.String()), 0o644), "unable to write %s", f.name)
+}
+
+func writeParams(f *markdownFile, params []spec.Parameter) {
+	f.body.WriteString("| Parameter name | Value | Description | Additional |\n|----------------|-------|-------------|------------|\n")
+
+	for _, p := range params {
+		value := strings.Join(p.Type, " of ")
+		if p.CollectionFormatDescription != "" {
+			value += ", " + p.CollectionFormatDescription
+		}
+
+		description := inline(p.Description)
+		if len(p.Enum) > 0 {
+			description += " Possible values are: " + codeList(p.Enum) + "."
+		}
+
+		required := ""
+		if p.Required {
+			required = "Required"
+		}
+
+		fmt.Fprintf(&f.body, "| `%s` | %s | %s | %s |\n",
+			p.Name, cellEscaper.Replace(value), cellEscaper.Replace(strings.TrimSpace(description)), required)
+	}
+}
+
+func writeSecurity(f *markdownFile, security map[string]spec.Security) {
+	names := make([]string, 0, len(security))
+	for name := range security {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	kinds := make([]string, 0, len(names))
+
+	for _, name := range names {
+		scheme := security[name].Scheme
+		if scheme == nil {
+			continue
+		}
+
+		switch {
+		case scheme.IsAPIKey:
+			kinds = append(kinds, "`API key`")
+		case scheme.IsBasic:
+			kinds = append(kinds, "`BASIC`")
+		case scheme.IsBearer:
+			kinds = append(kinds, "`Bearer`")
+		case scheme.IsOAuth2:
+			kinds = append(kinds, "`OAuth2`")
+		}
+	}
+
+	fmt.Fprintf(&f.body, "%s.\n", strings.Join(kinds, " "))
+
+	for _, name := range names {
+		sec := security[name]
+		if sec.Scheme == nil || !sec.Scheme.IsOAuth2 || len(sec.Scopes) == 0 {
+			continue
+		}
+
+		f.body.WriteString("\nFor OAuth 2 authorisation, the following scopes are required:\n\n| Scope | Description |\n|-------|-------------|\n")
+
+		for _, scope := range sortedScopes(sec.Scopes) {
+			fmt.Fprintf(&f.body, "| `%s` | %s |\n", scope, cellEscaper.Replace(sec.Scopes[scope]))
+		}
+	}
+}
+
+// writeCode writes a JSON code block.
+func writeCode(f *markdownFile, code string) {
+	fmt.Fprintf(&f.body, "```json\n%s\n```\n", strings.TrimRight(code, "\n"))
+}
+
+// inline returns an HTML description to be written within a line, such as a table cell, unwrapping it
+// from its paragraph when it has only one.
+func inline(description string) string {
+	description = strings.TrimSpace(description)
+
+	if strings.HasPrefix(description, "<p>") && strings.HasSuffix(description, "</p>") && strings.Count(description, "<p>") == 1 {
+		description = strings.TrimSuffix(strings.TrimPrefix(description, "<p>"), "</p>")
+	}
+
+	return description
+}
+
+func propertyAdditional(property *spec.Resource) string {
+	switch {
+	case !property.Required && property.ReadOnly:
+		return "Optional, read only."
+	case !property.Required:
+		return "Optional"
+	case property.ReadOnly:
+		return "Read only."
+	default:
+		return ""
+	}
+}
+
+func codeList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "`" + v + "`"
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+func sortedMethodVersions(m map[string][]spec.Method) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedRanges(m map[string]spec.Response) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedScopes(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
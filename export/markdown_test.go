@@ -0,0 +1,169 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+func TestMarkdown(t *testing.T) {
+	markdownLinkRegex := regexp.MustCompile(`\]\(([^)]+\.md)\)`)
+
+	tests := []struct {
+		name      string
+		file      string
+		wantFiles map[string][]string // content expected of each file
+	}{
+		{
+			name: "methods and resources",
+			file: "bookings_api.json",
+			wantFiles: map[string][]string{
+				"bookings/reference.md": {
+					"title: \"Bookings reference\"",
+					"## [Bookings of rooms](reference/bookings-of-rooms.md)",
+				},
+				"bookings/reference/bookings-of-rooms.md": {
+					"| [get](bookings-of-rooms/list-bookings-v2.md) | `GET /v2/bookings` | List bookings |",
+				},
+				"bookings/reference/bookings-of-rooms/list-bookings-v2.md": {
+					"method: \"list-bookings-v2\"",
+					"GET https://bookings.example.com/v2/bookings",
+					"| `room` | string | Room the bookings are for |  |",
+					"| 200 | OK The bookings | [Booking[]](../../resources/booking.md) |",
+				},
+				"bookings/resources/booking.md": {
+					"resource: \"booking\"",
+					"- [GET](../reference/bookings-of-rooms/list-bookings-v2.md) - List bookings",
+					"| `room` | string |  | Optional |",
+				},
+			},
+		},
+		{
+			name: "examples",
+			file: "examples_api.json",
+			wantFiles: map[string][]string{
+				"bookings/resources/booking.md": {
+					"## Examples",
+					"### A family booking",
+					"\"guests\": 4",
+					"Possible values are: `pending`, `confirmed`.",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Set(config.SpecDir, "../fixtures/")
+			cfg.Set(config.SpecFilename, []string{tt.file})
+			cfg.Set(config.DefaultAssetsDir, "../assets")
+
+			suite, err := spec.LoadSpecifications(cfg, nil)
+			if err != nil {
+				t.Fatalf("LoadSpecifications() error = %v", err)
+			}
+
+			dir := t.TempDir()
+
+			files, err := Markdown(suite, dir)
+			if err != nil {
+				t.Fatalf("Markdown() error = %v", err)
+			}
+
+			if files == 0 {
+				t.Error("Markdown() wrote no files")
+			}
+
+			for file, wantMatches := range tt.wantFiles {
+				content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+				if err != nil {
+					t.Errorf("Markdown() did not write %s: %v", file, err)
+
+					continue
+				}
+
+				if !strings.HasPrefix(string(content), "---\nspecification: ") {
+					t.Errorf("Markdown() %s does not start with front matter", file)
+				}
+
+				for _, want := range wantMatches {
+					if !strings.Contains(string(content), want) {
+						t.Errorf("Markdown() %s does not contain %s", file, want)
+					}
+				}
+			}
+
+			_ = filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+
+				content, err := os.ReadFile(name)
+				if err != nil {
+					return err
+				}
+
+				for _, m := range markdownLinkRegex.FindAllStringSubmatch(string(content), -1) {
+					if _, err := os.Stat(filepath.Join(filepath.Dir(name), filepath.FromSlash(m[1]))); err != nil {
+						t.Errorf("Markdown() %s links to missing %s", name, m[1])
+					}
+				}
+
+				return nil
+			})
+		})
+	}
+}
+
+func TestMarkdownFile_link(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{
+			name: "sibling",
+			from: "spec/resources/pet.md",
+			to:   "spec/resources/tag.md",
+			want: "tag.md",
+		},
+		{
+			name: "method to resource",
+			from: "spec/reference/pets/add-pet.md",
+			to:   "spec/resources/pet.md",
+			want: "../../resources/pet.md",
+		},
+		{
+			name: "summary to API group",
+			from: "spec/reference.md",
+			to:   "spec/reference/pets.md",
+			want: "reference/pets.md",
+		},
+		{
+			name: "other version",
+			from: "spec/reference/pets/add-pet.md",
+			to:   "spec/resources/pet/v/1.0.md",
+			want: "../../resources/pet/v/1.0.md",
+		},
+		{
+			name: "space escaped",
+			from: "spec/reference.md",
+			to:   "spec/resources/my pet.md",
+			want: "resources/my%20pet.md",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &markdownFile{name: tt.from}
+			if got := f.link(tt.to); got != tt.want {
+				t.Errorf("link() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
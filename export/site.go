@@ -0,0 +1,416 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	wraperrors "github.com/pkg/errors"
+)
+
+// versionParam is the query parameter selecting the version of a reference page.
+const versionParam = "v"
+
+var (
+	// htmlLinkRegexes match the attributes of an HTML page linking to another resource, quoted either way.
+	htmlLinkRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`(\b(?:href|src|action)=")([^"]*)(")`),
+		regexp.MustCompile(`(\b(?:href|src|action)=')([^']*)(')`),
+	}
+	// cssLinkRegex matches the resources a stylesheet refers to.
+	cssLinkRegex = regexp.MustCompile(`(url\(['"]?)([^'")]*)(['"]?\))`)
+)
+
+// RenderErrors lists the pages that could not be rendered.
+type RenderErrors []error
+
+func (e RenderErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d pages failed to render: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// page is a resource of the site, rendered by the handler of a route.
+type page struct {
+	path     string // path of the route
+	version  string // version selected by the page, empty for the current one
+	html     bool   // whether the page is an HTML document, written as the index of a directory
+	redirect string // target the route redirects to, if it does
+	body     []byte
+}
+
+// key identifies the page among those of the site.
+func (p *page) key() string {
+	return pageKey(p.path, p.version)
+}
+
+func pageKey(p, version string) string {
+	if version == "" {
+		return p
+	}
+
+	return p + "?" + versionParam + "=" + version
+}
+
+// file returns the location of the page within the site.
+func (p *page) file() string {
+	if !p.html {
+		return strings.TrimPrefix(p.path, "/")
+	}
+
+	dir := strings.Trim(p.path, "/")
+	if p.version != "" {
+		dir = path.Join(dir, versionParam, url.PathEscape(p.version))
+	}
+
+	if dir == "" {
+		return "index.html"
+	}
+
+	return dir + "/index.html"
+}
+
+// url returns the URL of the page within the site, relative to its base path.
+func (p *page) url() string {
+	if !p.html {
+		return strings.TrimPrefix(p.path, "/")
+	}
+
+	return strings.TrimSuffix(p.file(), "index.html")
+}
+
+// site renders the pages registered on a router, and writes them under a base path.
+type site struct {
+	routes   map[string]http.Handler // handlers of the routes, keyed by path
+	basePath string
+	pages    map[string]*page
+	errs     RenderErrors
+}
+
+// Site renders every page registered on router, including those of each version of the reference
+// documentation, and writes them to dir as a static site published under basePath. Links between the
+// pages are rewritten to their location within the site. It returns the number of pages written, and
+// RenderErrors listing the pages that failed to render, which are left out of the site.
+func Site(router *mux.Router, dir, basePath string) (int, error) {
+	s := newSite(router, basePath)
+
+	paths := make([]string, 0, len(s.routes))
+	for p := range s.routes {
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		s.render(p, "")
+	}
+
+	return s.writeAll(dir)
+}
+
+// Pages renders the pages registered on router at paths, along with the stylesheets, scripts and images
+// they link to, and writes them to dir as Site does. Links to other pages are left to the site published
+// under basePath.
+func Pages(router *mux.Router, dir, basePath string, paths ...string) (int, error) {
+	s := newSite(router, basePath)
+
+	for _, p := range paths {
+		s.renderPage(p, "")
+	}
+
+	s.renderAssets()
+
+	return s.writeAll(dir)
+}
+
+func newSite(router *mux.Router, basePath string) *site {
+	s := &site{
+		routes:   make(map[string]http.Handler),
+		basePath: "/" + strings.Trim(basePath, "/") + "/",
+		pages:    make(map[string]*page),
+	}
+
+	if s.basePath == "//" {
+		s.basePath = "/"
+	}
+
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		s.addRoute(route)
+
+		return nil
+	})
+
+	return s
+}
+
+// writeAll writes the pages rendered to dir, returning their number and the errors rendering them.
+func (s *site) writeAll(dir string) (int, error) {
+	s.dropDeadRedirects()
+
+	for _, p := range s.sortedPages() {
+		if err := s.write(dir, p); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(s.errs) > 0 {
+		return len(s.pages), s.errs
+	}
+
+	return len(s.pages), nil
+}
+
+// addRoute records the handler of a route serving a single path on GET. Routes matching path prefixes,
+// such as those proxied, or path variables do not serve pages.
+func (s *site) addRoute(route *mux.Route) {
+	tmpl, err := route.GetPathTemplate()
+	if err != nil {
+		return
+	}
+
+	if re, err := route.GetPathRegexp(); err != nil || !strings.HasSuffix(re, "$") || strings.Contains(tmpl, "{") {
+		return
+	}
+
+	methods, err := route.GetMethods()
+	if err != nil {
+		return
+	}
+
+	for _, method := range methods {
+		if method == http.MethodGet && route.GetHandler() != nil {
+			s.routes[tmpl] = route.GetHandler()
+		}
+	}
+}
+
+// render renders the page of a route for a version, followed by the pages of the other versions it links to.
+func (s *site) render(p, version string) {
+	if _, ok := s.pages[pageKey(p, version)]; ok {
+		return
+	}
+
+	pg := s.renderPage(p, version)
+	if pg == nil || !pg.html || pg.redirect != "" {
+		return
+	}
+
+	for _, u := range localLinks(pg.body) {
+		if v := u.Query().Get(versionParam); v != "" {
+			if _, ok := s.routes[u.Path]; ok {
+				s.render(u.Path, v)
+			}
+		}
+	}
+}
+
+// renderPage renders the page of a route for a version, returning nil if it failed to render.
+func (s *site) renderPage(p, version string) *page {
+	target := p
+	if version != "" {
+		target += "?" + url.Values{versionParam: {version}}.Encode()
+	}
+
+	rec, err := s.serve(target)
+	if err != nil {
+		s.errs = append(s.errs, wraperrors.Wrapf(err, "GET %s", target))
+
+		return nil
+	}
+
+	pg := &page{path: p, version: version, body: rec.Body.Bytes()}
+
+	switch {
+	case rec.Code >= http.StatusMultipleChoices && rec.Code < http.StatusBadRequest && rec.Header().Get("Location") != "":
+		location, err := url.Parse(rec.Header().Get("Location"))
+		if err != nil {
+			s.errs = append(s.errs, wraperrors.Wrapf(err, "GET %s: invalid redirect", target))
+
+			return nil
+		}
+
+		pg.redirect = (&url.URL{Path: p}).ResolveReference(location).String()
+		pg.html = true
+	case rec.Code != http.StatusOK:
+		s.errs = append(s.errs, wraperrors.Errorf("GET %s: status %d", target, rec.Code))
+
+		return nil
+	default:
+		pg.html = strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html")
+	}
+
+	s.pages[pg.key()] = pg
+
+	return pg
+}
+
+// renderAssets renders the resources the HTML pages rendered link to, other than further pages.
+func (s *site) renderAssets() {
+	for _, pg := range s.sortedPages() {
+		if !pg.html || pg.redirect != "" {
+			continue
+		}
+
+		for _, link := range localLinks(pg.body) {
+			if _, ok := s.routes[link.Path]; !ok {
+				continue
+			}
+
+			if _, ok := s.pages[link.Path]; ok {
+				continue
+			}
+
+			if asset := s.renderPage(link.Path, ""); asset != nil && asset.html {
+				delete(s.pages, asset.key())
+			}
+		}
+	}
+}
+
+// dropDeadRedirects leaves out of the site the redirects to pages it does not hold, which would be broken.
+func (s *site) dropDeadRedirects() {
+	for k, p := range s.pages {
+		if p.redirect == "" {
+			continue
+		}
+
+		if _, ok := s.lookup(p.redirect); !ok {
+			log().Warnf("Not exporting %s, which redirects to %s outside of the site", k, p.redirect)
+
+			delete(s.pages, k)
+		}
+	}
+}
+
+// serve serves a GET of target with the handler of its route, reporting the panics of the handler.
+func (s *site) serve(target string) (rec *httptest.ResponseRecorder, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = wraperrors.Errorf("%v", r)
+		}
+	}()
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	rec = httptest.NewRecorder()
+
+	s.routes[req.URL.Path].ServeHTTP(rec, req)
+
+	return rec, nil
+}
+
+// write writes a page to its location under dir, rewriting the links it holds.
+func (s *site) write(dir string, p *page) error {
+	body := p.body
+
+	switch {
+	case p.redirect != "":
+		link := html.EscapeString(s.link(p.redirect))
+		body = []byte(fmt.Sprintf(`<!DOCTYPE html><html><head><meta http-equiv="refresh" content="0; url=%s">`+
+			`<link rel="canonical" href="%s"></head><body><a href="%s">%s</a></body></html>`, link, link, link, link))
+	case p.html:
+		for _, re := range htmlLinkRegexes {
+			body = s.rewrite(re, body)
+		}
+	case strings.HasSuffix(p.path, ".css"):
+		body = s.rewrite(cssLinkRegex, body)
+	}
+
+	name := filepath.Join(dir, filepath.FromSlash(p.file()))
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return wraperrors.Wrapf(err, "unable to create directory for %s", p.key())
+	}
+
+	return wraperrors.Wrapf(os.WriteFile(name, body, 0o644), "unable to write %s", p.key())
+}
+
+// rewrite rewrites the local links matched by re, whose second group is the link.
+func (s *site) rewrite(re *regexp.Regexp, body []byte) []byte {
+	return re.ReplaceAllFunc(body, func(m []byte) []byte {
+		parts := re.FindSubmatch(m)
+
+		return []byte(string(parts[1]) + s.link(string(parts[2])) + string(parts[3]))
+	})
+}
+
+// link returns the URL of a link within the site, leaving links to other sites unchanged.
+func (s *site) link(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || !isLocal(u) {
+		return link
+	}
+
+	rewritten := s.basePath + strings.TrimPrefix(u.Path, "/")
+
+	if p, ok := s.lookup(link); ok {
+		rewritten = s.basePath + p.url()
+	} else if u.RawQuery != "" {
+		rewritten += "?" + u.RawQuery
+	}
+
+	if u.Fragment != "" {
+		rewritten += "#" + u.EscapedFragment()
+	}
+
+	return rewritten
+}
+
+// lookup returns the page of the site a local link refers to.
+func (s *site) lookup(link string) (*page, bool) {
+	u, err := url.Parse(link)
+	if err != nil || !isLocal(u) {
+		return nil, false
+	}
+
+	p, ok := s.pages[pageKey(u.Path, u.Query().Get(versionParam))]
+
+	return p, ok
+}
+
+func (s *site) sortedPages() []*page {
+	keys := make([]string, 0, len(s.pages))
+	for k := range s.pages {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	pages := make([]*page, len(keys))
+	for i, k := range keys {
+		pages[i] = s.pages[k]
+	}
+
+	return pages
+}
+
+// localLinks returns the local links of an HTML page.
+func localLinks(body []byte) []*url.URL {
+	var links []*url.URL
+
+	for _, re := range htmlLinkRegexes {
+		for _, m := range re.FindAllSubmatch(body, -1) {
+			if u, err := url.Parse(string(m[2])); err == nil && isLocal(u) {
+				links = append(links, u)
+			}
+		}
+	}
+
+	return links
+}
+
+// isLocal returns whether a URL is an absolute path on the site itself.
+func isLocal(u *url.URL) bool {
+	return u.Scheme == "" && u.Host == "" && strings.HasPrefix(u.Path, "/")
+}
@@ -0,0 +1,180 @@
+package export
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/handlers"
+)
+
+func TestSite(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.SpecDir, "../fixtures/")
+	cfg.Set(config.SpecFilename, []string{"bookings_api.json"})
+	cfg.Set(config.DefaultAssetsDir, "../assets")
+
+	router, _, err := handlers.BuildRouter(cfg, nil)
+	if err != nil {
+		t.Fatalf("BuildRouter() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		basePath string
+		wantLink string
+	}{
+		{
+			name:     "root",
+			basePath: "",
+			wantLink: `href="/bookings/reference/bookings-of-rooms/list-bookings-v2/v/latest/"`,
+		},
+		{
+			name:     "root slash",
+			basePath: "/",
+			wantLink: `href="/bookings/reference/bookings-of-rooms/list-bookings-v2/v/latest/"`,
+		},
+		{
+			name:     "relative base path",
+			basePath: "docs",
+			wantLink: `href="/docs/bookings/reference/bookings-of-rooms/list-bookings-v2/v/latest/"`,
+		},
+		{
+			name:     "base path with slashes",
+			basePath: "/docs/",
+			wantLink: `href="/docs/bookings/reference/bookings-of-rooms/list-bookings-v2/v/latest/"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			pages, err := Site(router, dir, tt.basePath)
+			if err != nil {
+				t.Fatalf("Site() error = %v", err)
+			}
+
+			if pages == 0 {
+				t.Error("Site() wrote no pages")
+			}
+
+			for _, file := range []string{
+				"index.html",
+				"bookings/reference/index.html",
+				"bookings/reference/legacy-bookings/list-bookings-v1/index.html",
+				"bookings/reference/legacy-bookings/list-bookings-v1/v/latest/index.html",
+				"bookings/resources/booking/v/latest/index.html",
+				"bookings/resources/booking/index.html",
+				"css/style.css",
+				"js/explorer.js",
+			} {
+				if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(file))); err != nil {
+					t.Errorf("Site() did not write %s: %v", file, err)
+				}
+			}
+
+			body, err := os.ReadFile(filepath.Join(dir, "bookings", "reference", "bookings-of-rooms", "index.html"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(string(body), tt.wantLink) {
+				t.Errorf("Site() page does not link %s", tt.wantLink)
+			}
+
+			if strings.Contains(string(body), "?v=") {
+				t.Error("Site() page links to a version by its query")
+			}
+		})
+	}
+}
+
+func TestPages(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.SpecDir, "../fixtures/")
+	cfg.Set(config.SpecFilename, []string{"bookings_api.json"})
+	cfg.Set(config.DefaultAssetsDir, "../assets")
+
+	router, _, err := handlers.BuildRouter(cfg, nil)
+	if err != nil {
+		t.Fatalf("BuildRouter() error = %v", err)
+	}
+
+	dir := t.TempDir()
+
+	if _, err := Pages(router, dir, "/docs", "/bookings/print"); err != nil {
+		t.Fatalf("Pages() error = %v", err)
+	}
+
+	for _, file := range []string{
+		"bookings/print/index.html",
+		"css/style.css",
+		"css/print.css",
+		"js/highlight.pack.js",
+	} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(file))); err != nil {
+			t.Errorf("Pages() did not write %s: %v", file, err)
+		}
+	}
+
+	for _, file := range []string{
+		"index.html",
+		"bookings/reference/index.html",
+	} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(file))); err == nil {
+			t.Errorf("Pages() wrote %s, which is not linked as an asset", file)
+		}
+	}
+
+	body, err := os.ReadFile(filepath.Join(dir, "bookings", "print", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(body), `href="/docs/css/print.css"`) {
+		t.Error("Pages() page does not link the print stylesheet within the site")
+	}
+}
+
+func TestSite_RenderErrors(t *testing.T) {
+	router := mux.NewRouter()
+	router.Path("/").Methods(http.MethodGet).HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<a href="/failed">failed</a><a href="/panicked">panicked</a>`))
+	})
+	router.Path("/failed").Methods(http.MethodGet).HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	router.Path("/panicked").Methods(http.MethodGet).HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("rendering failed")
+	})
+	router.Path("/moved").Methods(http.MethodGet).Handler(http.RedirectHandler("/", http.StatusFound))
+
+	dir := t.TempDir()
+
+	pages, err := Site(router, dir, "/")
+
+	var renderErrs RenderErrors
+	if !errors.As(err, &renderErrs) || len(renderErrs) != 2 {
+		t.Fatalf("Site() error = %v, want 2 RenderErrors", err)
+	}
+
+	if pages != 2 {
+		t.Errorf("Site() pages = %d, want 2", pages)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "index.html")); err != nil {
+		t.Errorf("Site() did not write the rendered page: %v", err)
+	}
+
+	moved, err := os.ReadFile(filepath.Join(dir, "moved", "index.html"))
+	if err != nil || !strings.Contains(string(moved), `url=/"`) {
+		t.Errorf("Site() redirect = %q, %v, want a refresh to /", moved, err)
+	}
+}
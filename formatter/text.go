@@ -0,0 +1,41 @@
+package formatter
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// PlainText returns the text content of an HTML document or fragment, leaving out scripts and styles,
+// with runs of white space collapsed to a single space.
+func PlainText(doc []byte) string {
+	var b strings.Builder
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(doc))
+	skip := ""
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return strings.Join(strings.Fields(b.String()), " ")
+		case html.StartTagToken:
+			name, _ := tokenizer.TagName()
+			if tag := string(name); skip == "" && (tag == "script" || tag == "style") {
+				skip = tag
+			}
+
+			b.WriteByte(' ')
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == skip {
+				skip = ""
+			}
+
+			b.WriteByte(' ')
+		case html.TextToken:
+			if skip == "" {
+				b.Write(tokenizer.Text())
+			}
+		}
+	}
+}
@@ -0,0 +1,397 @@
+// Package api provides a read-only JSON API over the documentation model, for tools to query the
+// specifications, API groups, methods and resources documented.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	wraperrors "github.com/pkg/errors"
+
+	"github.com/kenjones-cisco/dapperdox/render"
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// Prefix is the path all routes of the API start with.
+const Prefix = "/api/v1"
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+
+	// versionParam is the query parameter selecting the version of the methods and resources.
+	versionParam = "v"
+	// latestVersion is the version of the resources given when none is selected.
+	latestVersion = "latest"
+)
+
+// page is a page of a list, giving the URL of the next page when there is one.
+type page struct {
+	Items  interface{} `json:"items"`
+	Total  int         `json:"total"`
+	Offset int         `json:"offset"`
+	Limit  int         `json:"limit"`
+	Next   string      `json:"next,omitempty"`
+}
+
+// apiError is the body of the response to a request that failed.
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// handler serves the API from the specifications documented by a Renderer.
+type handler struct {
+	suite *spec.Suite
+}
+
+// Register creates the routes of the API.
+func Register(r *mux.Router, rnd *render.Renderer) {
+	log().Info("Registering JSON API")
+
+	h := &handler{suite: rnd.Suite()}
+
+	routes := map[string]http.HandlerFunc{
+		"/specs":                                              h.specs,
+		"/specs/{specID}":                                     h.specification,
+		"/specs/{specID}/groups":                              h.specGroups,
+		"/specs/{specID}/groups/{groupID}":                    h.group,
+		"/specs/{specID}/groups/{groupID}/methods":            h.methods,
+		"/specs/{specID}/groups/{groupID}/methods/{methodID}": h.method,
+		"/specs/{specID}/resources":                           h.resources,
+		"/specs/{specID}/resources/{resourceID}":              h.resource,
+		"/groups":                                             h.groups,
+	}
+
+	for path, hf := range routes {
+		r.Path(Prefix + path).Methods(http.MethodGet).HandlerFunc(hf)
+	}
+}
+
+// specs lists the specifications, ordered by ID.
+func (h *handler) specs(w http.ResponseWriter, req *http.Request) {
+	specs := h.sortedSpecs()
+
+	list := make([]Specification, len(specs))
+	for i, s := range specs {
+		list[i] = newSpecification(s)
+	}
+
+	paginate(w, req, len(list), func(from, to int) interface{} { return list[from:to] })
+}
+
+func (h *handler) specification(w http.ResponseWriter, req *http.Request) {
+	s, ok := h.lookupSpec(w, req)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newSpecification(s))
+}
+
+// specGroups lists the API groups of a specification, in the order they are documented.
+func (h *handler) specGroups(w http.ResponseWriter, req *http.Request) {
+	s, ok := h.lookupSpec(w, req)
+	if !ok {
+		return
+	}
+
+	list := make([]Group, len(s.APIs))
+	for i, api := range s.APIs {
+		list[i] = newGroup(s.ID, api)
+	}
+
+	paginate(w, req, len(list), func(from, to int) interface{} { return list[from:to] })
+}
+
+// groups lists the API groups of every specification, those of each specification in turn.
+func (h *handler) groups(w http.ResponseWriter, req *http.Request) {
+	list := make([]Group, 0)
+
+	for _, s := range h.sortedSpecs() {
+		for _, api := range s.APIs {
+			list = append(list, newGroup(s.ID, api))
+		}
+	}
+
+	paginate(w, req, len(list), func(from, to int) interface{} { return list[from:to] })
+}
+
+// group gives an API group, listing the methods of the version selected.
+func (h *handler) group(w http.ResponseWriter, req *http.Request) {
+	s, api, ok := h.lookupGroup(w, req)
+	if !ok {
+		return
+	}
+
+	methods, version, ok := versionMethods(w, req, api)
+	if !ok {
+		return
+	}
+
+	g := newGroup(s.ID, *api)
+	g.Methods = make([]MethodRef, len(methods))
+
+	for i, m := range methods {
+		g.Methods[i] = newMethodRef(s.ID, api.ID, m)
+	}
+
+	log().Tracef("API group %s/%s version %s", s.ID, api.ID, version)
+
+	writeJSON(w, http.StatusOK, g)
+}
+
+// methods lists the methods of the version selected of an API group.
+func (h *handler) methods(w http.ResponseWriter, req *http.Request) {
+	s, api, ok := h.lookupGroup(w, req)
+	if !ok {
+		return
+	}
+
+	methods, version, ok := versionMethods(w, req, api)
+	if !ok {
+		return
+	}
+
+	list := make([]Method, len(methods))
+	for i, m := range methods {
+		list[i] = newMethod(s.ID, *api, m, version)
+	}
+
+	paginate(w, req, len(list), func(from, to int) interface{} { return list[from:to] })
+}
+
+// method gives the version selected of a method.
+func (h *handler) method(w http.ResponseWriter, req *http.Request) {
+	s, api, ok := h.lookupGroup(w, req)
+	if !ok {
+		return
+	}
+
+	methods, version, ok := versionMethods(w, req, api)
+	if !ok {
+		return
+	}
+
+	id := mux.Vars(req)["methodID"]
+
+	for _, m := range methods {
+		if m.ID == id {
+			writeJSON(w, http.StatusOK, newMethod(s.ID, *api, m, version))
+
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "method %q of API group %q not found in version %q", id, api.ID, version)
+}
+
+// resources lists the resources of the version selected of a specification, ordered by ID.
+func (h *handler) resources(w http.ResponseWriter, req *http.Request) {
+	s, ok := h.lookupSpec(w, req)
+	if !ok {
+		return
+	}
+
+	resources, version, ok := versionResources(w, req, s)
+	if !ok {
+		return
+	}
+
+	ids := make([]string, 0, len(resources))
+	for id := range resources {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	paginate(w, req, len(ids), func(from, to int) interface{} {
+		list := make([]Resource, 0, to-from)
+		for _, id := range ids[from:to] {
+			list = append(list, newResource(s.ID, resources[id], version))
+		}
+
+		return list
+	})
+}
+
+// resource gives the version selected of a resource.
+func (h *handler) resource(w http.ResponseWriter, req *http.Request) {
+	s, ok := h.lookupSpec(w, req)
+	if !ok {
+		return
+	}
+
+	resources, version, ok := versionResources(w, req, s)
+	if !ok {
+		return
+	}
+
+	id := mux.Vars(req)["resourceID"]
+
+	r, ok := resources[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "resource %q not found in version %q", id, version)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newResource(s.ID, r, version))
+}
+
+func (h *handler) sortedSpecs() []*spec.APISpecification {
+	specs := make([]*spec.APISpecification, 0, len(h.suite.Specs))
+	for _, s := range h.suite.Specs {
+		specs = append(specs, s)
+	}
+
+	sort.Slice(specs, func(i, j int) bool { return specs[i].ID < specs[j].ID })
+
+	return specs
+}
+
+// lookupSpec returns the specification of the request, responding with an error when there is none.
+func (h *handler) lookupSpec(w http.ResponseWriter, req *http.Request) (*spec.APISpecification, bool) {
+	id := mux.Vars(req)["specID"]
+
+	s, ok := h.suite.Specs[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "specification %q not found", id)
+	}
+
+	return s, ok
+}
+
+// lookupGroup returns the specification and API group of the request, responding with an error when
+// there are none.
+func (h *handler) lookupGroup(w http.ResponseWriter, req *http.Request) (*spec.APISpecification, *spec.APIGroup, bool) {
+	s, ok := h.lookupSpec(w, req)
+	if !ok {
+		return nil, nil, false
+	}
+
+	id := mux.Vars(req)["groupID"]
+
+	for i := range s.APIs {
+		if s.APIs[i].ID == id {
+			return s, &s.APIs[i], true
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "API group %q not found in specification %q", id, s.ID)
+
+	return nil, nil, false
+}
+
+// versionMethods returns the methods of an API group in the version selected by the request, its
+// current version by default, responding with an error when it has no such version.
+func versionMethods(w http.ResponseWriter, req *http.Request, api *spec.APIGroup) ([]spec.Method, string, bool) {
+	version := req.URL.Query().Get(versionParam)
+	if version == "" || version == api.CurrentVersion {
+		return api.Methods, api.CurrentVersion, true
+	}
+
+	methods, ok := api.Versions[version]
+	if !ok {
+		writeError(w, http.StatusNotFound, "version %q of API group %q not found", version, api.ID)
+	}
+
+	return methods, version, ok
+}
+
+// versionResources returns the resources of a specification in the version selected by the request, the
+// latest by default, responding with an error when it has no such version.
+func versionResources(w http.ResponseWriter, req *http.Request, s *spec.APISpecification) (map[string]*spec.Resource, string, bool) {
+	version := req.URL.Query().Get(versionParam)
+	if version == "" {
+		version = latestVersion
+	}
+
+	resources, ok := s.ResourceList[version]
+	if !ok && req.URL.Query().Get(versionParam) != "" {
+		writeError(w, http.StatusNotFound, "version %q of specification %q not found", version, s.ID)
+
+		return nil, version, false
+	}
+
+	return resources, version, true
+}
+
+// paginate responds with the page of a list of total items selected by the offset and limit query
+// parameters, which items returns given the bounds of the page.
+func paginate(w http.ResponseWriter, req *http.Request, total int, items func(from, to int) interface{}) {
+	offset, limit, err := pageParams(req.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+
+		return
+	}
+
+	// the offset is clamped first, so that the end of the page cannot overflow
+	from := offset
+	if from > total {
+		from = total
+	}
+
+	to := total
+	if limit < total-from {
+		to = from + limit
+	}
+
+	p := page{Items: items(from, to), Total: total, Offset: offset, Limit: limit}
+
+	if to < total {
+		next := req.URL.Query()
+		next.Set("offset", strconv.Itoa(to))
+		next.Set("limit", strconv.Itoa(limit))
+
+		p.Next = (&url.URL{Path: req.URL.Path, RawQuery: next.Encode()}).String()
+	}
+
+	writeJSON(w, http.StatusOK, p)
+}
+
+// pageParams returns the offset and limit query parameters, defaulting to the first page.
+func pageParams(values url.Values) (int, int, error) {
+	offset, limit := 0, defaultLimit
+
+	if v := values.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, 0, wraperrors.Errorf("invalid offset %q", v)
+		}
+
+		offset = n
+	}
+
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxLimit {
+			return 0, 0, wraperrors.Errorf("invalid limit %q, it must be between 1 and %d", v, maxLimit)
+		}
+
+		limit = n
+	}
+
+	return offset, limit, nil
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, apiError{Error: fmt.Sprintf(format, args...)})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(v); err != nil {
+		log().Errorf("Unable to write response: %s", err)
+	}
+}
@@ -0,0 +1,566 @@
+package api
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// The types below are the JSON representations of the documentation model. Their field names are part of
+// the API, so are kept stable whatever the model structs become. The model refers back from resources to
+// methods, and from methods to their API group, which are given as references instead of being embedded.
+
+// Specification is the JSON representation of a spec.APISpecification.
+type Specification struct {
+	ID                  string                    `json:"id"`
+	Title               string                    `json:"title"`
+	Description         string                    `json:"description"` // HTML
+	SpecURL             string                    `json:"specUrl"`     // Location the specification was loaded from
+	GroupBy             string                    `json:"groupBy"`
+	Servers             []Server                  `json:"servers"`
+	SecurityDefinitions map[string]SecurityScheme `json:"securityDefinitions"`
+	Versions            []string                  `json:"versions"` // Versions of the resources documented
+	Errors              []LoadError               `json:"errors"`
+}
+
+// Server is a server of the API declared by a specification.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+// SecurityScheme is the JSON representation of a spec.SecurityScheme.
+type SecurityScheme struct {
+	Type             string            `json:"type"` // basic, bearer, apiKey or oauth2
+	Description      string            `json:"description"`
+	ParamName        string            `json:"paramName,omitempty"`
+	ParamLocation    string            `json:"paramLocation,omitempty"`
+	OAuth2Flow       string            `json:"oauth2Flow,omitempty"`
+	AuthorizationURL string            `json:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes,omitempty"`
+}
+
+// LoadError is a problem found loading a specification.
+type LoadError struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+// Group is the JSON representation of a spec.APIGroup, listing its methods.
+type Group struct {
+	ID             string      `json:"id"`
+	Name           string      `json:"name"`
+	SpecID         string      `json:"specId"`
+	URL            string      `json:"url"` // URL of the API, which the paths of the methods are relative to
+	CurrentVersion string      `json:"currentVersion"`
+	Versions       []string    `json:"versions"`
+	Consumes       []string    `json:"consumes"`
+	Produces       []string    `json:"produces"`
+	Methods        []MethodRef `json:"methods"` // Methods of the current version
+}
+
+// MethodRef refers to a method of an API group.
+type MethodRef struct {
+	ID      string `json:"id"`
+	SpecID  string `json:"specId"`
+	GroupID string `json:"groupId"`
+	Name    string `json:"name"`
+	Method  string `json:"method"` // Upper case HTTP method
+	Path    string `json:"path"`
+}
+
+// Method is the JSON representation of a version of a spec.Method.
+type Method struct {
+	MethodRef
+	Version       string        `json:"version"`
+	OperationName string        `json:"operationName"`
+	Description   string        `json:"description"` // HTML
+	Consumes      []string      `json:"consumes"`
+	Produces      []string      `json:"produces"`
+	Parameters    []Parameter   `json:"parameters"`
+	Responses     []Response    `json:"responses"`
+	Security      []Security    `json:"security"`
+	Resources     []ResourceRef `json:"resources"`
+	CodeSamples   []CodeSample  `json:"codeSamples"` // Samples declared by the operation
+}
+
+// Parameter is the JSON representation of a spec.Parameter.
+type Parameter struct {
+	Name             string       `json:"name"`
+	In               string       `json:"in"` // path, query, header, cookie, formData or body
+	Description      string       `json:"description"`
+	Type             []string     `json:"type"`
+	Enum             []string     `json:"enum"`
+	Required         bool         `json:"required"`
+	CollectionFormat string       `json:"collectionFormat,omitempty"`
+	IsArray          bool         `json:"isArray"`
+	Resource         *ResourceRef `json:"resource,omitempty"` // Resource sent by a body parameter
+}
+
+// Response is the JSON representation of a spec.Response, for a status code, range of codes or by default.
+type Response struct {
+	Status       string       `json:"status"` // Status code, range such as 4XX, or default
+	Description  string       `json:"description"`
+	Resource     *ResourceRef `json:"resource,omitempty"`
+	IsArray      bool         `json:"isArray"`
+	Headers      []Header     `json:"headers"`
+	ContentTypes []string     `json:"contentTypes"`
+}
+
+// Header is the JSON representation of a spec.Header of a response.
+type Header struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Type        []string `json:"type"`
+	Required    bool     `json:"required"`
+	Enum        []string `json:"enum"`
+	Default     string   `json:"default,omitempty"`
+}
+
+// Security is a security scheme required by a method, with the scopes it requires.
+type Security struct {
+	Type   string   `json:"type"`
+	Scopes []string `json:"scopes"`
+}
+
+// CodeSample is a request snippet declared by an operation.
+type CodeSample struct {
+	Lang   string `json:"lang"`
+	Label  string `json:"label"`
+	Source string `json:"source"`
+}
+
+// ResourceRef refers to a resource.
+type ResourceRef struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// Resource is the JSON representation of a version of a spec.Resource. Its properties are nested, except
+// for those referring back to a resource they are part of, which are given as references.
+type Resource struct {
+	ResourceRef
+	Version       string          `json:"version,omitempty"`
+	Description   string          `json:"description"` // HTML
+	Type          []string        `json:"type"`
+	Example       json.RawMessage `json:"example,omitempty"`
+	Required      bool            `json:"required"`
+	ReadOnly      bool            `json:"readOnly"`
+	Enum          []string        `json:"enum"`
+	Properties    []Property      `json:"properties"`
+	Variants      []Resource      `json:"variants,omitempty"`
+	Discriminator *Discriminator  `json:"discriminator,omitempty"`
+	Examples      []Example       `json:"examples"`
+	Methods       []MethodRef     `json:"methods,omitempty"` // Methods using the resource
+}
+
+// Property is a property of a resource.
+type Property struct {
+	Name string `json:"name"`
+	Resource
+	Recursive *ResourceRef `json:"recursive,omitempty"` // Resource the property refers back to
+}
+
+// Discriminator selects the variant of a polymorphic resource.
+type Discriminator struct {
+	PropertyName string            `json:"propertyName"`
+	Mapping      map[string]string `json:"mapping"`
+}
+
+// Example is a named example of a resource.
+type Example struct {
+	Name        string          `json:"name"`
+	Summary     string          `json:"summary"`
+	Description string          `json:"description"`
+	Value       json.RawMessage `json:"value"`
+}
+
+func newSpecification(s *spec.APISpecification) Specification {
+	out := Specification{
+		ID:                  s.ID,
+		Title:               s.APIInfo.Title,
+		Description:         s.APIInfo.Description,
+		SpecURL:             s.URL,
+		GroupBy:             s.GroupBy,
+		Servers:             []Server{},
+		SecurityDefinitions: make(map[string]SecurityScheme, len(s.SecurityDefinitions)),
+		Versions:            specificationVersions(s),
+		Errors:              []LoadError{},
+	}
+
+	for _, server := range s.Servers {
+		out.Servers = append(out.Servers, Server{URL: server.URL, Description: server.Description})
+	}
+
+	for name, scheme := range s.SecurityDefinitions {
+		out.SecurityDefinitions[name] = SecurityScheme{
+			Type:             scheme.Type,
+			Description:      scheme.Description,
+			ParamName:        scheme.ParamName,
+			ParamLocation:    scheme.ParamLocation,
+			OAuth2Flow:       scheme.OAuth2Flow,
+			AuthorizationURL: scheme.AuthorizationURL,
+			TokenURL:         scheme.TokenURL,
+			Scopes:           scheme.Scopes,
+		}
+	}
+
+	for _, err := range s.Errors {
+		out.Errors = append(out.Errors, LoadError{Pointer: err.Pointer, Message: err.Message})
+	}
+
+	return out
+}
+
+func newGroup(specID string, api spec.APIGroup) Group {
+	g := Group{
+		ID:             api.ID,
+		Name:           api.Name,
+		SpecID:         specID,
+		CurrentVersion: api.CurrentVersion,
+		Versions:       groupVersions(api),
+		Consumes:       strs(api.Consumes),
+		Produces:       strs(api.Produces),
+		Methods:        make([]MethodRef, 0, len(api.Methods)),
+	}
+
+	if api.URL != nil {
+		g.URL = api.URL.String()
+	}
+
+	for _, m := range api.Methods {
+		g.Methods = append(g.Methods, newMethodRef(specID, api.ID, m))
+	}
+
+	return g
+}
+
+func newMethodRef(specID, groupID string, m spec.Method) MethodRef {
+	return MethodRef{
+		ID:      m.ID,
+		SpecID:  specID,
+		GroupID: groupID,
+		Name:    m.Name,
+		Method:  strings.ToUpper(m.Method),
+		Path:    m.Path,
+	}
+}
+
+func newMethod(specID string, api spec.APIGroup, m spec.Method, version string) Method {
+	method := Method{
+		MethodRef:     newMethodRef(specID, api.ID, m),
+		Version:       version,
+		OperationName: m.OperationName,
+		Description:   m.Description,
+		Consumes:      strs(m.Consumes),
+		Produces:      strs(m.Produces),
+		Parameters:    []Parameter{},
+		Responses:     []Response{},
+		Security:      []Security{},
+		Resources:     []ResourceRef{},
+		CodeSamples:   []CodeSample{},
+	}
+
+	for _, params := range [][]spec.Parameter{m.PathParams, m.QueryParams, m.HeaderParams, m.CookieParams, m.FormParams} {
+		for _, p := range params {
+			method.Parameters = append(method.Parameters, newParameter(p))
+		}
+	}
+
+	if m.BodyParam != nil {
+		method.Parameters = append(method.Parameters, newParameter(*m.BodyParam))
+	}
+
+	codes := make([]int, 0, len(m.Responses))
+	for code := range m.Responses {
+		codes = append(codes, code)
+	}
+
+	sort.Ints(codes)
+
+	for _, code := range codes {
+		method.Responses = append(method.Responses, newResponse(strconv.Itoa(code), m.Responses[code]))
+	}
+
+	for _, r := range sortedRanges(m.ResponseRanges) {
+		method.Responses = append(method.Responses, newResponse(r, m.ResponseRanges[r]))
+	}
+
+	if m.DefaultResponse != nil {
+		method.Responses = append(method.Responses, newResponse("default", *m.DefaultResponse))
+	}
+
+	types := make([]string, 0, len(m.Security))
+	for t := range m.Security {
+		types = append(types, t)
+	}
+
+	sort.Strings(types)
+
+	for _, t := range types {
+		method.Security = append(method.Security, Security{Type: t, Scopes: sortedScopes(m.Security[t].Scopes)})
+	}
+
+	for _, r := range m.Resources {
+		if r != nil {
+			method.Resources = append(method.Resources, ResourceRef{ID: r.ID, Title: r.Title})
+		}
+	}
+
+	for _, cs := range m.CodeSamples {
+		method.CodeSamples = append(method.CodeSamples, CodeSample{Lang: cs.Lang, Label: cs.Label, Source: cs.Source})
+	}
+
+	return method
+}
+
+func newParameter(p spec.Parameter) Parameter {
+	param := Parameter{
+		Name:             p.Name,
+		In:               p.In,
+		Description:      p.Description,
+		Type:             strs(p.Type),
+		Enum:             strs(p.Enum),
+		Required:         p.Required,
+		CollectionFormat: p.CollectionFormat,
+		IsArray:          p.IsArray,
+	}
+
+	if p.Resource != nil {
+		param.Resource = &ResourceRef{ID: p.Resource.ID, Title: p.Resource.Title}
+	}
+
+	return param
+}
+
+func newResponse(status string, r spec.Response) Response {
+	rsp := Response{
+		Status:       status,
+		Description:  r.Description,
+		IsArray:      r.IsArray,
+		Headers:      make([]Header, 0, len(r.Headers)),
+		ContentTypes: strs(r.ContentTypes),
+	}
+
+	if r.Resource != nil {
+		rsp.Resource = &ResourceRef{ID: r.Resource.ID, Title: r.Resource.Title}
+	}
+
+	for _, h := range r.Headers {
+		rsp.Headers = append(rsp.Headers, Header{
+			Name:        h.Name,
+			Description: h.Description,
+			Type:        strs(h.Type),
+			Required:    h.Required,
+			Enum:        strs(h.Enum),
+			Default:     h.Default,
+		})
+	}
+
+	return rsp
+}
+
+// newResource returns a version of a resource, with the methods of specID using it.
+func newResource(specID string, r *spec.Resource, version string) Resource {
+	res := resource(r, map[*spec.Resource]bool{})
+	res.Version = version
+
+	for _, m := range r.Methods {
+		if m == nil {
+			continue
+		}
+
+		groupID := ""
+		if m.APIGroup != nil {
+			groupID = m.APIGroup.ID
+		}
+
+		res.Methods = append(res.Methods, newMethodRef(specID, groupID, *m))
+	}
+
+	sort.Slice(res.Methods, func(i, j int) bool {
+		a, b := res.Methods[i], res.Methods[j]
+		if a.GroupID != b.GroupID {
+			return a.GroupID < b.GroupID
+		}
+
+		return a.ID < b.ID
+	})
+
+	if res.Methods == nil {
+		res.Methods = []MethodRef{}
+	}
+
+	return res
+}
+
+// resource returns a resource with its properties and variants, the resources it is part of being
+// ancestors, which properties refer back to instead of nesting.
+func resource(r *spec.Resource, ancestors map[*spec.Resource]bool) Resource {
+	ancestors[r] = true
+	defer delete(ancestors, r)
+
+	res := Resource{
+		ResourceRef: ResourceRef{ID: r.ID, Title: r.Title},
+		Description: r.Description,
+		Type:        strs(r.Type),
+		Example:     rawJSON(r.Schema),
+		Required:    r.Required,
+		ReadOnly:    r.ReadOnly,
+		Enum:        strs(r.Enum),
+		Properties:  make([]Property, 0, len(r.Properties)),
+		Examples:    make([]Example, 0, len(r.Examples)),
+	}
+
+	if res.Example == nil {
+		res.Example = rawJSON(r.Example)
+	}
+
+	for _, name := range sortedProperties(r.Properties) {
+		p := r.Properties[name]
+
+		switch {
+		case p == nil:
+			continue
+		case p.Recursive != nil:
+			res.Properties = append(res.Properties, Property{Name: name, Resource: leaf(p), Recursive: &ResourceRef{ID: p.Recursive.ID, Title: p.Recursive.Title}})
+		case ancestors[p]:
+			res.Properties = append(res.Properties, Property{Name: name, Resource: leaf(p), Recursive: &ResourceRef{ID: p.ID, Title: p.Title}})
+		default:
+			res.Properties = append(res.Properties, Property{Name: name, Resource: resource(p, ancestors)})
+		}
+	}
+
+	for _, v := range r.Variants {
+		if v != nil && !ancestors[v] {
+			res.Variants = append(res.Variants, resource(v, ancestors))
+		}
+	}
+
+	if r.Discriminator != nil {
+		res.Discriminator = &Discriminator{PropertyName: r.Discriminator.PropertyName, Mapping: r.Discriminator.Mapping}
+	}
+
+	for _, e := range r.Examples {
+		res.Examples = append(res.Examples, Example{Name: e.Name, Summary: e.Summary, Description: e.Description, Value: rawJSON(e.Value)})
+	}
+
+	return res
+}
+
+// leaf returns a resource without its properties or variants.
+func leaf(r *spec.Resource) Resource {
+	return Resource{
+		ResourceRef: ResourceRef{ID: r.ID, Title: r.Title},
+		Description: r.Description,
+		Type:        strs(r.Type),
+		Required:    r.Required,
+		ReadOnly:    r.ReadOnly,
+		Enum:        strs(r.Enum),
+		Properties:  []Property{},
+		Examples:    []Example{},
+	}
+}
+
+// specificationVersions returns the versions documented by a specification, in order.
+func specificationVersions(s *spec.APISpecification) []string {
+	versions := make(map[string]bool)
+
+	for v := range s.ResourceList {
+		versions[v] = true
+	}
+
+	for _, api := range s.APIs {
+		for _, v := range groupVersions(api) {
+			versions[v] = true
+		}
+	}
+
+	return sortedVersions(versions)
+}
+
+// groupVersions returns the versions of the methods of an API group, in order.
+func groupVersions(api spec.APIGroup) []string {
+	versions := map[string]bool{}
+	if api.CurrentVersion != "" {
+		versions[api.CurrentVersion] = true
+	}
+
+	for v := range api.Versions {
+		versions[v] = true
+	}
+
+	return sortedVersions(versions)
+}
+
+// rawJSON returns a JSON document as is, or as a string if it is not valid JSON.
+func rawJSON(s string) json.RawMessage {
+	if s == "" {
+		return nil
+	}
+
+	if json.Valid([]byte(s)) {
+		return json.RawMessage(s)
+	}
+
+	b, _ := json.Marshal(s)
+
+	return b
+}
+
+// strs returns list, empty rather than nil for it to be serialized as an array.
+func strs(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+
+	return list
+}
+
+func sortedRanges(ranges map[string]spec.Response) []string {
+	keys := make([]string, 0, len(ranges))
+	for k := range ranges {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedScopes(scopes map[string]string) []string {
+	keys := make([]string, 0, len(scopes))
+	for k := range scopes {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedProperties(properties map[string]*spec.Resource) []string {
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedVersions(versions map[string]bool) []string {
+	keys := make([]string, 0, len(versions))
+	for k := range versions {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
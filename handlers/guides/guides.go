@@ -33,24 +33,61 @@ import (
 	"github.com/kenjones-cisco/dapperdox/spec"
 )
 
-const maxNavLevels = 2
+const (
+	maxNavLevels  = 2
+	templatesBase = "assets/templates" // base of the compiled templates, guides included
+)
 
 // Register routes for guide pages.
-func Register(r *mux.Router) {
+func Register(r *mux.Router, rnd *render.Renderer) {
 	log().Info("Registering guides")
 
 	// specification specific guides
-	for _, specification := range spec.APISuite {
+	for _, specification := range rnd.Suite().Specs {
 		log().Debugf("- Specification guides for %q", specification.APIInfo.Title)
-		register(r, "assets/templates", specification)
+		register(r, rnd, specification)
 	}
 
 	// Top level guides
 	log().Debug("- Root guides")
-	register(r, "assets/templates", nil)
+	register(r, rnd, nil)
+}
+
+// Guide is a guide page, rendered from a compiled asset.
+type Guide struct {
+	Route string // Route serving the guide
+	Asset string // Asset compiled from the guide
+	Title string // Title given by the metadata of the guide, or its file name
+}
+
+// List returns the guides of a specification, or the top level guides when specification is nil.
+func List(assets *asset.Store, specification *spec.APISpecification) []Guide {
+	pathBase, routeBase := guideBases(specification)
+
+	var list []Guide
+
+	for _, path := range assets.Names() {
+		if !strings.HasPrefix(path, pathBase) { // Only keep assets we want
+			continue
+		}
+
+		switch filepath.Ext(path) {
+		case ".tmpl", ".md":
+			list = append(list, Guide{
+				Route: routeBase + stripBasepathAndExtension(path, pathBase),
+				Asset: path,
+				Title: guideTitle(assets, path),
+			})
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Route < list[j].Route })
+
+	return list
 }
 
-func register(r *mux.Router, base string, specification *spec.APISpecification) {
+// guideBases returns the base of the assets of the guides of a specification, and that of their routes.
+func guideBases(specification *spec.APISpecification) (string, string) {
 	rootNode := "/guides"
 	routeBase := "/guides"
 
@@ -59,7 +96,26 @@ func register(r *mux.Router, base string, specification *spec.APISpecification)
 		routeBase = "/" + specification.ID + routeBase
 	}
 
-	pathBase := base + rootNode
+	return templatesBase + rootNode, routeBase
+}
+
+// guideTitle returns the Title metadata of a guide, otherwise the last part of its Navigation metadata
+// or its file name.
+func guideTitle(assets *asset.Store, path string) string {
+	if title := assets.MetaData(path, "Title"); title != "" {
+		return title
+	}
+
+	name := assets.MetaData(path, "Navigation")
+	if name == "" {
+		name = strings.TrimSuffix(path, filepath.Ext(path))
+	}
+
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+func register(r *mux.Router, rnd *render.Renderer, specification *spec.APISpecification) {
+	pathBase, routeBase := guideBases(specification)
 
 	guidesNavigation := &navigation.Node{}
 
@@ -68,36 +124,27 @@ func register(r *mux.Router, base string, specification *spec.APISpecification)
 
 	log().Tracef("  - Walk compiled asset tree %s", pathBase)
 
-	for _, path := range asset.Names() {
-		if !strings.HasPrefix(path, pathBase) { // Only keep assets we want
-			continue
-		}
+	for _, guide := range List(rnd.Assets(), specification) {
+		path := guide.Asset
+		route := guide.Route
 
-		ext := filepath.Ext(path)
-
-		switch ext {
-		case ".tmpl", ".md":
-			log().Debugf("    - File %s", path)
+		log().Debugf("    - File %s", path)
 
-			// Convert path/filename to route
-			route := routeBase + stripBasepathAndExtension(path, pathBase)
-			absresource := stripBasepathAndExtension(path, base)
-			resource := strings.TrimPrefix(absresource, "/")
+		resource := strings.TrimPrefix(stripBasepathAndExtension(path, templatesBase), "/")
 
-			log().Tracef("      = URL  %s", route)
+		log().Tracef("      = URL  %s", route)
 
-			buildNavigation(guidesNavigation, path, pathBase, route, ext)
+		buildNavigation(rnd.Assets(), guidesNavigation, path, pathBase, route, filepath.Ext(path))
 
-			r.Path(route).Methods(http.MethodGet).HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-				sid := "TOP LEVEL"
-				if specification != nil {
-					sid = specification.ID
-				}
+		r.Path(route).Methods(http.MethodGet).HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			sid := "TOP LEVEL"
+			if specification != nil {
+				sid = specification.ID
+			}
 
-				log().Tracef("Fetching guide from %q for spec ID %s", resource, sid)
-				render.HTML(w, http.StatusOK, resource, render.DefaultVars(req, specification, render.Vars{"Guide": resource}))
-			})
-		}
+			log().Tracef("Fetching guide from %q for spec ID %s", resource, sid)
+			rnd.HTML(w, http.StatusOK, resource, rnd.DefaultVars(req, specification, render.Vars{"Guide": resource}))
+		})
 	}
 
 	sortNavigation(guidesNavigation)
@@ -110,7 +157,7 @@ func register(r *mux.Router, base string, specification *spec.APISpecification)
 	})
 
 	// Register the guides navigation with the renderer
-	render.SetGuidesNavigation(specification, guidesNavigation.Children)
+	rnd.SetGuidesNavigation(specification, guidesNavigation.Children)
 }
 
 func findFirstGuideURI(tree *navigation.Node) string {
@@ -151,12 +198,12 @@ func stripBasepathAndExtension(name, basepath string) string {
 	return strings.TrimSuffix(strings.TrimPrefix(name, basepath), filepath.Ext(name))
 }
 
-func buildNavigation(nav *navigation.Node, path, pathBase, route, ext string) {
+func buildNavigation(assets *asset.Store, nav *navigation.Node, path, pathBase, route, ext string) {
 	log().Tracef("      - Look for metadata asset %s", path)
 
 	// See if guide has been marked up with navigation metadata...
-	hierarchy := asset.MetaData(path, "Navigation")
-	sortOrder := asset.MetaData(path, "SortOrder")
+	hierarchy := assets.MetaData(path, "Navigation")
+	sortOrder := assets.MetaData(path, "SortOrder")
 
 	if len(hierarchy) > 0 {
 		log().Tracef("      * Got navigation metadata %s for file %s", hierarchy, path)
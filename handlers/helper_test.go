@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"sync"
 	"testing"
 
 	"github.com/go-openapi/swag"
@@ -12,6 +13,7 @@ import (
 type fakeDiscover struct {
 	t *testing.T
 
+	mu        sync.Mutex // the updater fetches specs from its own goroutine
 	testName  string
 	sPaths    map[string]string
 	wantSpecs map[string][]byte
@@ -26,6 +28,9 @@ func (fd *fakeDiscover) Run() {}
 
 // Specs inspects testing conditions for determining auto-discovery updater properly fetches specs.
 func (fd *fakeDiscover) Specs() map[string][]byte {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+
 	specs := make(map[string][]byte)
 
 	for k, v := range fd.sPaths {
@@ -44,6 +49,14 @@ func (fd *fakeDiscover) Specs() map[string][]byte {
 
 func (fd *fakeDiscover) RegisterOnChangeFunc(f func()) {}
 
+// set changes the testing conditions.
+func (fd *fakeDiscover) set(testName string, sPaths map[string]string, wantSpecs map[string][]byte, wantErr bool) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+
+	fd.testName, fd.sPaths, fd.wantSpecs, fd.wantErr = testName, sPaths, wantSpecs, wantErr
+}
+
 // specToByteSlice opens a test spec at a provided file-path and converts into a byte slice.
 func specToByteSlice(specLoc string) []byte {
 	raw, err := swag.LoadFromFileOrHTTP(specLoc)
@@ -23,7 +23,6 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
-	"github.com/spf13/viper"
 
 	"github.com/kenjones-cisco/dapperdox/config"
 	"github.com/kenjones-cisco/dapperdox/render"
@@ -31,16 +30,16 @@ import (
 )
 
 // Register creates routes for each home handler.
-func Register(r *mux.Router) {
+func Register(r *mux.Router, rnd *render.Renderer) {
 	log().Debug("registering handlers for home page")
 
 	// Homepages for each loaded specification
 	var specification *spec.APISpecification // Ends up being populated with the last spec processed
 
-	for _, specification = range spec.APISuite {
+	for _, specification = range rnd.Suite().Specs {
 		log().Tracef("Build homepage route for specification %q", specification.ID)
 
-		r.Path("/" + specification.ID + "/reference").Methods(http.MethodGet).HandlerFunc(specificationSummaryHandler(specification))
+		r.Path("/" + specification.ID + "/reference").Methods(http.MethodGet).HandlerFunc(specificationSummaryHandler(rnd, specification))
 
 		// If missingh trailing slash, redirect to add it
 		r.Path("/" + specification.ID).Methods(http.MethodGet).HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
@@ -48,25 +47,27 @@ func Register(r *mux.Router) {
 		})
 	}
 
-	if len(spec.APISuite) == 1 && !viper.GetBool(config.ForceSpecList) {
+	if len(rnd.Suite().Specs) == 1 && !rnd.Config().GetBool(config.ForceSpecList) {
 		// If there is only one specification loaded, then hotwire '/' to redirect to the
 		// specification summary page unless DapperDox is configured to show the specification list page.
 		r.Path("/").Methods(http.MethodGet).HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 			http.Redirect(w, req, "/"+specification.ID+"/reference", http.StatusFound)
 		})
 	} else {
-		r.Path("/").Methods(http.MethodGet).HandlerFunc(specificationListHandler)
+		r.Path("/").Methods(http.MethodGet).HandlerFunc(specificationListHandler(rnd))
 	}
 }
 
-func specificationListHandler(w http.ResponseWriter, req *http.Request) {
-	log().Trace("Render HTML for top level index page")
+func specificationListHandler(rnd *render.Renderer) func(w http.ResponseWriter, req *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		log().Trace("Render HTML for top level index page")
 
-	render.HTML(w, http.StatusOK, "specification_list",
-		render.DefaultVars(req, nil, render.Vars{"Title": "Specifications list", "SpecificationList": true}))
+		rnd.HTML(w, http.StatusOK, "specification_list",
+			rnd.DefaultVars(req, nil, render.Vars{"Title": rnd.Message("title.specification_list"), "SpecificationList": true}))
+	}
 }
 
-func specificationSummaryHandler(s *spec.APISpecification) func(w http.ResponseWriter, req *http.Request) {
+func specificationSummaryHandler(rnd *render.Renderer, s *spec.APISpecification) func(w http.ResponseWriter, req *http.Request) {
 	// The default "theme" level reference index page.
 	tmpl := "specification_summary"
 
@@ -74,12 +75,20 @@ func specificationSummaryHandler(s *spec.APISpecification) func(w http.ResponseW
 
 	log().Tracef("+ Test for template %q", customTmpl)
 
-	if render.TemplateLookup(customTmpl) != nil {
+	if rnd.TemplateLookup(customTmpl) != nil {
 		tmpl = customTmpl
 	}
 
 	return func(w http.ResponseWriter, req *http.Request) {
-		render.HTML(w, http.StatusOK, tmpl,
-			render.DefaultVars(req, s, render.Vars{"Title": "Specification summary", "SpecificationSummary": true}))
+		// A specification that failed to load lists its problems in place of its summary.
+		if len(s.Errors) > 0 {
+			rnd.HTML(w, http.StatusInternalServerError, "specification_errors",
+				rnd.DefaultVars(req, s, render.Vars{"Title": rnd.Message("title.specification_errors"), "SpecificationSummary": true}))
+
+			return
+		}
+
+		rnd.HTML(w, http.StatusOK, tmpl,
+			rnd.DefaultVars(req, s, render.Vars{"Title": rnd.Message("title.specification_summary"), "SpecificationSummary": true}))
 	}
 }
@@ -42,10 +42,10 @@ func (r *responseCapture) WriteHeader(status int) {
 }
 
 // Register handles registering paths to proxy.
-func Register(r *mux.Router) {
+func Register(r *mux.Router, cfg *viper.Viper) {
 	log().Debug("Registering proxied paths:")
 
-	for k, v := range viper.GetStringMapString(config.ProxyPath) {
+	for k, v := range cfg.GetStringMapString(config.ProxyPath) {
 		register(r, k, v)
 	}
 
@@ -0,0 +1,34 @@
+package reference
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/kenjones-cisco/dapperdox/postman"
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// postmanHandler is a http.Handler serving the Postman collection of the methods of a specification.
+func postmanHandler(specification *spec.APISpecification) func(w http.ResponseWriter, req *http.Request) {
+	var collection bytes.Buffer
+
+	enc := json.NewEncoder(&collection)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(postman.New(specification))
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		if err != nil {
+			log().Errorf("Unable to build the Postman collection of %s: %s", specification.ID, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(collection.Bytes())
+	}
+}
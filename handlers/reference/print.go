@@ -0,0 +1,94 @@
+package reference
+
+import (
+	"net/http"
+	"sort"
+
+	unrolled "github.com/unrolled/render"
+
+	"github.com/kenjones-cisco/dapperdox/render"
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+const latestVersion = "latest"
+
+// printVersion holds the API groups and resources of a version of a specification, printed together.
+type printVersion struct {
+	Version   string
+	APIs      []printAPI
+	Resources []*spec.Resource
+}
+
+// printAPI holds the methods of an API group in a version.
+type printAPI struct {
+	API     spec.APIGroup
+	Methods []spec.Method
+}
+
+// printHandler is a http.Handler rendering the whole specification on a single page, to be printed.
+func (reg *registry) printHandler(specification *spec.APISpecification) func(w http.ResponseWriter, req *http.Request) {
+	versions := printVersions(specification)
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		log().Tracef("-- template: print  Specification %s", specification.ID)
+
+		reg.rnd.HTML(w, http.StatusOK, "print",
+			reg.rnd.DefaultVars(req, specification, render.Vars{
+				"Title":         reg.rnd.Message("title.reference"),
+				"PrintVersions": versions,
+			}),
+			unrolled.HTMLOptions{Layout: "print_layout"})
+	}
+}
+
+// printVersions returns each version of the API groups and resources of a specification, the latest first.
+func printVersions(specification *spec.APISpecification) []*printVersion {
+	byVersion := make(map[string]*printVersion)
+
+	get := func(version string) *printVersion {
+		if _, ok := byVersion[version]; !ok {
+			byVersion[version] = &printVersion{Version: version}
+		}
+
+		return byVersion[version]
+	}
+
+	for _, api := range specification.APIs {
+		if len(api.Methods) > 0 {
+			v := get(api.CurrentVersion)
+			v.APIs = append(v.APIs, printAPI{API: api, Methods: api.Methods})
+		}
+
+		for version, methods := range api.Versions {
+			if version != api.CurrentVersion && len(methods) > 0 {
+				v := get(version)
+				v.APIs = append(v.APIs, printAPI{API: api, Methods: methods})
+			}
+		}
+	}
+
+	for version, resources := range specification.ResourceList {
+		v := get(version)
+
+		for _, resource := range resources {
+			v.Resources = append(v.Resources, resource)
+		}
+
+		sort.Slice(v.Resources, func(i, j int) bool { return v.Resources[i].ID < v.Resources[j].ID })
+	}
+
+	versions := make([]*printVersion, 0, len(byVersion))
+	for _, v := range byVersion {
+		versions = append(versions, v)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		if versions[i].Version == latestVersion || versions[j].Version == latestVersion {
+			return versions[i].Version == latestVersion
+		}
+
+		return versions[i].Version > versions[j].Version
+	})
+
+	return versions
+}
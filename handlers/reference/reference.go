@@ -24,6 +24,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/kenjones-cisco/dapperdox/codesample"
 	"github.com/kenjones-cisco/dapperdox/render"
 	"github.com/kenjones-cisco/dapperdox/spec"
 )
@@ -33,27 +34,35 @@ type (
 	versionedResource map[string]*spec.Resource // key is version
 )
 
-var (
+// registry holds the methods and resources of the specifications documented by a Renderer, keyed by path.
+type registry struct {
+	rnd                 *render.Renderer
 	pathVersionMethod   map[string]versionedMethod   // Key is path
 	pathVersionResource map[string]versionedResource // Key is path
-)
+}
 
 // Register creates routes for specification resource.
-func Register(r *mux.Router) {
+func Register(r *mux.Router, rnd *render.Renderer) {
 	log().Info("Registering reference documentation")
 
-	pathVersionMethod = make(map[string]versionedMethod)
-	pathVersionResource = make(map[string]versionedResource)
+	reg := &registry{
+		rnd:                 rnd,
+		pathVersionMethod:   make(map[string]versionedMethod),
+		pathVersionResource: make(map[string]versionedResource),
+	}
 
-	// Loop for all APISpecification's in the APISuite
-	for _, specification := range spec.APISuite {
+	// Loop for all APISpecification's in the Suite
+	for _, specification := range rnd.Suite().Specs {
 		specID := "/" + specification.ID
 
 		log().Debugf("Registering reference for OpenAPI specification %q", specification.APIInfo.Title)
 
+		r.Path(specID + "/print").Methods(http.MethodGet).HandlerFunc(reg.printHandler(specification))
+		r.Path(specID + "/postman.json").Methods(http.MethodGet).HandlerFunc(postmanHandler(specification))
+
 		for _, api := range specification.APIs {
 			log().Debugf("  - Scanning API [%s] %s", api.ID, api.Name)
-			r.Path(specID + "/reference/" + api.ID).Methods(http.MethodGet).HandlerFunc(apiHandler(specification, api))
+			r.Path(specID + "/reference/" + api.ID).Methods(http.MethodGet).HandlerFunc(reg.apiHandler(specification, api))
 
 			version := api.CurrentVersion
 
@@ -63,13 +72,13 @@ func Register(r *mux.Router) {
 				log().Debugf("    + method %s [%s]", path, method.Name)
 
 				// Add version->method to pathVersionMethod
-				if _, ok := pathVersionMethod[path]; !ok {
-					pathVersionMethod[path] = make(versionedMethod)
+				if _, ok := reg.pathVersionMethod[path]; !ok {
+					reg.pathVersionMethod[path] = make(versionedMethod)
 
-					r.Path(path).Methods(http.MethodGet).HandlerFunc(methodHandler(specification, api, path))
+					r.Path(path).Methods(http.MethodGet).HandlerFunc(reg.methodHandler(specification, api, path))
 				}
 
-				pathVersionMethod[path][version] = method
+				reg.pathVersionMethod[path][version] = method
 			}
 
 			for version, methods := range api.Versions {
@@ -78,13 +87,13 @@ func Register(r *mux.Router) {
 
 					path := specID + "/reference/" + api.ID + "/" + method.ID
 					// Add version->resource to pathVersionResource
-					if _, ok := pathVersionMethod[path]; !ok {
-						pathVersionMethod[path] = make(versionedMethod)
+					if _, ok := reg.pathVersionMethod[path]; !ok {
+						reg.pathVersionMethod[path] = make(versionedMethod)
 
-						r.Path(path).Methods(http.MethodGet).HandlerFunc(methodHandler(specification, api, path))
+						r.Path(path).Methods(http.MethodGet).HandlerFunc(reg.methodHandler(specification, api, path))
 					}
 
-					pathVersionMethod[path][version] = method
+					reg.pathVersionMethod[path][version] = method
 				}
 			}
 		}
@@ -98,13 +107,13 @@ func Register(r *mux.Router) {
 				path := specID + "/resources/" + id
 				log().Debugf("      + resource %s", id)
 
-				if _, ok := pathVersionResource[path]; !ok {
-					pathVersionResource[path] = make(versionedResource)
+				if _, ok := reg.pathVersionResource[path]; !ok {
+					reg.pathVersionResource[path] = make(versionedResource)
 
-					r.Path(path).Methods(http.MethodGet).HandlerFunc(globalResourceHandler(specification, path))
+					r.Path(path).Methods(http.MethodGet).HandlerFunc(reg.globalResourceHandler(specification, path))
 				}
 
-				pathVersionResource[path][version] = resource
+				reg.pathVersionResource[path][version] = resource
 			}
 		}
 	}
@@ -153,7 +162,7 @@ func getAPIVersions(api spec.APIGroup) []string {
 }
 
 // apiHandler is a http.Handler for rendering API reference docs.
-func apiHandler(specification *spec.APISpecification, api spec.APIGroup) func(w http.ResponseWriter, req *http.Request) {
+func (reg *registry) apiHandler(specification *spec.APISpecification, api spec.APIGroup) func(w http.ResponseWriter, req *http.Request) {
 	return func(w http.ResponseWriter, req *http.Request) {
 		version := req.FormValue("v") // Get the resource version
 		if version == "" {
@@ -166,14 +175,14 @@ func apiHandler(specification *spec.APISpecification, api spec.APIGroup) func(w
 		tmpl := "api"
 		customTmpl := "reference/" + api.ID
 
-		if render.TemplateLookup(customTmpl) != nil {
+		if reg.rnd.TemplateLookup(customTmpl) != nil {
 			tmpl = customTmpl
 		}
 
 		log().Tracef("-- template: %s  Version %s", tmpl, version)
 
-		render.HTML(w, http.StatusOK, tmpl,
-			render.DefaultVars(req, specification,
+		reg.rnd.HTML(w, http.StatusOK, tmpl,
+			reg.rnd.DefaultVars(req, specification,
 				render.Vars{
 					"Title":         api.Name,
 					"API":           api,
@@ -181,48 +190,52 @@ func apiHandler(specification *spec.APISpecification, api spec.APIGroup) func(w
 					"Version":       version,
 					"Versions":      versions,
 					"LatestVersion": api.CurrentVersion,
+					"Canonical":     reg.rnd.CanonicalURL(req, api.CurrentVersion, versions),
 				}))
 	}
 }
 
 // methodHandler is a http.Handler for rendering API method reference docs.
-func methodHandler(specification *spec.APISpecification, api spec.APIGroup, path string) func(w http.ResponseWriter, req *http.Request) {
+func (reg *registry) methodHandler(specification *spec.APISpecification, api spec.APIGroup, path string) func(w http.ResponseWriter, req *http.Request) {
 	return func(w http.ResponseWriter, req *http.Request) {
 		version := req.FormValue("v") // Get the resource version
 		if version == "" {
 			version = api.CurrentVersion
 		}
 
-		versions := getMethodVersions(api, pathVersionMethod[path])
-		method := pathVersionMethod[path][version]
+		versions := getMethodVersions(api, reg.pathVersionMethod[path])
+		method := reg.pathVersionMethod[path][version]
 
 		tmpl := "method"
 		customTmpl := "reference/" + api.ID + "/" + method.ID
 
-		if render.TemplateLookup(customTmpl) != nil {
+		if reg.rnd.TemplateLookup(customTmpl) != nil {
 			tmpl = customTmpl
 		}
 
 		log().Tracef("-- template: %s  Version %s", tmpl, version)
 
 		// TODO default to latest if version not found, or 404 ?
-		method = pathVersionMethod[path][version]
+		method = reg.pathVersionMethod[path][version]
 
-		render.HTML(w, http.StatusOK, tmpl,
-			render.DefaultVars(req, specification,
+		reg.rnd.HTML(w, http.StatusOK, tmpl,
+			reg.rnd.DefaultVars(req, specification,
 				render.Vars{
-					"Title":         method.Name,
-					"API":           api,
-					"Method":        method,
-					"Version":       version,
-					"Versions":      versions,
-					"LatestVersion": api.CurrentVersion,
+					"Title":           method.Name,
+					"API":             api,
+					"Method":          method,
+					"CodeSamples":     codesample.Samples(api, method),
+					"Version":         version,
+					"Versions":        versions,
+					"LatestVersion":   api.CurrentVersion,
+					"Canonical":       reg.rnd.CanonicalURL(req, api.CurrentVersion, versions),
+					"MetaDescription": render.MetaDescription(method.Description),
 				}))
 	}
 }
 
 // globalResourceHandler is a http.Handler for rendering API resource reference docs.
-func globalResourceHandler(specification *spec.APISpecification, path string) func(w http.ResponseWriter, req *http.Request) {
+func (reg *registry) globalResourceHandler(specification *spec.APISpecification, path string) func(w http.ResponseWriter, req *http.Request) {
 	return func(w http.ResponseWriter, req *http.Request) {
 		version := req.FormValue("v") // Get the resource version - blank is the latest
 		if version == "" {
@@ -233,31 +246,38 @@ func globalResourceHandler(specification *spec.APISpecification, path string) fu
 		var versions []string
 
 		ix := 0
-		versionList := pathVersionResource[path]
+		versionList := reg.pathVersionResource[path]
 
 		if len(versionList) > 1 {
 			// There is more than one version (there is always a "latest"), so
 			// compile list of those available for resource
-			versions = make([]string, len(pathVersionResource[path]))
+			versions = make([]string, len(reg.pathVersionResource[path]))
 			for key := range versionList {
 				versions[ix] = key
 				ix++
 			}
 		}
 
-		resource := pathVersionResource[path][version]
+		resource := reg.pathVersionResource[path][version]
 
 		log().Debugf("Render resource %s", resource.ID)
 
 		tmpl := "resource"
 		customTmpl := "resources/" + resource.ID
 
-		if render.TemplateLookup(customTmpl) != nil {
+		if reg.rnd.TemplateLookup(customTmpl) != nil {
 			tmpl = customTmpl
 		}
 
 		log().Tracef("-- template: %s  Version %s", tmpl, version)
 
-		render.HTML(w, http.StatusOK, tmpl, render.DefaultVars(req, specification, render.Vars{"Title": resource.Title, "Resource": resource, "Version": version, "Versions": versions}))
+		reg.rnd.HTML(w, http.StatusOK, tmpl, reg.rnd.DefaultVars(req, specification, render.Vars{
+			"Title":           resource.Title,
+			"Resource":        resource,
+			"Version":         version,
+			"Versions":        versions,
+			"Canonical":       reg.rnd.CanonicalURL(req, "latest", versions),
+			"MetaDescription": render.MetaDescription(resource.Description),
+		}))
 	}
 }
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	wraperrors "github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	log "github.com/kenjones-cisco/dapperdox/logger"
+)
+
+// Reloader serves the documentation of local specifications, rebuilding it when the files of the
+// specifications, assets or theme change.
+type Reloader struct {
+	*LiveRouter
+
+	watcher  *fsnotify.Watcher
+	debounce time.Duration
+	done     chan struct{}
+	closed   sync.Once
+
+	reloaded func(error)
+}
+
+// NewReloader creates a Reloader serving the documentation of the local specifications set up by cfg
+// and watching the spec-dir, assets-dir and theme directory for changes.
+func NewReloader(cfg *viper.Viper) (*Reloader, error) {
+	return newReloader(cfg, nil)
+}
+
+// newReloader creates a Reloader calling reloaded, when set, after each reload triggered by a change.
+func newReloader(cfg *viper.Viper, reloaded func(error)) (*Reloader, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, wraperrors.Wrap(err, "unable to create file watcher")
+	}
+
+	rl := &Reloader{
+		LiveRouter: NewRouterChain(cfg),
+		watcher:    watcher,
+		debounce:   cfg.GetDuration(config.WatchDebounce),
+		done:       make(chan struct{}),
+		reloaded:   reloaded,
+	}
+
+	for _, dir := range watchedDirs(cfg) {
+		if err := rl.watchTree(dir); err != nil {
+			_ = watcher.Close()
+
+			return nil, err
+		}
+	}
+
+	go rl.run()
+
+	return rl, nil
+}
+
+// Close stops watching for changes.
+func (rl *Reloader) Close() {
+	rl.closed.Do(func() {
+		close(rl.done)
+		_ = rl.watcher.Close()
+	})
+}
+
+// run reloads the documentation once no change has been seen for the debounce period.
+func (rl *Reloader) run() {
+	var fire <-chan time.Time
+
+	for {
+		select {
+		case <-rl.done:
+			return
+		case event, ok := <-rl.watcher.Events:
+			if !ok {
+				return
+			}
+
+			if ignored(event.Name) {
+				continue
+			}
+
+			log.Logger().Debugf("File changed: %s", event)
+
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := rl.watchTree(event.Name); err != nil {
+						log.Logger().Warnf("Unable to watch %s: %s", event.Name, err)
+					}
+				}
+			}
+
+			fire = time.After(rl.debounce)
+		case err, ok := <-rl.watcher.Errors:
+			if !ok {
+				return
+			}
+
+			log.Logger().Warnf("File watcher error: %s", err)
+		case <-fire:
+			fire = nil
+
+			err := rl.Reload()
+			if err != nil {
+				log.Logger().Errorf("Reload failed, serving the previous documentation: %s", err)
+			} else {
+				log.Logger().Info("Reloaded documentation")
+			}
+
+			if rl.reloaded != nil {
+				rl.reloaded(err)
+			}
+		}
+	}
+}
+
+// watchTree watches a directory and its sub-directories, which fsnotify does not do by itself.
+func (rl *Reloader) watchTree(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		if path != root && ignored(path) {
+			return filepath.SkipDir
+		}
+
+		log.Logger().Debugf("Watching %s", path)
+
+		return wraperrors.Wrapf(rl.watcher.Add(path), "unable to watch %s", path)
+	})
+}
+
+// watchedDirs returns the existing directories holding the local specifications, assets and theme.
+func watchedDirs(cfg *viper.Viper) []string {
+	dirs := []string{cfg.GetString(config.SpecDir), cfg.GetString(config.AssetsDir)}
+
+	if theme := cfg.GetString(config.Theme); theme != "" {
+		dir := filepath.Join(cfg.GetString(config.DefaultAssetsDir), "themes")
+		if cfg.GetString(config.ThemeDir) != "" {
+			dir = cfg.GetString(config.ThemeDir)
+		}
+
+		dirs = append(dirs, filepath.Join(dir, theme))
+	}
+
+	var existing []string
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			log.Logger().Warnf("Not watching %s: not a directory", dir)
+
+			continue
+		}
+
+		existing = append(existing, dir)
+	}
+
+	return existing
+}
+
+// ignored returns whether a file is hidden or an editor backup, whose changes are not reloaded.
+func ignored(path string) bool {
+	name := filepath.Base(path)
+
+	return strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~")
+}
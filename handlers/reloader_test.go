@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+)
+
+func TestReloader(t *testing.T) {
+	cfg := config.New()
+
+	specDir := t.TempDir()
+	assetsDir := t.TempDir()
+
+	spec := specToByteSlice("../fixtures/common_api.json")
+	writeFile(t, filepath.Join(specDir, "swagger.json"), string(spec))
+
+	cfg.Set(config.SpecDir, specDir)
+	cfg.Set(config.AssetsDir, assetsDir)
+	cfg.Set(config.DefaultAssetsDir, "../assets")
+	cfg.Set(config.WatchDebounce, "50ms")
+
+	reloads := make(chan error, 1)
+
+	rl, err := newReloader(cfg, func(err error) { reloads <- err })
+	if err != nil {
+		t.Fatalf("newReloader() error = %v", err)
+	}
+	defer rl.Close()
+
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		wantErr   bool
+		path      string
+		wantCode  int
+		wantMatch string
+	}{
+		{
+			name:      "specification changed",
+			file:      filepath.Join(specDir, "swagger.json"),
+			content:   strings.Replace(string(spec), `"List Accounts"`, `"List Reloaded Accounts"`, 1),
+			path:      "/aws-service/reference",
+			wantCode:  http.StatusOK,
+			wantMatch: "List Reloaded Accounts",
+		},
+		{
+			name:      "guide added in a new directory",
+			file:      filepath.Join(assetsDir, "templates", "guides", "intro.md"),
+			content:   "# Introduction\n\nReloaded guide\n",
+			path:      "/guides/intro",
+			wantCode:  http.StatusOK,
+			wantMatch: "Reloaded guide",
+		},
+		{
+			name:      "invalid specification keeps the previous documentation",
+			file:      filepath.Join(specDir, "swagger.json"),
+			content:   `{"swagger": `,
+			wantErr:   true,
+			path:      "/aws-service/reference",
+			wantCode:  http.StatusOK,
+			wantMatch: "List Reloaded Accounts",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFile(t, tt.file, tt.content)
+
+			select {
+			case err := <-reloads:
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("reload error = %v, wantErr %v", err, tt.wantErr)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no reload after the file changed")
+			}
+
+			rec := httptest.NewRecorder()
+			rl.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			body, _ := io.ReadAll(rec.Body)
+			if rec.Code != tt.wantCode || !strings.Contains(string(body), tt.wantMatch) {
+				t.Errorf("GET %s = %d, want %d containing %q", tt.path, rec.Code, tt.wantCode, tt.wantMatch)
+			}
+		})
+	}
+}
+
+// writeFile writes a file, creating its directory when needed.
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
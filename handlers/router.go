@@ -5,46 +5,67 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/justinas/nosurf"
+	wraperrors "github.com/pkg/errors"
 	"github.com/spf13/viper"
 
 	"github.com/kenjones-cisco/dapperdox/config"
 	"github.com/kenjones-cisco/dapperdox/discover"
+	"github.com/kenjones-cisco/dapperdox/handlers/api"
 	"github.com/kenjones-cisco/dapperdox/handlers/guides"
 	"github.com/kenjones-cisco/dapperdox/handlers/home"
 	"github.com/kenjones-cisco/dapperdox/handlers/proxy"
 	"github.com/kenjones-cisco/dapperdox/handlers/reference"
+	"github.com/kenjones-cisco/dapperdox/handlers/search"
+	"github.com/kenjones-cisco/dapperdox/handlers/sitemap"
 	"github.com/kenjones-cisco/dapperdox/handlers/specs"
 	"github.com/kenjones-cisco/dapperdox/handlers/static"
 	"github.com/kenjones-cisco/dapperdox/handlers/timeout"
+	"github.com/kenjones-cisco/dapperdox/i18n"
 	log "github.com/kenjones-cisco/dapperdox/logger"
 	"github.com/kenjones-cisco/dapperdox/render"
+	searchindex "github.com/kenjones-cisco/dapperdox/search"
 	"github.com/kenjones-cisco/dapperdox/spec"
 	"github.com/kenjones-cisco/dapperdox/version"
 )
 
-// NewRouterChain creates a router with a chain of middlewares that acts as an http.Handler.
-func NewRouterChain() http.Handler {
-	router := createMiddlewareRouter()
+var errNoSpecifications = wraperrors.New("no specifications loaded")
 
-	loadAndRegisterSpecs(router, nil)
+// localeCookieAge is the time the locale chosen by a reader is remembered for, in seconds.
+const localeCookieAge = 365 * 24 * 60 * 60
 
-	return router
+// NewRouterChain creates a router with a chain of middlewares that acts as an http.Handler, documenting
+// the local specifications set up by cfg.
+func NewRouterChain(cfg *viper.Viper) *LiveRouter {
+	docs, err := buildRouters(cfg, nil, nil)
+	if err != nil {
+		log.Logger().Errorf("Load specification error: %s", err)
+
+		suite := &spec.Suite{}
+
+		return newLiveRouter(cfg, nil, createMiddlewareRouter(render.New(cfg, suite)), suite)
+	}
+
+	lr := newLiveRouter(cfg, nil, docs.router, docs.suite)
+	lr.localized, lr.indexes = docs.localized, docs.indexes
+
+	return lr
 }
 
-func createMiddlewareRouter() *mux.Router {
+func createMiddlewareRouter(rnd *render.Renderer) *mux.Router {
 	router := mux.NewRouter()
 	router.Use(
 		handlers.RecoveryHandler(handlers.RecoveryLogger(log.Logger()), handlers.PrintRecoveryStack(true)),
 		withLogger,
-		timeoutHandler,
-		withCsrf,
-		injectHeaders,
-		handlers.CORS(handlers.AllowedOrigins(viper.GetStringSlice(config.AllowOrigin))),
+		timeoutHandler(rnd),
+		withCsrf(rnd),
+		injectHeaders(rnd.Config()),
+		handlers.CORS(handlers.AllowedOrigins(rnd.Config().GetStringSlice(config.AllowOrigin))),
 	)
 
 	router.PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index)
@@ -52,60 +73,238 @@ func createMiddlewareRouter() *mux.Router {
 	return router
 }
 
-func loadAndRegisterSpecs(router *mux.Router, d discover.DiscoveryManager) {
-	specs.Register(router, d)
+// BuildRouter loads the specifications set up by cfg, or discovered by d, into a new Suite and registers
+// the routes documenting them on a new router, along with those serving the specifications themselves.
+// The specifications are documented in the default locale.
+func BuildRouter(cfg *viper.Viper, d discover.DiscoveryManager) (*mux.Router, *spec.Suite, error) {
+	router, suite, _, err := buildRouter(cfg, d, i18n.Locales(cfg)[0], nil)
+
+	return router, suite, err
+}
+
+// routers are the routers documenting the specifications in each locale.
+type routers struct {
+	router    *mux.Router                   // router of the default locale
+	suite     *spec.Suite                   // specifications documented by router
+	localized map[string]*mux.Router        // routers of the other locales, keyed by locale
+	indexes   map[string]*searchindex.Index // search index of each locale
+}
+
+// buildRouters builds the router of each locale configured, as buildRouter does. The search index of each
+// locale reuses that of previous, which may be nil, for the same locale.
+func buildRouters(cfg *viper.Viper, d discover.DiscoveryManager, previous map[string]*searchindex.Index) (*routers, error) {
+	locales := i18n.Locales(cfg)
+
+	router, suite, idx, err := buildRouter(cfg, d, locales[0], previous[locales[0]])
+	if err != nil {
+		return nil, err
+	}
+
+	docs := &routers{
+		router:    router,
+		suite:     suite,
+		localized: make(map[string]*mux.Router),
+		indexes:   map[string]*searchindex.Index{locales[0]: idx},
+	}
+
+	for _, locale := range locales[1:] {
+		if docs.localized[locale], _, docs.indexes[locale], err = buildRouter(cfg, d, locale, previous[locale]); err != nil {
+			return nil, err
+		}
+	}
+
+	return docs, nil
+}
 
-	newspecs, err := spec.LoadSpecifications(d)
+// buildRouter builds the router as BuildRouter does in a locale, returning the search index of the Suite
+// too. The index reuses that of the specifications and guides unchanged since previous, which may be nil,
+// was built.
+func buildRouter(cfg *viper.Viper, d discover.DiscoveryManager, locale string, previous *searchindex.Index) (*mux.Router, *spec.Suite, *searchindex.Index, error) {
+	suite, err := spec.LoadLocalizedSpecifications(cfg, d, locale)
 	if err != nil {
-		log.Logger().Fatalf("Load specification error: %s", err)
+		return nil, nil, nil, err
+	}
+
+	for _, loadErr := range suite.Errors() {
+		log.Logger().Warnf("Specification problem: %s", loadErr)
 	}
 
-	// TODO(): fix registrators memory leak
-	// only update/register new specs if new items identified.
-	if newspecs {
-		render.Register()
-		reference.Register(router) // large memory leak when processing multiple/duplicate API specs
-		guides.Register(router)
-		static.Register(router)
-		home.Register(router) // small memory leak when processing multiple/duplicate API specs
-		proxy.Register(router)
+	rnd := render.NewLocalized(cfg, suite, locale)
+	router := createMiddlewareRouter(rnd)
+
+	specs.Register(router, cfg, d)
+
+	// the listings of the specs are served, empty, until any are loaded.
+	idx := search.NewIndex(rnd, previous)
+	search.Register(router, rnd, idx)
+	api.Register(router, rnd)
+	sitemap.Register(router, rnd)
+
+	// only register the specs if any were loaded.
+	if len(suite.Specs) > 0 {
+		reference.Register(router, rnd)
+		guides.Register(router, rnd)
+
+		static.Register(router, rnd)
+		home.Register(router, rnd)
+		proxy.Register(router, cfg)
 	}
+
+	return router, suite, idx, nil
+}
+
+// LiveRouter serves requests with a router that is built afresh whenever the specifications change, so
+// that routes of removed specifications go with the router they were registered on. When several locales
+// are configured, a router is built for each of them, and requests are served by that of their locale.
+type LiveRouter struct {
+	cfg        *viper.Viper
+	d          discover.DiscoveryManager // source of the specifications, when discovered rather than local
+	negotiator *i18n.Negotiator          // selects the locale of requests, nil when there is a single locale
+
+	mu        sync.RWMutex // held for reading while serving a request, and for writing while swapping routers
+	router    *mux.Router
+	suite     *spec.Suite                   // specifications documented by router
+	localized map[string]*mux.Router        // routers of the locales other than the default one
+	indexes   map[string]*searchindex.Index // search index of each locale, whose unchanged parts are reused by a rebuild
+
+	building sync.Mutex // held while rebuilding, so that rebuilds do not overlap
+}
+
+// newLiveRouter creates a LiveRouter serving router, which documents suite, and rebuilding it from the
+// specifications set up by cfg and discovered by d.
+func newLiveRouter(cfg *viper.Viper, d discover.DiscoveryManager, router *mux.Router, suite *spec.Suite) *LiveRouter {
+	lr := &LiveRouter{cfg: cfg, d: d, router: router, suite: suite}
+
+	if locales := i18n.Locales(cfg); len(locales) > 1 {
+		lr.negotiator = i18n.NewNegotiator(locales)
+	}
+
+	return lr
+}
+
+// ServeHTTP serves a request with the router last built successfully for its locale. A request for a
+// path prefixed by a locale is served the page of the path without the prefix, and remembers the locale
+// in a cookie for the pages linked to.
+func (lr *LiveRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	lr.mu.RLock()
+	router, localized := lr.router, lr.localized
+	lr.mu.RUnlock()
+
+	if lr.negotiator != nil {
+		w.Header().Add("Vary", "Accept-Language")
+
+		locale, path, prefixed := lr.negotiator.Locale(req)
+		if prefixed {
+			http.SetCookie(w, &http.Cookie{Name: i18n.CookieName, Value: locale, Path: "/", MaxAge: localeCookieAge, SameSite: http.SameSiteLaxMode})
+
+			u := *req.URL
+			u.Path, u.RawPath = path, ""
+
+			req = req.Clone(req.Context())
+			req.URL = &u
+		}
+
+		if r, ok := localized[locale]; ok {
+			router = r
+		}
+	}
+
+	router.ServeHTTP(w, req)
+}
+
+// Reload rebuilds the documentation from the specifications, assets and theme. The previous documentation
+// keeps being served when the rebuild fails, or, for local specifications, when it brings load errors to
+// specifications that had none. Discovered services come and go, so whatever is discovered is documented.
+func (lr *LiveRouter) Reload() error {
+	if lr.d != nil {
+		return lr.rebuild(nil)
+	}
+
+	return lr.rebuild(func(suite, previous *spec.Suite) error {
+		if len(suite.Specs) == 0 {
+			return errNoSpecifications
+		}
+
+		if errs := suite.Errors(); len(errs) > 0 && len(previous.Errors()) == 0 {
+			return errs
+		}
+
+		return nil
+	})
+}
+
+// rebuild loads the specifications and assets into a new router, which replaces the one serving requests
+// unless accept, when set, rejects the Suite it documents given the Suite previously documented. The
+// previous router keeps serving requests while the new one is built, and when rebuilding fails.
+func (lr *LiveRouter) rebuild(accept func(suite, previous *spec.Suite) error) (err error) {
+	lr.building.Lock()
+	defer lr.building.Unlock()
+
+	defer func() {
+		// Compiling assets and templates panics on invalid files.
+		if r := recover(); r != nil {
+			err = wraperrors.Errorf("%v", r)
+		}
+	}()
+
+	docs, err := buildRouters(lr.cfg, lr.d, lr.indexes)
+	if err != nil {
+		return err
+	}
+
+	if accept != nil {
+		if err := accept(docs.suite, lr.suite); err != nil {
+			return err
+		}
+	}
+
+	lr.mu.Lock()
+	lr.router, lr.suite, lr.localized, lr.indexes = docs.router, docs.suite, docs.localized, docs.indexes
+	lr.mu.Unlock()
+
+	return nil
 }
 
 func withLogger(h http.Handler) http.Handler {
 	return handlers.CombinedLoggingHandler(os.Stdout, h)
 }
 
-func withCsrf(h http.Handler) http.Handler {
-	csrfHandler := nosurf.New(h)
-	csrfHandler.SetFailureHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		rsn := nosurf.Reason(req).Error()
-		log.Logger().Warnf("failed csrf validation: %s", rsn)
-		render.HTML(w, http.StatusBadRequest, "error", map[string]interface{}{"error": rsn})
-	}))
+func withCsrf(rnd *render.Renderer) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		csrfHandler := nosurf.New(h)
+		csrfHandler.SetFailureHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			rsn := nosurf.Reason(req).Error()
+			log.Logger().Warnf("failed csrf validation: %s", rsn)
+			rnd.HTML(w, http.StatusBadRequest, "error", map[string]interface{}{"error": rsn})
+		}))
 
-	return csrfHandler
+		return csrfHandler
+	}
 }
 
-func timeoutHandler(h http.Handler) http.Handler {
-	return timeout.Handler(h, 1*time.Second, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		log.Logger().Warn("request timed out")
-		render.HTML(w, http.StatusRequestTimeout, "error", map[string]interface{}{"error": "Request timed out"})
-	}))
+func timeoutHandler(rnd *render.Renderer) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return timeout.Handler(h, 1*time.Second, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			log.Logger().Warn("request timed out")
+			rnd.HTML(w, http.StatusRequestTimeout, "error", map[string]interface{}{"error": "Request timed out"})
+		}))
+	}
 }
 
 // Handle additional headers such as strict transport security for TLS, and
 // giving the Server name.
-func injectHeaders(h http.Handler) http.Handler {
-	tlsEnabled := viper.GetString(config.TLSCert) != "" && viper.GetString(config.TLSKey) != ""
+func injectHeaders(cfg *viper.Viper) mux.MiddlewareFunc {
+	tlsEnabled := cfg.GetString(config.TLSCert) != "" && cfg.GetString(config.TLSKey) != ""
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Add("Server", fmt.Sprintf("%s %s", version.ProductName, version.Version))
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Server", fmt.Sprintf("%s %s", version.ProductName, version.Version))
 
-		if tlsEnabled {
-			w.Header().Add("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
-		}
+			if tlsEnabled {
+				w.Header().Add("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+			}
 
-		h.ServeHTTP(w, r)
-	})
+			h.ServeHTTP(w, r)
+		})
+	}
 }
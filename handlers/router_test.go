@@ -0,0 +1,523 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/i18n"
+	"github.com/kenjones-cisco/dapperdox/postman"
+)
+
+func TestBuildRouter_Print(t *testing.T) {
+	anchorLinkRegex := regexp.MustCompile(`href="#([^"]+)"`)
+
+	tests := []struct {
+		name        string
+		file        string
+		path        string
+		wantMatches []string
+	}{
+		{
+			name: "methods and resources",
+			file: "bookings_api.json",
+			path: "/bookings/print",
+			wantMatches: []string{
+				`<h1 class="nomargin">Bookings reference</h1>`,
+				`id="/reference/bookings-of-rooms/list-bookings-v2?v=latest"`,
+				`id="/reference/legacy-bookings/list-bookings-v1?v=latest"`,
+				`id="/resources/booking?v=latest"`,
+				`href="#/resources/booking?v=latest"`,
+				`media="print"`,
+			},
+		},
+		{
+			name: "request bodies and variants",
+			file: "polymorphic_api.json",
+			path: "/pet-shelter/print",
+			wantMatches: []string{
+				"Request body",
+				"Variants",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Set(config.SpecDir, "../fixtures/")
+			cfg.Set(config.SpecFilename, []string{tt.file})
+			cfg.Set(config.DefaultAssetsDir, "../assets")
+
+			router, _, err := BuildRouter(cfg, nil)
+			if err != nil {
+				t.Fatalf("BuildRouter() error = %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d, want %d", tt.path, rec.Code, http.StatusOK)
+			}
+
+			body := rec.Body.String()
+
+			for _, want := range tt.wantMatches {
+				if !strings.Contains(body, want) {
+					t.Errorf("GET %s does not contain %s", tt.path, want)
+				}
+			}
+
+			if strings.Contains(body, "navbar") {
+				t.Errorf("GET %s contains the site navigation", tt.path)
+			}
+
+			for _, m := range anchorLinkRegex.FindAllStringSubmatch(body, -1) {
+				if !strings.Contains(body, `id="`+m[1]+`"`) {
+					t.Errorf("GET %s links to missing anchor %s", tt.path, html.UnescapeString(m[1]))
+				}
+			}
+		})
+	}
+}
+
+func TestBuildRouter_Postman(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.SpecDir, "../fixtures/")
+	cfg.Set(config.SpecFilename, []string{"postman_api.json"})
+	cfg.Set(config.DefaultAssetsDir, "../assets")
+
+	router, _, err := BuildRouter(cfg, nil)
+	if err != nil {
+		t.Fatalf("BuildRouter() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rooms/postman.json", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /rooms/postman.json = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("GET /rooms/postman.json Content-Type = %s, want application/json", ct)
+	}
+
+	var c postman.Collection
+	if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil {
+		t.Fatalf("GET /rooms/postman.json is not a collection: %v", err)
+	}
+
+	if c.Info.Schema != postman.SchemaURL || len(c.Item) != 1 {
+		t.Errorf("GET /rooms/postman.json = %+v, want a folder of the Rooms API", c)
+	}
+}
+
+func TestBuildRouter_Search(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.SpecDir, "../fixtures/")
+	cfg.Set(config.SpecFilename, []string{"bookings_api.json"})
+	cfg.Set(config.DefaultAssetsDir, "../assets")
+
+	router, _, err := BuildRouter(cfg, nil)
+	if err != nil {
+		t.Fatalf("BuildRouter() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		wantMatches []string
+	}{
+		{
+			name: "page",
+			path: "/search?q=room&spec=bookings",
+			wantMatches: []string{
+				`<option value="bookings" selected>Bookings</option>`,
+				`<a href="/bookings/reference/bookings-of-rooms/list-bookings-v2">List bookings</a>`,
+				`Bookings of <mark>rooms</mark>`,
+			},
+		},
+		{
+			name: "json",
+			path: "/search.json?q=room&limit=1",
+			wantMatches: []string{
+				`"query":"room","total":4,`,
+				`"titleHighlight":"Bookings of <mark>rooms</mark>"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d, want %d", tt.path, rec.Code, http.StatusOK)
+			}
+
+			for _, want := range tt.wantMatches {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("GET %s does not contain %s", tt.path, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildRouter_API(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.SpecDir, "../fixtures/")
+	cfg.Set(config.SpecFilename, []string{"bookings_api.json", "recursive_api.json"})
+	cfg.Set(config.DefaultAssetsDir, "../assets")
+
+	router, _, err := BuildRouter(cfg, nil)
+	if err != nil {
+		t.Fatalf("BuildRouter() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		wantCode    int
+		wantMatches []string
+	}{
+		{
+			name:     "specifications",
+			path:     "/api/v1/specs",
+			wantCode: http.StatusOK,
+			wantMatches: []string{
+				`"id":"bookings","title":"Bookings"`,
+				`"id":"discussions","title":"Discussions"`,
+				`"total":2,"offset":0,"limit":50}`,
+			},
+		},
+		{
+			name:     "groups of every specification paginated",
+			path:     "/api/v1/groups?limit=1&offset=1",
+			wantCode: http.StatusOK,
+			wantMatches: []string{
+				`"id":"legacy-bookings","name":"Legacy bookings","specId":"bookings"`,
+				`"offset":1,"limit":1,"next":"/api/v1/groups?limit=1&offset=2"`,
+			},
+		},
+		{
+			name:     "method",
+			path:     "/api/v1/specs/bookings/groups/legacy-bookings/methods/list-bookings-v1?v=latest",
+			wantCode: http.StatusOK,
+			wantMatches: []string{
+				`"id":"list-bookings-v1","specId":"bookings","groupId":"legacy-bookings"`,
+				`"path":"/v1/bookings","version":"latest"`,
+				`"resource":{"id":"booking","title":"Booking"},"isArray":true`,
+			},
+		},
+		{
+			name:     "resource used by methods",
+			path:     "/api/v1/specs/bookings/resources/booking",
+			wantCode: http.StatusOK,
+			wantMatches: []string{
+				`"id":"booking","title":"Booking","version":"latest"`,
+				`"methods":[{"id":"list-bookings-v2","specId":"bookings","groupId":"bookings-of-rooms"`,
+			},
+		},
+		{
+			name:     "recursive resource",
+			path:     "/api/v1/specs/discussions/resources/comment",
+			wantCode: http.StatusOK,
+			wantMatches: []string{
+				`"recursive":{"id":"comment","title":"Comment"}`,
+			},
+		},
+		{
+			name:        "unknown version",
+			path:        "/api/v1/specs/bookings/groups/legacy-bookings/methods?v=v9",
+			wantCode:    http.StatusNotFound,
+			wantMatches: []string{`{"error":"version \"v9\" of API group \"legacy-bookings\" not found"}`},
+		},
+		{
+			name:        "unknown specification",
+			path:        "/api/v1/specs/nope/groups",
+			wantCode:    http.StatusNotFound,
+			wantMatches: []string{`{"error":"specification \"nope\" not found"}`},
+		},
+		{
+			name:        "offset past the end",
+			path:        "/api/v1/specs?offset=" + strconv.Itoa(math.MaxInt),
+			wantCode:    http.StatusOK,
+			wantMatches: []string{`"items":[],"total":2,"offset":` + strconv.Itoa(math.MaxInt)},
+		},
+		{
+			name:        "invalid limit",
+			path:        "/api/v1/specs?limit=0",
+			wantCode:    http.StatusBadRequest,
+			wantMatches: []string{`"error":"invalid limit \"0\"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("GET %s = %d, want %d", tt.path, rec.Code, tt.wantCode)
+			}
+
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Errorf("GET %s Content-Type = %s, want application/json", tt.path, ct)
+			}
+
+			for _, want := range tt.wantMatches {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("GET %s does not contain %s\n%s", tt.path, want, rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestBuildRouter_NoSpecifications(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.DiscoveryEnabled, true)
+	cfg.Set(config.DefaultAssetsDir, "../assets")
+
+	// nothing discovered yet
+	router, _, err := BuildRouter(cfg, &specsDiscover{specs: map[string][]byte{}})
+	if err != nil {
+		t.Fatalf("BuildRouter() error = %v", err)
+	}
+
+	tests := []struct {
+		path      string
+		wantMatch string
+	}{
+		{path: "/api/v1/specs", wantMatch: `"items":[],"total":0`},
+		{path: "/api/v1/groups", wantMatch: `"items":[],"total":0`},
+		{path: "/sitemap.xml", wantMatch: `<urlset`},
+		{path: "/robots.txt", wantMatch: `User-agent: *`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d, want %d", tt.path, rec.Code, http.StatusOK)
+			}
+
+			if !strings.Contains(rec.Body.String(), tt.wantMatch) {
+				t.Errorf("GET %s does not contain %s\n%s", tt.path, tt.wantMatch, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestBuildRouter_Sitemap(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.SpecDir, "../fixtures/")
+	cfg.Set(config.SpecFilename, []string{"bookings_api.json", "recursive_api.json"})
+	cfg.Set(config.DefaultAssetsDir, "../assets")
+	cfg.Set(config.SiteURL, "https://docs.example.com/")
+	cfg.Set(config.RobotsDisallow, []string{"/api/", "/search"})
+
+	router, _, err := BuildRouter(cfg, nil)
+	if err != nil {
+		t.Fatalf("BuildRouter() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		wantMatches []string
+		wantMissing []string
+	}{
+		{
+			name: "sitemap",
+			path: "/sitemap.xml",
+			wantMatches: []string{
+				`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`,
+				"<loc>https://docs.example.com/</loc>",
+				"<loc>https://docs.example.com/bookings/reference</loc>",
+				"<loc>https://docs.example.com/bookings/reference/legacy-bookings</loc>",
+				"<loc>https://docs.example.com/bookings/reference/legacy-bookings/list-bookings-v1</loc>",
+				"<loc>https://docs.example.com/bookings/resources/booking</loc>",
+				"<loc>https://docs.example.com/discussions/resources/comment</loc>",
+			},
+			wantMissing: []string{"?v="},
+		},
+		{
+			name: "robots",
+			path: "/robots.txt",
+			wantMatches: []string{
+				"User-agent: *\nDisallow: /api/\nDisallow: /search\n",
+				"Sitemap: https://docs.example.com/sitemap.xml\n",
+			},
+		},
+		{
+			name: "current version",
+			path: "/bookings/reference/legacy-bookings/list-bookings-v1?v=latest",
+			wantMatches: []string{
+				`<link rel="canonical" href="https://docs.example.com/bookings/reference/legacy-bookings/list-bookings-v1">`,
+				`<meta name="description" content="A specification documenting the bookings of rooms, and their legacy API">`,
+			},
+		},
+		{
+			name: "resource",
+			path: "/bookings/resources/booking",
+			wantMatches: []string{
+				`<link rel="canonical" href="https://docs.example.com/bookings/resources/booking">`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d, want %d", tt.path, rec.Code, http.StatusOK)
+			}
+
+			for _, want := range tt.wantMatches {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("GET %s does not contain %s", tt.path, want)
+				}
+			}
+
+			for _, missing := range tt.wantMissing {
+				if strings.Contains(rec.Body.String(), missing) {
+					t.Errorf("GET %s contains %s", tt.path, missing)
+				}
+			}
+		})
+	}
+}
+
+func TestLiveRouter_Localization(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.SpecDir, "../fixtures/")
+	cfg.Set(config.SpecFilename, []string{"i18n_api.json"})
+	cfg.Set(config.DefaultAssetsDir, "../assets")
+	cfg.Set(config.AssetsDir, "../fixtures/i18n_assets")
+	cfg.Set(config.SiteURL, "https://docs.example.com")
+	cfg.Set(config.Locales, []string{"en", "fr", "de"})
+
+	router := NewRouterChain(cfg)
+
+	tests := []struct {
+		name           string
+		path           string
+		cookie         string
+		acceptLanguage string
+		wantCookie     string
+		wantMatches    []string
+		wantMissing    []string
+	}{
+		{
+			name: "default locale",
+			path: "/rooms/reference/rooms-available-to-book/get-room",
+			wantMatches: []string{
+				`<html lang="en">`,
+				`<title>Rooms: Get a room</title>`,
+				`<span class="status-desc">Not Found</span>`,
+				`<h2 class="sub-header">Request</h2>`,
+				`<a href="/fr/rooms/reference/rooms-available-to-book/get-room" hreflang="fr" lang="fr">Français</a>`,
+			},
+		},
+		{
+			name:       "prefix",
+			path:       "/fr/rooms/reference/rooms-available-to-book/get-room",
+			wantCookie: "fr",
+			wantMatches: []string{
+				`<html lang="fr">`,
+				`<title>Salles: Obtenir une salle</title>`,
+				`<p>Renvoie une salle par son identifiant.</p>`,
+				`<span class="status-desc">Non trouvé</span>`,
+				`<h2 class="sub-header">Requête</h2>`,
+				`<link rel="canonical" href="https://docs.example.com/fr/rooms/reference/rooms-available-to-book/get-room">`,
+			},
+		},
+		{
+			name:        "cookie",
+			path:        "/rooms/resources/room",
+			cookie:      "fr",
+			wantMatches: []string{`<h2 class="sub-header">Propriétés</h2>`, `<p>Nom de la salle</p>`},
+		},
+		{
+			name:           "accept language",
+			path:           "/rooms/resources/room",
+			acceptLanguage: "de-AT, en;q=0.5",
+			wantMatches:    []string{`<html lang="de">`, `<h2 class="sub-header">Eigenschaften</h2>`, `<p>Name of the room</p>`},
+		},
+		{
+			name:        "guide",
+			path:        "/guides/welcome",
+			wantMatches: []string{`<p>Welcome to the rooms.</p>`},
+		},
+		{
+			name:        "localized guide",
+			path:        "/fr/guides/welcome",
+			wantCookie:  "fr",
+			wantMatches: []string{`<p>Bienvenue dans les salles.</p>`},
+		},
+		{
+			name: "sitemap",
+			path: "/sitemap.xml",
+			wantMatches: []string{
+				"<loc>https://docs.example.com/rooms/reference</loc>",
+				"<loc>https://docs.example.com/fr/rooms/reference</loc>",
+				"<loc>https://docs.example.com/de/guides/welcome</loc>",
+			},
+			wantMissing: []string{"welcome.fr"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: i18n.CookieName, Value: tt.cookie})
+			}
+
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d, want %d", tt.path, rec.Code, http.StatusOK)
+			}
+
+			var cookie string
+
+			for _, c := range rec.Result().Cookies() {
+				if c.Name == i18n.CookieName {
+					cookie = c.Value
+				}
+			}
+
+			if cookie != tt.wantCookie {
+				t.Errorf("GET %s sets the locale cookie to %q, want %q", tt.path, cookie, tt.wantCookie)
+			}
+
+			for _, want := range tt.wantMatches {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("GET %s does not contain %s", tt.path, want)
+				}
+			}
+
+			for _, missing := range tt.wantMissing {
+				if strings.Contains(rec.Body.String(), missing) {
+					t.Errorf("GET %s contains %s", tt.path, missing)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Package search provides handlers searching the documentation.
+package search
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/kenjones-cisco/dapperdox/handlers/guides"
+	"github.com/kenjones-cisco/dapperdox/render"
+	index "github.com/kenjones-cisco/dapperdox/search"
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// response is the result of a search served as JSON.
+type response struct {
+	Query   string         `json:"query"`
+	Total   int            `json:"total"`
+	Results []index.Result `json:"results"`
+}
+
+// NewIndex builds the index of the specifications and guides documented by rnd, reusing the segments of
+// previous, which may be nil, for those that have not changed.
+func NewIndex(rnd *render.Renderer, previous *index.Index) *index.Index {
+	specs := make([]*spec.APISpecification, 0, len(rnd.Suite().Specs)+1)
+	for _, specification := range rnd.Suite().Specs {
+		specs = append(specs, specification)
+	}
+
+	sort.Slice(specs, func(i, j int) bool { return specs[i].ID < specs[j].ID })
+
+	var list []index.Guide
+
+	// Top level guides, followed by those of each specification.
+	for _, specification := range append([]*spec.APISpecification{nil}, specs...) {
+		id := ""
+		if specification != nil {
+			id = specification.ID
+		}
+
+		for _, guide := range guides.List(rnd.Assets(), specification) {
+			list = append(list, index.Guide{
+				Spec:  id,
+				Route: guide.Route,
+				Title: guide.Title,
+				Text:  rnd.Assets().Text(guide.Asset),
+			})
+		}
+	}
+
+	return index.New(rnd.Suite(), list, previous)
+}
+
+// Register creates the routes searching the documentation indexed by idx, as a page and as JSON.
+func Register(r *mux.Router, rnd *render.Renderer, idx *index.Index) {
+	log().Info("Registering search")
+
+	r.Path("/search").Methods(http.MethodGet).HandlerFunc(pageHandler(rnd, idx))
+	r.Path("/search.json").Methods(http.MethodGet).HandlerFunc(jsonHandler(idx))
+}
+
+// pageHandler is a http.Handler rendering the results of the search given by the query parameters.
+func pageHandler(rnd *render.Renderer, idx *index.Index) func(w http.ResponseWriter, req *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		q := query(req)
+		results := idx.Search(q)
+
+		log().Tracef("-- template: search  Query %q found %d results", q.Text, len(results))
+
+		rnd.HTML(w, http.StatusOK, "search", rnd.DefaultVars(req, rnd.Suite().Specs[q.Spec], render.Vars{
+			"Title":         rnd.Message("title.search"),
+			"SearchQuery":   q,
+			"SearchTotal":   len(results),
+			"SearchResults": limit(results, limitParam(req)),
+		}))
+	}
+}
+
+// jsonHandler is a http.Handler serving the results of the search given by the query parameters as JSON.
+func jsonHandler(idx *index.Index) func(w http.ResponseWriter, req *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		q := query(req)
+		results := idx.Search(q)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+
+		_ = enc.Encode(response{Query: q.Text, Total: len(results), Results: limit(results, limitParam(req))})
+	}
+}
+
+// query returns the search given by the q, spec, group and version query parameters.
+func query(req *http.Request) index.Query {
+	values := req.URL.Query()
+
+	return index.Query{
+		Text:    values.Get("q"),
+		Spec:    values.Get("spec"),
+		Group:   values.Get("group"),
+		Version: values.Get("version"),
+	}
+}
+
+// limitParam returns the number of results requested by the limit query parameter, within maxLimit.
+func limitParam(req *http.Request) int {
+	n, err := strconv.Atoi(req.URL.Query().Get("limit"))
+
+	switch {
+	case err != nil || n <= 0:
+		return defaultLimit
+	case n > maxLimit:
+		return maxLimit
+	}
+
+	return n
+}
+
+func limit(results []index.Result, n int) []index.Result {
+	if len(results) > n {
+		return results[:n]
+	}
+
+	if results == nil {
+		return []index.Result{}
+	}
+
+	return results
+}
@@ -0,0 +1,174 @@
+// Package sitemap provides the handlers of the sitemap and robots.txt, for search engines to index the
+// documentation.
+package sitemap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/handlers/guides"
+	"github.com/kenjones-cisco/dapperdox/i18n"
+	"github.com/kenjones-cisco/dapperdox/render"
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// xmlns is the namespace of the sitemap protocol.
+const xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// urlSet is the document of a sitemap.
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	URLs    []loc    `xml:"url"`
+}
+
+type loc struct {
+	Loc string `xml:"loc"`
+}
+
+// Register creates the routes of the sitemap and robots.txt of the documentation, built from the
+// site-url as it is registered, so that they follow the specifications and guides on a reload.
+func Register(r *mux.Router, rnd *render.Renderer) {
+	log().Info("Registering sitemap")
+
+	sitemap, err := Build(rnd)
+	if err != nil {
+		log().Errorf("Failed to build the sitemap: %s", err)
+	}
+
+	robots := Robots(rnd)
+
+	r.Path("/sitemap.xml").Methods(http.MethodGet).HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if sitemap == nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		_, _ = w.Write(sitemap)
+	})
+
+	r.Path("/robots.txt").Methods(http.MethodGet).HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write(robots)
+	})
+}
+
+// Build returns the sitemap of the pages documented by rnd: the specification list when it is the home
+// page, then the summary, guides, API groups, methods and resources of each specification, followed by
+// the top level guides. Versions are left out, the unversioned URLs documenting the current version.
+// Specifications that failed to load have no pages to index. When several locales are configured, each
+// page is listed in the default locale, then prefixed by each of the other locales.
+func Build(rnd *render.Renderer) ([]byte, error) {
+	site := rnd.SiteURL()
+	set := urlSet{Xmlns: xmlns}
+
+	prefixes := []string{""}
+	for _, locale := range i18n.Locales(rnd.Config())[1:] {
+		prefixes = append(prefixes, "/"+locale)
+	}
+
+	add := func(path string) {
+		for _, prefix := range prefixes {
+			set.URLs = append(set.URLs, loc{Loc: site + prefix + path})
+		}
+	}
+
+	specs := rnd.Suite().Specs
+	if len(specs) > 1 || rnd.Config().GetBool(config.ForceSpecList) {
+		add("/")
+	}
+
+	ids := make([]string, 0, len(specs))
+	for id := range specs {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		specification := specs[id]
+		if len(specification.Errors) > 0 {
+			continue
+		}
+
+		add("/" + id + "/reference")
+
+		for _, guide := range guides.List(rnd.Assets(), specification) {
+			add(guide.Route)
+		}
+
+		for _, api := range specification.APIs {
+			add("/" + id + "/reference/" + api.ID)
+
+			for _, method := range api.Methods {
+				add("/" + id + "/reference/" + api.ID + "/" + method.ID)
+			}
+		}
+
+		for _, resource := range sortedResources(specification) {
+			add("/" + id + "/resources/" + resource)
+		}
+	}
+
+	for _, guide := range guides.List(rnd.Assets(), nil) {
+		add(guide.Route)
+	}
+
+	var b bytes.Buffer
+
+	b.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(&b)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(set); err != nil {
+		return nil, err
+	}
+
+	b.WriteByte('\n')
+
+	return b.Bytes(), nil
+}
+
+// Robots returns the robots.txt of the documentation, disallowing the paths of the robots.disallow
+// configuration and giving the URL of the sitemap.
+func Robots(rnd *render.Renderer) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("User-agent: *\n")
+
+	disallow := rnd.Config().GetStringSlice(config.RobotsDisallow)
+	if len(disallow) == 0 {
+		b.WriteString("Disallow:\n")
+	}
+
+	for _, path := range disallow {
+		fmt.Fprintf(&b, "Disallow: %s\n", path)
+	}
+
+	fmt.Fprintf(&b, "\nSitemap: %s/sitemap.xml\n", rnd.SiteURL())
+
+	return b.Bytes()
+}
+
+// sortedResources returns the IDs of the latest resources of a specification, in order.
+func sortedResources(specification *spec.APISpecification) []string {
+	resources := specification.ResourceList["latest"]
+
+	ids := make([]string, 0, len(resources))
+	for id := range resources {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
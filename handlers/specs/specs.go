@@ -33,20 +33,18 @@ import (
 	"github.com/kenjones-cisco/dapperdox/discover"
 )
 
-var specReplacer *strings.Replacer
-
 // Register creates routes for each static resource.
-func Register(r *mux.Router, d discover.DiscoveryManager) {
+func Register(r *mux.Router, cfg *viper.Viper, d discover.DiscoveryManager) {
 	log().Info("Registering specifications")
 
-	loadReplacer()
+	specReplacer := newReplacer(cfg)
 
 	var specMap map[string][]byte
 
-	if viper.GetBool(config.DiscoveryEnabled) {
+	if cfg.GetBool(config.DiscoveryEnabled) {
 		specMap = loadSpecsByDiscovery(d)
 	} else {
-		specMap = loadSpecsByDir()
+		specMap = loadSpecsByDir(cfg.GetString(config.SpecDir))
 	}
 
 	for k := range specMap {
@@ -65,24 +63,22 @@ func Register(r *mux.Router, d discover.DiscoveryManager) {
 	}
 }
 
-func loadReplacer() {
-	// Build a replacer to search/replace specification URLs
-	if specReplacer == nil {
-		var replacements []string
-
-		// Configure the replacer with key=value pairs
-		for k, v := range viper.GetStringMapString(config.SpecRewriteURL) {
-			if v != "" {
-				// Map between configured to=from URL pair
-				replacements = append(replacements, k, v)
-			} else {
-				// Map between configured URL and site URL
-				replacements = append(replacements, k, viper.GetString(config.SiteURL))
-			}
+// newReplacer builds a replacer to search/replace the specification URLs configured.
+func newReplacer(cfg *viper.Viper) *strings.Replacer {
+	var replacements []string
+
+	// Configure the replacer with key=value pairs
+	for k, v := range cfg.GetStringMapString(config.SpecRewriteURL) {
+		if v != "" {
+			// Map between configured to=from URL pair
+			replacements = append(replacements, k, v)
+		} else {
+			// Map between configured URL and site URL
+			replacements = append(replacements, k, cfg.GetString(config.SiteURL))
 		}
-
-		specReplacer = strings.NewReplacer(replacements...)
 	}
+
+	return strings.NewReplacer(replacements...)
 }
 
 func loadSpecsByDiscovery(d discover.DiscoveryManager) map[string][]byte {
@@ -103,14 +99,14 @@ func loadSpecsByDiscovery(d discover.DiscoveryManager) map[string][]byte {
 	return nsMap
 }
 
-func loadSpecsByDir() map[string][]byte {
-	if viper.GetString(config.SpecDir) == "" {
+func loadSpecsByDir(specDir string) map[string][]byte {
+	if specDir == "" {
 		log().Info("- No local specifications to serve")
 
 		return nil
 	}
 
-	base, err := filepath.Abs(filepath.Clean(viper.GetString(config.SpecDir)))
+	base, err := filepath.Abs(filepath.Clean(specDir))
 	if err != nil {
 		log().Errorf("Error forming specification path: %s", err)
 
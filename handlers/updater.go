@@ -1,46 +1,51 @@
 package handlers
 
 import (
-	"net/http"
+	"sync/atomic"
 	"time"
 
-	"github.com/gorilla/mux"
 	"github.com/spf13/viper"
 
 	"github.com/kenjones-cisco/dapperdox/config"
 	"github.com/kenjones-cisco/dapperdox/discover"
+	log "github.com/kenjones-cisco/dapperdox/logger"
+	"github.com/kenjones-cisco/dapperdox/render"
+	"github.com/kenjones-cisco/dapperdox/spec"
 )
 
-// Updater periodically refreshes API documentation from discovered specs.
+// Updater periodically refreshes API documentation from discovered specs, building a new router on
+// each update and swapping it in behind the stable http.Handler returned by Router.
 type Updater struct {
-	d discover.DiscoveryManager
-	r *mux.Router
+	r *LiveRouter
 
+	timer  *time.Timer // initial update
 	ticker *time.Ticker
 	done   chan bool
 
 	closed   bool
-	notified bool
+	notified int32 // set to 1, atomically, when the discovered specs have changed since the last update
 }
 
-// NewAutoDiscoverUpdater creates a new Updater instance with AutoDiscovery background process.
-func NewAutoDiscoverUpdater(discoverer discover.DiscoveryManager) *Updater {
-	router := createMiddlewareRouter()
+// NewAutoDiscoverUpdater creates a new Updater instance with AutoDiscovery background process, documenting
+// the specs discovered as set up by cfg.
+func NewAutoDiscoverUpdater(cfg *viper.Viper, discoverer discover.DiscoveryManager) *Updater {
+	// Nothing is documented until the first update.
+	suite := &spec.Suite{}
+	router := newLiveRouter(cfg, discoverer, createMiddlewareRouter(render.New(cfg, suite)), suite)
 
 	// create updater instance to periodically fetch the latest auto-discovered specs
 	updater := &Updater{
-		d:        discoverer,
 		r:        router,
-		ticker:   time.NewTicker(viper.GetDuration(config.DiscoveryPeriodTime)),
+		ticker:   time.NewTicker(cfg.GetDuration(config.DiscoveryPeriodTime)),
 		done:     make(chan bool),
-		notified: true,
+		notified: 1,
 	}
 
 	// register the an OnChange function to know when the available discovery data has been changed
 	discoverer.RegisterOnChangeFunc(updater.onChange)
 
 	// wait a short configured period of time and then
-	time.AfterFunc(viper.GetDuration(config.DiscoveryInitialDelay), updater.update)
+	updater.timer = time.AfterFunc(cfg.GetDuration(config.DiscoveryInitialDelay), updater.update)
 
 	// initiate periodic spec updater to fetch latest discovered API specs and generate API documentation
 	go func(u *Updater) {
@@ -57,17 +62,22 @@ func NewAutoDiscoverUpdater(discoverer discover.DiscoveryManager) *Updater {
 	return updater
 }
 
-// Router returns an Updater's Router instance.
-func (u *Updater) Router() http.Handler {
+// Router returns the http.Handler serving the documentation of the last update, which can also be
+// reloaded without waiting for the next one.
+func (u *Updater) Router() *LiveRouter {
 	return u.r
 }
 
-// Close stops the periodic ticker and closes boolean channel.
+// Close stops the initial update timer and periodic ticker, and closes boolean channel.
 func (u *Updater) Close() {
 	if u.closed {
 		return
 	}
 
+	if u.timer != nil {
+		u.timer.Stop()
+	}
+
 	u.ticker.Stop()
 
 	u.done <- true
@@ -76,15 +86,20 @@ func (u *Updater) Close() {
 	u.closed = true
 }
 
+// onChange is called by the discovery process, from its own goroutine, when the discovered specs change.
 func (u *Updater) onChange() {
-	u.notified = true
+	atomic.StoreInt32(&u.notified, 1)
 }
 
 func (u *Updater) update() {
-	if !u.notified {
+	if !atomic.CompareAndSwapInt32(&u.notified, 1, 0) {
 		return
 	}
 
-	loadAndRegisterSpecs(u.r, u.d)
-	u.notified = false
+	if err := u.r.Reload(); err != nil {
+		log.Logger().Errorf("Load specification error: %s", err)
+
+		// retried on the next tick, the discovered specs not being documented yet
+		atomic.StoreInt32(&u.notified, 1)
+	}
 }
@@ -1,27 +1,35 @@
 package handlers
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/spf13/viper"
 
 	"github.com/kenjones-cisco/dapperdox/config"
 	"github.com/kenjones-cisco/dapperdox/discover"
+	"github.com/kenjones-cisco/dapperdox/spec"
 )
 
 func TestUpdater_AutoDiscoverUpdater(t *testing.T) {
-	config.Restore()
+	cfg := config.New()
 
-	viper.Set(config.DiscoveryEnabled, true)
-	viper.Set(config.DiscoveryPeriodTime, "1s")
+	cfg.Set(config.DiscoveryEnabled, true)
+	cfg.Set(config.DiscoveryPeriodTime, "1s")
 
 	fd := &fakeDiscover{
 		t: t,
 	}
 
-	updater := NewAutoDiscoverUpdater(fd)
+	updater := NewAutoDiscoverUpdater(cfg, fd)
 	defer updater.Close()
 
 	type fields struct {
@@ -109,10 +117,7 @@ func TestUpdater_AutoDiscoverUpdater(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			fd.testName = tt.name
-			fd.sPaths = tt.fields.specPathMap
-			fd.wantSpecs = tt.wantSpecs
-			fd.wantErr = tt.wantErr
+			fd.set(tt.name, tt.fields.specPathMap, tt.wantSpecs, tt.wantErr)
 
 			time.Sleep(time.Second * 2)
 		})
@@ -121,25 +126,342 @@ func TestUpdater_AutoDiscoverUpdater(t *testing.T) {
 
 func TestUpdater_Close(t *testing.T) {
 	updater := &Updater{
-		d:      discover.NewDefaultDiscoverer(),
-		r:      mux.NewRouter(),
+		r:      &LiveRouter{d: discover.NewDefaultDiscoverer(), router: mux.NewRouter()},
 		ticker: time.NewTicker(time.Second * 1),
 		done:   make(chan bool),
 	}
 
-	closed := false
+	closed := make(chan bool)
 
 	go func(u *Updater) {
-		for range u.done {
-			closed = true
+		for done := range u.done {
+			closed <- done
 		}
+
+		close(closed)
 	}(updater)
 
 	updater.Close()
 
-	if !closed {
+	if !<-closed {
 		t.Error("updater.Close() failed to clean up")
 	}
 
 	updater.Close()
 }
+
+func TestUpdater_CloseBeforeInitialUpdate(t *testing.T) {
+	cfg := config.New()
+
+	cfg.Set(config.DiscoveryEnabled, true)
+	cfg.Set(config.DiscoveryInitialDelay, "50ms")
+
+	sd := &specsDiscover{}
+
+	updater := NewAutoDiscoverUpdater(cfg, sd)
+	updater.Close()
+
+	time.Sleep(100 * time.Millisecond)
+
+	// the initial update did not run
+	if atomic.LoadInt32(&updater.notified) != 1 {
+		t.Error("updater.Close() did not stop the initial update")
+	}
+}
+
+// specsDiscover serves a fixed set of discovered specifications.
+type specsDiscover struct {
+	specs map[string][]byte
+}
+
+func (sd *specsDiscover) Shutdown()                   {}
+func (sd *specsDiscover) Run()                        {}
+func (sd *specsDiscover) Specs() map[string][]byte    { return sd.specs }
+func (sd *specsDiscover) RegisterOnChangeFunc(func()) {}
+
+func TestUpdater_Update(t *testing.T) {
+	cfg := config.New()
+
+	cfg.Set(config.DiscoveryEnabled, true)
+	cfg.Set(config.DefaultAssetsDir, "../assets")
+
+	common := specToByteSlice("../fixtures/common_api.json")
+	allof := specToByteSlice("../fixtures/allof_api.json")
+
+	sd := &specsDiscover{}
+	updater := &Updater{r: newLiveRouter(cfg, sd, mux.NewRouter(), &spec.Suite{})}
+
+	tests := []struct {
+		name  string
+		specs map[string][]byte
+		want  map[string]int // path -> status code
+	}{
+		{
+			name:  "specifications discovered",
+			specs: map[string][]byte{"specs/common": common, "specs/allof": allof},
+			want: map[string]int{
+				"/aws-service/reference":         http.StatusOK,
+				"/service-consumption/reference": http.StatusOK,
+				"/specs/allof/api.json":          http.StatusOK,
+			},
+		},
+		{
+			name:  "specification removed",
+			specs: map[string][]byte{"specs/common": common},
+			want: map[string]int{
+				"/aws-service/reference":         http.StatusOK,
+				"/service-consumption/reference": http.StatusNotFound,
+				"/specs/allof/api.json":          http.StatusNotFound,
+			},
+		},
+		{
+			name:  "all specifications removed",
+			specs: map[string][]byte{},
+			want: map[string]int{
+				"/aws-service/reference": http.StatusNotFound,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sd.specs = tt.specs
+			updater.onChange()
+			updater.update()
+
+			for path, code := range tt.want {
+				rec := httptest.NewRecorder()
+				updater.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+				if rec.Code != code {
+					t.Errorf("GET %s = %d, want %d", path, rec.Code, code)
+				}
+			}
+		})
+	}
+}
+
+// failingDiscover fails to serve its specifications, until it is told not to.
+type failingDiscover struct {
+	specsDiscover
+	fail bool
+}
+
+func (fd *failingDiscover) Specs() map[string][]byte {
+	if fd.fail {
+		panic("discovered specifications unavailable")
+	}
+
+	return fd.specs
+}
+
+func TestUpdater_UpdateRetried(t *testing.T) {
+	cfg := config.New()
+
+	cfg.Set(config.DiscoveryEnabled, true)
+	cfg.Set(config.DefaultAssetsDir, "../assets")
+
+	fd := &failingDiscover{specsDiscover: specsDiscover{specs: map[string][]byte{"specs/common": specToByteSlice("../fixtures/common_api.json")}}, fail: true}
+	updater := &Updater{r: newLiveRouter(cfg, fd, mux.NewRouter(), &spec.Suite{})}
+
+	updater.onChange()
+	updater.update()
+
+	// the failed update is retried, though discovery did not change since
+	fd.fail = false
+	updater.update()
+
+	rec := httptest.NewRecorder()
+	updater.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/aws-service/reference", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /aws-service/reference = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestUpdater_UpdateRoutes(t *testing.T) {
+	cfg := config.New()
+
+	cfg.Set(config.DiscoveryEnabled, true)
+	cfg.Set(config.DefaultAssetsDir, "../assets")
+
+	sd := &specsDiscover{specs: map[string][]byte{"specs/common": specToByteSlice("../fixtures/common_api.json")}}
+	updater := &Updater{r: newLiveRouter(cfg, sd, mux.NewRouter(), &spec.Suite{})}
+
+	countRoutes := func() int {
+		count := 0
+		_ = updater.r.router.Walk(func(*mux.Route, *mux.Router, []*mux.Route) error {
+			count++
+
+			return nil
+		})
+
+		return count
+	}
+
+	updater.onChange()
+	updater.update()
+
+	want := countRoutes()
+
+	// Routes were registered again on the same router on every update.
+	for i := 0; i < 20; i++ {
+		updater.onChange()
+		updater.update()
+	}
+
+	if got := countRoutes(); got != want {
+		t.Errorf("routes after repeated updates = %d, want %d", got, want)
+	}
+}
+
+func TestUpdater_UpdateMemory(t *testing.T) {
+	cfg := config.New()
+
+	cfg.Set(config.DiscoveryEnabled, true)
+	cfg.Set(config.DefaultAssetsDir, "../assets")
+
+	common := specToByteSlice("../fixtures/common_api.json")
+	allof := specToByteSlice("../fixtures/allof_api.json")
+
+	sd := &specsDiscover{}
+	updater := &Updater{r: newLiveRouter(cfg, sd, mux.NewRouter(), &spec.Suite{})}
+
+	// Each update discovers a service that was not discovered before, in place of the previous one.
+	discoverService := func(i int) {
+		sd.specs = map[string][]byte{
+			"specs/common":                   common,
+			fmt.Sprintf("specs/allof-%d", i): allof,
+		}
+
+		updater.onChange()
+		updater.update()
+	}
+
+	heapAlloc := func() uint64 {
+		runtime.GC()
+
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+
+		return stats.HeapAlloc
+	}
+
+	const warmup, updates = 5, 25
+
+	for i := 0; i < warmup; i++ {
+		discoverService(i)
+	}
+
+	before := heapAlloc()
+
+	for i := warmup; i < updates; i++ {
+		discoverService(i)
+	}
+
+	// Each router left behind by an update would hold several megabytes.
+	if after := heapAlloc(); after > before+before/2 {
+		t.Errorf("heap grew from %d to %d bytes over %d updates", before, after, updates-warmup)
+	}
+
+	for path, code := range map[string]int{
+		"/aws-service/reference":                           http.StatusOK,
+		"/service-consumption/reference":                   http.StatusOK,
+		fmt.Sprintf("/specs/allof-%d/api.json", updates-1): http.StatusOK,
+		"/specs/allof-0/api.json":                          http.StatusNotFound,
+		fmt.Sprintf("/specs/allof-%d/api.json", updates-2): http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		updater.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if rec.Code != code {
+			t.Errorf("GET %s = %d, want %d", path, rec.Code, code)
+		}
+	}
+}
+
+func TestUpdater_ServeWhileUpdating(t *testing.T) {
+	cfg := config.New()
+
+	cfg.Set(config.DiscoveryEnabled, true)
+	cfg.Set(config.DefaultAssetsDir, "../assets")
+
+	common := specToByteSlice("../fixtures/common_api.json")
+	allof := specToByteSlice("../fixtures/allof_api.json")
+
+	sd := &specsDiscover{specs: map[string][]byte{"specs/common": common, "specs/allof": allof}}
+	updater := &Updater{r: newLiveRouter(cfg, sd, mux.NewRouter(), &spec.Suite{})}
+
+	updater.onChange()
+	updater.update()
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 5; i++ {
+			updater.onChange()
+			updater.update()
+		}
+	}()
+
+	// Pages keep being served from the previous documentation while the next one is built.
+	for _, path := range []string{"/", "/aws-service/reference", "/service-consumption/reference", "/guides"} {
+		for i := 0; i < 5; i++ {
+			rec := httptest.NewRecorder()
+			updater.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+			if rec.Code >= http.StatusInternalServerError {
+				t.Errorf("GET %s = %d", path, rec.Code)
+			}
+		}
+	}
+
+	<-done
+}
+
+func TestUpdater_Registry(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "../fixtures/common_api.json")
+	}))
+	defer api.Close()
+
+	file := filepath.Join(t.TempDir(), "registry.yaml")
+	if err := os.WriteFile(file, []byte("services:\n  - name: common\n    spec: "+api.URL+"/swagger.json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the default configuration, without rewrites
+	cfg := config.New()
+
+	cfg.Set(config.DiscoveryEnabled, true)
+	cfg.Set(config.DefaultAssetsDir, "../assets")
+	cfg.Set(config.DiscoveryBackend, discover.BackendRegistry)
+	cfg.Set(config.DiscoveryRegistryFile, file)
+
+	dm, err := discover.NewDiscoverer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go dm.Run()
+	defer dm.Shutdown()
+
+	for deadline := time.Now().Add(5 * time.Second); len(dm.Specs()) == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	updater := &Updater{r: newLiveRouter(cfg, dm, mux.NewRouter(), &spec.Suite{})}
+
+	updater.onChange()
+	updater.update()
+
+	for path, want := range map[string]string{"/common/api.json": `"title": "AWS Service"`, "/aws-service/reference": "AWS Service"} {
+		rec := httptest.NewRecorder()
+		updater.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("GET %s = %d, want %d with %s", path, rec.Code, http.StatusOK, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package i18n
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Catalog holds the messages of a locale, keyed by their ID.
+type Catalog map[string]string
+
+// Message returns the message of an ID, formatted with args as by fmt.Sprintf when there are any. The ID
+// itself is returned when the catalog has no such message.
+func (c Catalog) Message(id string, args ...interface{}) string {
+	msg, ok := c[id]
+	if !ok {
+		msg = id
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+
+	return fmt.Sprintf(msg, args...)
+}
+
+// Load reads the messages of a CSV file into the catalog, replacing those of the same ID. Each line gives
+// the ID of a message, a comma and the message, which may contain further commas. Blank lines and lines
+// starting with # are skipped.
+func (c Catalog) Load(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		i := strings.IndexByte(line, ',')
+		if i <= 0 {
+			log().Errorf("Invalid message at line %d of %s: %q", n, file, line)
+
+			continue
+		}
+
+		c[line[:i]] = line[i+1:]
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,101 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"golang.org/x/text/language"
+)
+
+// Extension is the vendor extension of the objects of a specification giving their localized fields, such
+// as their title, summary or description, keyed by locale:
+//
+//	"description": "Lists the bookings",
+//	"x-i18n": {
+//	  "fr": {"description": "Liste les réservations"}
+//	}
+const Extension = "x-i18n"
+
+// SourceExtension is the vendor extension LocalizeDocument records the fields it replaced in, as they were
+// in the default locale, so that the IDs derived from them are the same in every locale.
+const SourceExtension = "x-i18n-source"
+
+// LocalizeDocument returns a JSON specification with the fields of each object replaced by those given by
+// its x-i18n extension for locale, or else for the base language of locale, such as fr for fr-CA. Objects
+// keep the fields of the default locale when they have none for locale. The extensions are removed, so
+// that the document is the same whatever locales it was localized to, and the fields replaced are kept in
+// an x-i18n-source extension. Documents without extensions, or that are not JSON, are returned unchanged.
+func LocalizeDocument(raw []byte, locale string) []byte {
+	if !bytes.Contains(raw, []byte(`"`+Extension+`"`)) {
+		return raw
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber() // keep the numbers, such as examples, as written
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		log().Debugf("Failed to decode the specification to localize: %s", err)
+
+		return raw
+	}
+
+	localize(doc, candidates(locale))
+
+	localized, err := json.Marshal(doc)
+	if err != nil {
+		log().Errorf("Failed to encode the localized specification: %s", err)
+
+		return raw
+	}
+
+	return localized
+}
+
+// candidates returns the locales whose fields localize objects to locale, in increasing priority.
+func candidates(locale string) []string {
+	if locale == "" {
+		return nil
+	}
+
+	base, _ := language.Make(locale).Base()
+	if base.String() == locale {
+		return []string{locale}
+	}
+
+	return []string{base.String(), locale}
+}
+
+func localize(node interface{}, locales []string) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if translations, ok := n[Extension].(map[string]interface{}); ok {
+			delete(n, Extension)
+
+			source := make(map[string]interface{})
+
+			for _, locale := range locales {
+				fields, _ := translations[locale].(map[string]interface{})
+				for k, v := range fields {
+					if _, ok := source[k]; !ok {
+						source[k] = n[k] // a field the default locale does not have is recorded as null
+					}
+
+					n[k] = v
+				}
+			}
+
+			if len(source) > 0 {
+				n[SourceExtension] = source
+			}
+		}
+
+		for _, child := range n {
+			localize(child, locales)
+		}
+	case []interface{}:
+		for _, child := range n {
+			localize(child, locales)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Package i18n localizes the documentation: it selects the locale of each request, holds the catalogs of
+// the messages of the themes, and localizes specifications by their x-i18n extensions.
+package i18n
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/spf13/viper"
+	"golang.org/x/text/language"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+)
+
+// CookieName is the cookie holding the locale chosen by a reader.
+const CookieName = "locale"
+
+// Locales returns the locales the documentation is served in, the first being the default locale. When
+// none are configured, the documentation is served in the single, unnamed, locale of the theme.
+func Locales(cfg *viper.Viper) []string {
+	var locales []string
+
+	seen := make(map[string]bool)
+
+	// Locales given by the environment are separated by commas or spaces.
+	for _, value := range cfg.GetStringSlice(config.Locales) {
+		for _, locale := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if !seen[locale] {
+				seen[locale] = true
+				locales = append(locales, locale)
+			}
+		}
+	}
+
+	if len(locales) == 0 {
+		return []string{""}
+	}
+
+	return locales
+}
+
+// Negotiator selects the locale of the requests among those the documentation is served in.
+type Negotiator struct {
+	locales []string
+	matcher language.Matcher
+}
+
+// NewNegotiator creates a Negotiator selecting among locales, the first being the default locale.
+func NewNegotiator(locales []string) *Negotiator {
+	tags := make([]language.Tag, len(locales))
+	for i, locale := range locales {
+		tags[i] = language.Make(locale)
+	}
+
+	return &Negotiator{locales: locales, matcher: language.NewMatcher(tags)}
+}
+
+// Locales returns the locales negotiated, the first being the default locale.
+func (n *Negotiator) Locales() []string {
+	return n.locales
+}
+
+// Locale returns the locale of a request along with the path of the page requested. A locale prefixing
+// the path, such as /fr/guides, selects it, the path being stripped of the prefix; otherwise the locale
+// is that of the cookie, or else the best match of the Accept-Language header, or else the default.
+func (n *Negotiator) Locale(req *http.Request) (locale, path string, prefixed bool) {
+	for _, locale := range n.locales {
+		prefix := "/" + locale
+
+		switch {
+		case locale == "":
+		case req.URL.Path == prefix:
+			return locale, "/", true
+		case strings.HasPrefix(req.URL.Path, prefix+"/"):
+			return locale, strings.TrimPrefix(req.URL.Path, prefix), true
+		}
+	}
+
+	if cookie, err := req.Cookie(CookieName); err == nil {
+		for _, locale := range n.locales {
+			if cookie.Value == locale {
+				return locale, req.URL.Path, false
+			}
+		}
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(req.Header.Get("Accept-Language"))
+	if err != nil {
+		log().Debugf("Invalid Accept-Language header %q: %s", req.Header.Get("Accept-Language"), err)
+	}
+
+	_, i, _ := n.matcher.Match(tags...)
+
+	return n.locales[i], req.URL.Path, false
+}
@@ -0,0 +1,238 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+)
+
+func TestLocales(t *testing.T) {
+	tests := []struct {
+		name    string
+		locales []string
+		want    []string
+	}{
+		{
+			name: "none",
+			want: []string{""},
+		},
+		{
+			name:    "flags",
+			locales: []string{"en", "fr", "de"},
+			want:    []string{"en", "fr", "de"},
+		},
+		{
+			name:    "environment",
+			locales: []string{"en, fr de", "fr"},
+			want:    []string{"en", "fr", "de"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Set(config.Locales, tt.locales)
+
+			if got := Locales(cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Locales() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiator_Locale(t *testing.T) {
+	n := NewNegotiator([]string{"en", "fr", "de"})
+
+	tests := []struct {
+		name           string
+		target         string
+		cookie         string
+		acceptLanguage string
+		wantLocale     string
+		wantPath       string
+		wantPrefixed   bool
+	}{
+		{
+			name:       "default",
+			target:     "/bookings/reference",
+			wantLocale: "en",
+			wantPath:   "/bookings/reference",
+		},
+		{
+			name:         "prefix",
+			target:       "/fr/bookings/reference",
+			cookie:       "de",
+			wantLocale:   "fr",
+			wantPath:     "/bookings/reference",
+			wantPrefixed: true,
+		},
+		{
+			name:         "prefix only",
+			target:       "/de",
+			wantLocale:   "de",
+			wantPath:     "/",
+			wantPrefixed: true,
+		},
+		{
+			name:       "not a prefix",
+			target:     "/france/reference",
+			wantLocale: "en",
+			wantPath:   "/france/reference",
+		},
+		{
+			name:           "cookie",
+			target:         "/guides/welcome",
+			cookie:         "de",
+			acceptLanguage: "fr",
+			wantLocale:     "de",
+			wantPath:       "/guides/welcome",
+		},
+		{
+			name:           "unknown cookie",
+			target:         "/guides/welcome",
+			cookie:         "it",
+			acceptLanguage: "fr",
+			wantLocale:     "fr",
+			wantPath:       "/guides/welcome",
+		},
+		{
+			name:           "accept language",
+			target:         "/guides/welcome",
+			acceptLanguage: "it, fr-CA;q=0.8, en;q=0.5",
+			wantLocale:     "fr",
+			wantPath:       "/guides/welcome",
+		},
+		{
+			name:           "invalid accept language",
+			target:         "/guides/welcome",
+			acceptLanguage: "=;",
+			wantLocale:     "en",
+			wantPath:       "/guides/welcome",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: CookieName, Value: tt.cookie})
+			}
+
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+
+			locale, path, prefixed := n.Locale(req)
+			if locale != tt.wantLocale || path != tt.wantPath || prefixed != tt.wantPrefixed {
+				t.Errorf("Locale() = %q, %q, %v, want %q, %q, %v", locale, path, prefixed, tt.wantLocale, tt.wantPath, tt.wantPrefixed)
+			}
+		})
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "messages.csv")
+
+	content := "# Messages\n\ntitle.search,Search\nreference.title,%s reference\nmethod.response_codes,Codes, with a resource\ninvalid\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Catalog{"title.search": "Recherche", "nav.summary": "Summary"}
+	if err := c.Load(file); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		id   string
+		args []interface{}
+		want string
+	}{
+		{id: "title.search", want: "Search"},
+		{id: "nav.summary", want: "Summary"},
+		{id: "reference.title", args: []interface{}{"Bookings"}, want: "Bookings reference"},
+		{id: "method.response_codes", want: "Codes, with a resource"},
+		{id: "missing.message", want: "missing.message"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := c.Message(tt.id, tt.args...); got != tt.want {
+				t.Errorf("Message() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if err := c.Load(filepath.Join(t.TempDir(), "missing.csv")); !os.IsNotExist(err) {
+		t.Errorf("Load() error = %v, want not exist", err)
+	}
+}
+
+func TestLocalizeDocument(t *testing.T) {
+	raw := []byte(`{
+  "info": {
+    "title": "Rooms",
+    "version": "1.0.0",
+    "x-i18n": {"fr": {"title": "Salles"}, "fr-CA": {"description": "Les salles"}}
+  },
+  "paths": {
+    "/rooms": {
+      "get": {
+        "summary": "List rooms",
+        "x-i18n": {"de": {"summary": "Räume auflisten"}},
+        "responses": {"200": {"description": "OK", "examples": {"application/json": {"size": 12.50}}}}
+      }
+    }
+  }
+}`)
+
+	tests := []struct {
+		name   string
+		raw    []byte
+		locale string
+		want   string
+	}{
+		{
+			name:   "default locale",
+			raw:    raw,
+			locale: "",
+			want: `{"info":{"title":"Rooms","version":"1.0.0"},` +
+				`"paths":{"/rooms":{"get":{"responses":{"200":{"description":"OK","examples":{"application/json":{"size":12.50}}}},"summary":"List rooms"}}}}`,
+		},
+		{
+			name:   "locale",
+			raw:    raw,
+			locale: "de",
+			want: `{"info":{"title":"Rooms","version":"1.0.0"},` +
+				`"paths":{"/rooms":{"get":{"responses":{"200":{"description":"OK","examples":{"application/json":{"size":12.50}}}},"summary":"Räume auflisten","x-i18n-source":{"summary":"List rooms"}}}}}`,
+		},
+		{
+			name:   "base language",
+			raw:    raw,
+			locale: "fr-CA",
+			want: `{"info":{"description":"Les salles","title":"Salles","version":"1.0.0","x-i18n-source":{"description":null,"title":"Rooms"}},` +
+				`"paths":{"/rooms":{"get":{"responses":{"200":{"description":"OK","examples":{"application/json":{"size":12.50}}}},"summary":"List rooms"}}}}`,
+		},
+		{
+			name:   "no extensions",
+			raw:    []byte(`{"info": {"title": "Rooms"}}`),
+			locale: "fr",
+			want:   `{"info": {"title": "Rooms"}}`,
+		},
+		{
+			name:   "YAML",
+			raw:    []byte("info:\n  title: Rooms\n  x-i18n:\n    fr:\n      title: Salles\n"),
+			locale: "fr",
+			want:   "info:\n  title: Rooms\n  x-i18n:\n    fr:\n      title: Salles\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LocalizeDocument(tt.raw, tt.locale); string(got) != tt.want {
+				t.Errorf("LocalizeDocument() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
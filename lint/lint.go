@@ -0,0 +1,185 @@
+// Package lint provides checks reporting problems in API specifications without serving them.
+package lint
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	wraperrors "github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// Severity of a Finding.
+type Severity string
+
+// all defined Severity levels.
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+)
+
+// Finding is a problem reported by a Rule.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Spec     string   `json:"spec"`
+	Pointer  string   `json:"pointer,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Rule checks a specification for one kind of problem.
+type Rule interface {
+	Name() string
+	Description() string
+	Check(s *spec.APISpecification) []Finding
+}
+
+var rules = make(map[string]Rule)
+
+// Register adds a rule to the rule set, replacing any registered rule of the same name.
+func Register(r Rule) {
+	rules[r.Name()] = r
+}
+
+// Rules returns the registered rules, ordered by name.
+func Rules() []Rule {
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	list := make([]Rule, 0, len(names))
+	for _, name := range names {
+		list = append(list, rules[name])
+	}
+
+	return list
+}
+
+// EnabledRules returns the rules enabled by cfg, all registered rules if none are configured.
+func EnabledRules(cfg *viper.Viper) ([]Rule, error) {
+	names := cfg.GetStringSlice(config.LintRules)
+	if len(names) == 0 {
+		return Rules(), nil
+	}
+
+	enabled := make([]Rule, 0, len(names))
+
+	for _, name := range names {
+		r, ok := rules[name]
+		if !ok {
+			return nil, wraperrors.Errorf("unknown lint rule %q", name)
+		}
+
+		enabled = append(enabled, r)
+	}
+
+	return enabled, nil
+}
+
+// Check runs the rules against the specifications, returning the findings ordered by
+// specification, JSON pointer and rule.
+func Check(specs map[string]*spec.APISpecification, enabled []Rule) []Finding {
+	var findings []Finding
+
+	for _, s := range specs {
+		for _, r := range enabled {
+			findings = append(findings, r.Check(s)...)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Spec != b.Spec {
+			return a.Spec < b.Spec
+		}
+
+		if a.Pointer != b.Pointer {
+			return a.Pointer < b.Pointer
+		}
+
+		return a.Rule < b.Rule
+	})
+
+	return findings
+}
+
+// Command loads the specifications configured by cfg through the spec package, as the server does,
+// and writes the findings of the enabled rules in the configured format. It returns the
+// process exit code: 0 if no errors were found, 1 if any were, and 2 if linting was not possible.
+func Command(cfg *viper.Viper, w io.Writer) int {
+	if cfg.GetBool(config.DiscoveryEnabled) {
+		log().Error("lint checks the specifications of the spec-dir, discovery must not be enabled")
+
+		return 2
+	}
+
+	enabled, err := EnabledRules(cfg)
+	if err != nil {
+		log().Error(err)
+
+		return 2
+	}
+
+	writer, ok := writers[cfg.GetString(config.LintFormat)]
+	if !ok {
+		log().Errorf("unknown lint format %q", cfg.GetString(config.LintFormat))
+
+		return 2
+	}
+
+	suite, err := spec.LoadSpecifications(cfg, nil)
+	if err != nil {
+		log().Errorf("unable to load specifications: %s", err)
+
+		return 2
+	}
+
+	report := newReport(suite.Specs, enabled, Check(suite.Specs, enabled))
+
+	if err := writer(w, report); err != nil {
+		log().Errorf("unable to write lint report: %s", err)
+
+		return 2
+	}
+
+	if report.Errors > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// rule is a Rule whose findings all have the same severity.
+type rule struct {
+	name        string
+	description string
+	severity    Severity
+	check       func(s *spec.APISpecification, report func(pointer, format string, args ...interface{}))
+}
+
+func (r *rule) Name() string { return r.name }
+
+func (r *rule) Description() string { return r.description }
+
+func (r *rule) Check(s *spec.APISpecification) []Finding {
+	var findings []Finding
+
+	r.check(s, func(pointer, format string, args ...interface{}) {
+		findings = append(findings, Finding{
+			Rule:     r.name,
+			Severity: r.severity,
+			Spec:     s.URL,
+			Pointer:  pointer,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	})
+
+	return findings
+}
@@ -0,0 +1,259 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+const testSpecDir = "../fixtures/"
+
+func TestCheck(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.SpecDir, testSpecDir)
+	cfg.Set(config.SpecFilename, []string{"lint_api.json", "invalid_api.json"})
+
+	suite, err := spec.LoadSpecifications(cfg, nil)
+	if err != nil {
+		t.Fatalf("LoadSpecifications() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		rule string
+		want []string
+	}{
+		{
+			name: "load errors",
+			rule: "spec-load",
+			want: []string{
+				"/invalid_api.json#/info/title",
+				"/invalid_api.json#/paths/~1things/get",
+				"/invalid_api.json#/paths/~1things/post/parameters/0",
+				"/invalid_api.json#/paths/~1things/post/responses/201/schema",
+				"/invalid_api.json#/paths/~1things~1{id}/delete",
+			},
+		},
+		{
+			name: "operations without operationId",
+			rule: "missing-operation-id",
+			want: []string{
+				"/invalid_api.json#/paths/~1things/get",
+				"/lint_api.json#/paths/~1things~1{id}/delete",
+			},
+		},
+		{
+			name: "operations without summary",
+			rule: "missing-summary",
+			want: []string{
+				"/invalid_api.json#/paths/~1things/get",
+			},
+		},
+		{
+			name: "definitions without title",
+			rule: "untitled-definition",
+			want: []string{
+				"/lint_api.json#/definitions/Owner",
+			},
+		},
+		{
+			name: "unknown sort keys",
+			rule: "unknown-sort-methods-by",
+			want: []string{
+				"/lint_api.json#/x-sortMethodsBy/1",
+			},
+		},
+		{
+			name: "duplicate method IDs",
+			rule: "duplicate-method-id",
+			want: []string{
+				"/lint_api.json#/paths/~1things~1{id}/get",
+			},
+		},
+		{
+			name: "unused definitions",
+			rule: "unused-definition",
+			want: []string{
+				"/lint_api.json#/definitions/Leftover",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := rules[tt.rule]
+			if !ok {
+				t.Fatalf("rule %s not registered", tt.rule)
+			}
+
+			var got []string
+			for _, f := range Check(suite.Specs, []Rule{r}) {
+				got = append(got, f.Spec+"#"+f.Pointer)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnabledRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "all rules by default",
+			want: len(rules),
+		},
+		{
+			name:  "configured rules",
+			rules: []string{"missing-summary", "unused-definition"},
+			want:  2,
+		},
+		{
+			name:    "unknown rule",
+			rules:   []string{"no-such-rule"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Set(config.LintRules, tt.rules)
+
+			got, err := EnabledRules(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EnabledRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(got) != tt.want {
+				t.Errorf("EnabledRules() = %d rules, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		specs     []string
+		rules     []string
+		format    string
+		discovery bool
+		want      int
+		check     func(t *testing.T, out []byte)
+	}{
+		{
+			name:   "text report with errors",
+			specs:  []string{"lint_api.json"},
+			format: "text",
+			want:   1,
+			check: func(t *testing.T, out []byte) {
+				if !strings.Contains(string(out), "1 specification(s) checked, 3 error(s), 2 warning(s)") {
+					t.Errorf("output = %s, want summary", out)
+				}
+			},
+		},
+		{
+			name:   "json report with warnings only",
+			specs:  []string{"lint_api.json"},
+			rules:  []string{"missing-operation-id", "unused-definition"},
+			format: "json",
+			want:   0,
+			check: func(t *testing.T, out []byte) {
+				var r Report
+				if err := json.Unmarshal(out, &r); err != nil {
+					t.Fatalf("invalid JSON output: %v", err)
+				}
+
+				if r.Errors != 0 || r.Warnings != 2 || len(r.Findings) != 2 {
+					t.Errorf("report = %+v, want 2 warnings", r)
+				}
+			},
+		},
+		{
+			name:   "junit report",
+			specs:  []string{"lint_api.json", "common_api.json"},
+			rules:  []string{"untitled-definition", "missing-summary"},
+			format: "junit",
+			want:   1,
+			check: func(t *testing.T, out []byte) {
+				var suites junitTestSuites
+				if err := xml.Unmarshal(out, &suites); err != nil {
+					t.Fatalf("invalid XML output: %v", err)
+				}
+
+				if len(suites.Suites) != 2 {
+					t.Fatalf("test suites = %d, want 2", len(suites.Suites))
+				}
+
+				failures := 0
+				for _, s := range suites.Suites {
+					failures += s.Failures
+
+					if s.Tests != 2 {
+						t.Errorf("test suite %s tests = %d, want 2", s.Name, s.Tests)
+					}
+				}
+
+				if failures != 1 {
+					t.Errorf("failures = %d, want 1", failures)
+				}
+			},
+		},
+		{
+			name:   "clean specification",
+			specs:  []string{"common_api.json"},
+			rules:  []string{"spec-load", "duplicate-method-id"},
+			format: "text",
+			want:   0,
+		},
+		{
+			name:   "unknown format",
+			specs:  []string{"lint_api.json"},
+			format: "html",
+			want:   2,
+		},
+		{
+			name:   "unknown rule",
+			specs:  []string{"lint_api.json"},
+			rules:  []string{"no-such-rule"},
+			format: "text",
+			want:   2,
+		},
+		{
+			name:      "discovery enabled",
+			format:    "text",
+			discovery: true,
+			want:      2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Set(config.SpecDir, testSpecDir)
+			cfg.Set(config.SpecFilename, tt.specs)
+			cfg.Set(config.LintRules, tt.rules)
+			cfg.Set(config.LintFormat, tt.format)
+			cfg.Set(config.DiscoveryEnabled, tt.discovery)
+
+			var out bytes.Buffer
+			if got := Command(cfg, &out); got != tt.want {
+				t.Errorf("Command() = %d, want %d\n%s", got, tt.want, out.String())
+			}
+
+			if tt.check != nil {
+				tt.check(t, out.Bytes())
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package lint
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// writers holds the report writer of each output format.
+var writers = map[string]func(w io.Writer, r *Report) error{
+	"text":  writeText,
+	"json":  writeJSON,
+	"junit": writeJUnit,
+}
+
+// Report holds the findings of a lint run.
+type Report struct {
+	Specs    []string  `json:"specs"`
+	Rules    []string  `json:"rules"`
+	Findings []Finding `json:"findings"`
+	Errors   int       `json:"errors"`
+	Warnings int       `json:"warnings"`
+}
+
+func newReport(specs map[string]*spec.APISpecification, enabled []Rule, findings []Finding) *Report {
+	r := &Report{
+		Findings: findings,
+	}
+
+	if r.Findings == nil {
+		r.Findings = []Finding{}
+	}
+
+	for _, s := range specs {
+		r.Specs = append(r.Specs, s.URL)
+	}
+
+	sort.Strings(r.Specs)
+
+	for _, rule := range enabled {
+		r.Rules = append(r.Rules, rule.Name())
+	}
+
+	for _, f := range findings {
+		if f.Severity == Error {
+			r.Errors++
+		} else {
+			r.Warnings++
+		}
+	}
+
+	return r
+}
+
+func writeText(w io.Writer, r *Report) error {
+	for _, f := range r.Findings {
+		if _, err := fmt.Fprintf(w, "%s#%s: %s: %s (%s)\n", f.Spec, f.Pointer, f.Severity, f.Message, f.Rule); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d specification(s) checked, %d error(s), %d warning(s)\n", len(r.Specs), r.Errors, r.Warnings)
+
+	return err
+}
+
+func writeJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+// writeJUnit reports each rule as a test case of the test suite of each specification.
+// Errors fail the test case, while warnings are only listed in its output.
+func writeJUnit(w io.Writer, r *Report) error {
+	suites := junitTestSuites{}
+
+	for _, s := range r.Specs {
+		suite := junitTestSuite{Name: s}
+
+		for _, rule := range r.Rules {
+			tc := junitTestCase{ClassName: s, Name: rule}
+
+			var errs, warnings []string
+
+			for _, f := range r.Findings {
+				if f.Spec != s || f.Rule != rule {
+					continue
+				}
+
+				line := fmt.Sprintf("#%s: %s", f.Pointer, f.Message)
+				if f.Severity == Error {
+					errs = append(errs, line)
+				} else {
+					warnings = append(warnings, line)
+				}
+			}
+
+			if len(errs) > 0 {
+				tc.Failure = &junitFailure{
+					Message: fmt.Sprintf("%d error(s)", len(errs)),
+					Type:    string(Error),
+					Content: strings.Join(errs, "\n"),
+				}
+				suite.Failures++
+			}
+
+			tc.SystemOut = strings.Join(warnings, "\n")
+
+			suite.Cases = append(suite.Cases, tc)
+			suite.Tests++
+		}
+
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
@@ -0,0 +1,241 @@
+package lint
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	gospec "github.com/go-openapi/spec"
+
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+func init() {
+	Register(&rule{
+		name:        "spec-load",
+		description: "The specification must load without errors.",
+		severity:    Error,
+		check:       checkLoad,
+	})
+	Register(&rule{
+		name:        "missing-operation-id",
+		description: "Operations should declare an operationId.",
+		severity:    Warning,
+		check:       checkOperationID,
+	})
+	Register(&rule{
+		name:        "missing-summary",
+		description: "Operations should declare a summary.",
+		severity:    Warning,
+		check:       checkSummary,
+	})
+	Register(&rule{
+		name:        "untitled-definition",
+		description: "Definitions must declare a title, which identifies the resource they document.",
+		severity:    Error,
+		check:       checkDefinitionTitle,
+	})
+	Register(&rule{
+		name:        "unknown-sort-methods-by",
+		description: "The x-sortMethodsBy extension must only list known sort keys.",
+		severity:    Error,
+		check:       checkSortMethodsBy,
+	})
+	Register(&rule{
+		name:        "duplicate-method-id",
+		description: "Methods of an API group must have distinct IDs.",
+		severity:    Error,
+		check:       checkDuplicateMethodID,
+	})
+	Register(&rule{
+		name:        "unused-definition",
+		description: "Definitions should be referenced by the specification.",
+		severity:    Warning,
+		check:       checkUnusedDefinition,
+	})
+}
+
+type operation struct {
+	pointer string
+	op      *gospec.Operation
+}
+
+func checkLoad(s *spec.APISpecification, report func(pointer, format string, args ...interface{})) {
+	for _, err := range s.Errors {
+		report(err.Pointer, "%s", err.Message)
+	}
+}
+
+func checkOperationID(s *spec.APISpecification, report func(pointer, format string, args ...interface{})) {
+	for _, o := range operations(s.Swagger()) {
+		if o.op.ID == "" {
+			report(o.pointer, "operation does not have an operationId member")
+		}
+	}
+}
+
+func checkSummary(s *spec.APISpecification, report func(pointer, format string, args ...interface{})) {
+	for _, o := range operations(s.Swagger()) {
+		if o.op.Summary == "" {
+			report(o.pointer, "operation does not have a summary member")
+		}
+	}
+}
+
+func checkDefinitionTitle(s *spec.APISpecification, report func(pointer, format string, args ...interface{})) {
+	doc := s.Swagger()
+	if doc == nil {
+		return
+	}
+
+	for _, name := range definitionNames(doc) {
+		if doc.Definitions[name].Title == "" {
+			report(spec.JSONPointer("definitions", name), "definition %s does not have a title member", name)
+		}
+	}
+}
+
+func checkSortMethodsBy(s *spec.APISpecification, report func(pointer, format string, args ...interface{})) {
+	doc := s.Swagger()
+	if doc == nil {
+		return
+	}
+
+	values, ok := doc.Extensions[spec.SortMethodsByExt].([]interface{})
+	if !ok {
+		return
+	}
+
+	known := spec.SortMethodsByValues()
+
+	for i, v := range values {
+		value, _ := v.(string)
+		if idx := sort.SearchStrings(known, value); idx == len(known) || known[idx] != value {
+			report(spec.JSONPointer(spec.SortMethodsByExt, strconv.Itoa(i)), "unknown sort key %q, expected one of %s", value, strings.Join(known, ", "))
+		}
+	}
+}
+
+func checkDuplicateMethodID(s *spec.APISpecification, report func(pointer, format string, args ...interface{})) {
+	for _, api := range s.APIs {
+		byID := make(map[string][]spec.Method)
+		for _, m := range api.Methods {
+			byID[m.ID] = append(byID[m.ID], m)
+		}
+
+		for id, methods := range byID {
+			sort.Slice(methods, func(i, j int) bool { return methods[i].Pointer < methods[j].Pointer })
+
+			first := methods[0]
+			for _, m := range methods[1:] {
+				report(m.Pointer, "method ID %q of %s %s duplicates that of %s %s in API group %q",
+					id, strings.ToUpper(m.Method), m.Path, strings.ToUpper(first.Method), first.Path, api.Name)
+			}
+		}
+	}
+}
+
+func checkUnusedDefinition(s *spec.APISpecification, report func(pointer, format string, args ...interface{})) {
+	doc := s.Swagger()
+	if doc == nil {
+		return
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return
+	}
+
+	used := make(map[string]bool)
+	collectReferences(v, used)
+
+	for _, name := range definitionNames(doc) {
+		if !used[name] {
+			report(spec.JSONPointer("definitions", name), "definition %s is not referenced", name)
+		}
+	}
+}
+
+// collectReferences records the names of the definitions referenced anywhere within a document,
+// including by a definition other than the referenced definition itself.
+func collectReferences(v interface{}, used map[string]bool) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if defs, ok := val["definitions"].(map[string]interface{}); ok {
+			for name, def := range defs {
+				refs := make(map[string]bool)
+				collectReferences(def, refs)
+				delete(refs, name)
+
+				for ref := range refs {
+					used[ref] = true
+				}
+			}
+		}
+
+		for k, member := range val {
+			if k != "definitions" {
+				collectReferences(member, used)
+			}
+		}
+	case []interface{}:
+		for _, member := range val {
+			collectReferences(member, used)
+		}
+	case string:
+		if strings.HasPrefix(val, spec.DefinitionsRef) {
+			used[strings.TrimPrefix(val, spec.DefinitionsRef)] = true
+		}
+	}
+}
+
+// operations returns the operations of a specification ordered by path and method.
+func operations(doc *gospec.Swagger) []operation {
+	if doc == nil || doc.Paths == nil {
+		return nil
+	}
+
+	paths := make([]string, 0, len(doc.Paths.Paths))
+	for path := range doc.Paths.Paths {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	var ops []operation
+
+	for _, path := range paths {
+		item := doc.Paths.Paths[path]
+
+		for _, o := range []struct {
+			method string
+			op     *gospec.Operation
+		}{
+			{"get", item.Get}, {"put", item.Put}, {"post", item.Post}, {"delete", item.Delete},
+			{"options", item.Options}, {"head", item.Head}, {"patch", item.Patch},
+		} {
+			if o.op != nil {
+				ops = append(ops, operation{pointer: spec.JSONPointer("paths", path, o.method), op: o.op})
+			}
+		}
+	}
+
+	return ops
+}
+
+func definitionNames(doc *gospec.Swagger) []string {
+	names := make([]string, 0, len(doc.Definitions))
+	for name := range doc.Definitions {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
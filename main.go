@@ -28,8 +28,10 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/kenjones-cisco/dapperdox/config"
-	"github.com/kenjones-cisco/dapperdox/discover"
-	"github.com/kenjones-cisco/dapperdox/handlers"
+	"github.com/kenjones-cisco/dapperdox/dapperdox"
+	"github.com/kenjones-cisco/dapperdox/diff"
+	"github.com/kenjones-cisco/dapperdox/export"
+	"github.com/kenjones-cisco/dapperdox/lint"
 	log "github.com/kenjones-cisco/dapperdox/logger"
 	"github.com/kenjones-cisco/dapperdox/network"
 	"github.com/kenjones-cisco/dapperdox/version"
@@ -38,11 +40,16 @@ import (
 func main() {
 	pflag.Usage = func() {
 		_, _ = fmt.Fprint(os.Stderr, "Usage:\n")
-		_, _ = fmt.Fprintf(os.Stderr, "  %s [OPTIONS]\n\n", version.ShortName)
+		_, _ = fmt.Fprintf(os.Stderr, "  %s [COMMAND] [OPTIONS]\n\n", version.ShortName)
 		_, _ = fmt.Fprintf(os.Stderr, "%s\n\n", version.ProductName)
+		_, _ = fmt.Fprint(os.Stderr, "Commands:\n")
+		_, _ = fmt.Fprint(os.Stderr, "  lint    Check the specifications for problems, without serving them\n")
+		_, _ = fmt.Fprint(os.Stderr, "  diff    Report the changes between two revisions of a specification: diff OLD NEW\n")
+		_, _ = fmt.Fprint(os.Stderr, "  export  Write the documentation as a static site to the export-dir\n\n")
 		_, _ = fmt.Fprintln(os.Stderr, pflag.CommandLine.FlagUsages())
 	}
 	// parse the CLI flags
+	config.RegisterFlags(pflag.CommandLine)
 	pflag.Parse()
 	_ = viper.BindPFlags(pflag.CommandLine)
 
@@ -53,46 +60,117 @@ func main() {
 
 	config.Init()
 
-	log.SetLevel(viper.GetString(config.LogLevel))
-
-	var chain http.Handler
-
-	if viper.GetBool(config.DiscoveryEnabled) {
-		// initialize auto-discovery instance
-		discoverer, err := discover.NewDiscoverer()
-		if err != nil {
-			log.Logger().Warnf("unable to create Discoverer: %v", err)
-
-			discoverer = discover.NewDefaultDiscoverer()
-		}
-
-		// invoke auto-discovery background process
-		go discoverer.Run()
-
-		// initialize updater instance
-		updater := handlers.NewAutoDiscoverUpdater(discoverer)
-		defer updater.Close()
+	// The commands and the documentation handler all read the configuration set up above.
+	cfg := viper.GetViper()
+
+	log.SetLevel(cfg.GetString(config.LogLevel))
+
+	switch cmd := pflag.Arg(0); cmd {
+	case "":
+	case "lint":
+		os.Exit(lint.Command(cfg, os.Stdout))
+	case "diff":
+		os.Exit(diff.Command(cfg, os.Stdout, pflag.Arg(1), pflag.Arg(2)))
+	case "export":
+		os.Exit(export.Command(cfg, os.Stdout))
+	default:
+		pflag.Usage()
+		log.Logger().Fatalf("Unknown command %q", cmd)
+	}
 
-		chain = updater.Router()
-	} else {
-		chain = handlers.NewRouterChain()
+	handler, err := dapperdox.New(options(cfg))
+	if err != nil {
+		log.Logger().Fatalf("Error creating the documentation handler: %s", err)
 	}
+	defer handler.Close()
 
 	var listener net.Listener
 
-	var err error
-
-	if viper.GetString(config.TLSCert) != "" && viper.GetString(config.TLSKey) != "" {
+	if cfg.GetString(config.TLSCert) != "" && cfg.GetString(config.TLSKey) != "" {
 		listener, err = network.NewSecuredListener()
 	} else {
 		listener, err = network.NewListener()
 	}
 
 	if err != nil {
-		log.Logger().Fatalf("Error listening on %s: %s", viper.GetString(config.BindAddr), err)
+		log.Logger().Fatalf("Error listening on %s: %s", cfg.GetString(config.BindAddr), err)
 	}
 
-	if err = http.Serve(listener, chain); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	if err = http.Serve(listener, handler); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Logger().Fatalf("%v", err)
 	}
 }
+
+// options returns the options of the documentation handler set up in cfg by the command line, environment
+// and configuration file.
+func options(cfg *viper.Viper) dapperdox.Options {
+	return dapperdox.Options{
+		SpecDir:         cfg.GetString(config.SpecDir),
+		SpecFilenames:   cfg.GetStringSlice(config.SpecFilename),
+		SpecDefaultHost: cfg.GetString(config.SpecDefaultHost),
+		SpecRewriteURL:  cfg.GetStringMapString(config.SpecRewriteURL),
+		ForceSpecList:   cfg.GetBool(config.ForceSpecList),
+		Locales:         cfg.GetStringSlice(config.Locales),
+
+		DefaultAssetsDir:   cfg.GetString(config.DefaultAssetsDir),
+		AssetsDir:          cfg.GetString(config.AssetsDir),
+		DocumentRewriteURL: cfg.GetStringMapString(config.DocumentRewriteURL),
+		ShowAssets:         cfg.GetBool(config.ShowAssets),
+
+		Theme:    cfg.GetString(config.Theme),
+		ThemeDir: cfg.GetString(config.ThemeDir),
+
+		SiteURL:        cfg.GetString(config.SiteURL),
+		RobotsDisallow: cfg.GetStringSlice(config.RobotsDisallow),
+		ProxyPaths:     cfg.GetStringMapString(config.ProxyPath),
+		AllowOrigins:   cfg.GetStringSlice(config.AllowOrigin),
+		TLSCertificate: cfg.GetString(config.TLSCert),
+		TLSKey:         cfg.GetString(config.TLSKey),
+
+		Watch:         cfg.GetBool(config.Watch),
+		WatchDebounce: cfg.GetDuration(config.WatchDebounce),
+
+		Discovery: dapperdox.DiscoveryOptions{
+			Enabled:            cfg.GetBool(config.DiscoveryEnabled),
+			Backend:            cfg.GetString(config.DiscoveryBackend),
+			Registry:           cfg.GetString(config.DiscoveryRegistryFile),
+			Namespace:          cfg.GetString(config.DiscoveryNamespace),
+			Namespaces:         cfg.GetStringSlice(config.DiscoveryNamespaces),
+			NamespaceSelector:  cfg.GetString(config.DiscoveryNamespaceSelector),
+			Kubeconfig:         cfg.GetString(config.DiscoveryKubeconfig),
+			Context:            cfg.GetString(config.DiscoveryKubeContext),
+			Clusters:           clusters(cfg),
+			DomainSuffix:       cfg.GetString(config.DiscoverySuffix),
+			Interval:           cfg.GetDuration(config.DiscoveryInterval),
+			SpecLoadTimeout:    cfg.GetDuration(config.DiscoverySpecLoadTimeout),
+			SpecPaths:          cfg.GetStringSlice(config.DiscoverySpecPaths),
+			IgnoreServices:     cfg.GetStringSlice(config.DiscoveryServiceIgnoreList),
+			GroupingKey:        cfg.GetString(config.DiscoveryGroupingKey),
+			GroupingConverters: cfg.GetStringMapString(config.DiscoveryGroupingConverters),
+			SpecGroupings:      cfg.GetStringMapString(config.SpecGroupings),
+			SpecRewrites:       cfg.GetString(config.SpecRewrites),
+			InitialDelay:       cfg.GetDuration(config.DiscoveryInitialDelay),
+			Period:             cfg.GetDuration(config.DiscoveryPeriodTime),
+
+			Consul: dapperdox.ConsulOptions{
+				Address:    cfg.GetString(config.DiscoveryConsulAddress),
+				Token:      cfg.GetString(config.DiscoveryConsulToken),
+				Datacenter: cfg.GetString(config.DiscoveryConsulDatacenter),
+				Tag:        cfg.GetString(config.DiscoveryConsulTag),
+				Meta:       cfg.GetStringMapString(config.DiscoveryConsulMeta),
+				Wait:       cfg.GetDuration(config.DiscoveryConsulWait),
+			},
+		},
+	}
+}
+
+// clusters returns the Kubernetes clusters listed by cfg.
+func clusters(cfg *viper.Viper) []dapperdox.ClusterOptions {
+	var clusters []dapperdox.ClusterOptions
+
+	if err := cfg.UnmarshalKey(config.DiscoveryClusters, &clusters); err != nil {
+		log.Logger().Errorf("Invalid %s: %s", config.DiscoveryClusters, err)
+	}
+
+	return clusters
+}
@@ -0,0 +1,135 @@
+package postman
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kenjones-cisco/dapperdox/codesample"
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// Collection variables holding the credentials of the requests, seeded with placeholders.
+const (
+	apiKeyVariable      = "apiKey"
+	accessTokenVariable = "accessToken"
+	usernameVariable    = "username"
+	passwordVariable    = "password"
+)
+
+// oauth2GrantTypes maps the OpenAPI 2.0 OAuth2 flows onto the grant types of Postman.
+var oauth2GrantTypes = map[string]string{
+	"implicit":    "implicit",
+	"accessCode":  "authorization_code",
+	"password":    "password_credentials",
+	"application": "client_credentials",
+}
+
+// Auth is the authorisation of a request, or of the requests of a collection, by one type of scheme.
+type Auth struct {
+	Type   string          `json:"type"` // Type is apikey, basic, bearer, oauth2 or noauth
+	APIKey []AuthAttribute `json:"apikey,omitempty"`
+	Basic  []AuthAttribute `json:"basic,omitempty"`
+	Bearer []AuthAttribute `json:"bearer,omitempty"`
+	OAuth2 []AuthAttribute `json:"oauth2,omitempty"`
+}
+
+// AuthAttribute is a setting of an Auth.
+type AuthAttribute struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+// auth returns the authorisation by the security schemes required, nil if none are. Postman authorises a
+// request by a single scheme, the first of those required ordered by type.
+func (b *builder) auth(security map[string]spec.Security) *Auth {
+	types := make([]string, 0, len(security))
+	for t := range security {
+		types = append(types, t)
+	}
+
+	sort.Strings(types)
+
+	for _, t := range types {
+		sec := security[t]
+		if sec.Scheme == nil {
+			continue
+		}
+
+		scheme := sec.Scheme
+
+		switch {
+		case scheme.IsAPIKey:
+			key, value, in := scheme.ParamName, b.variable(apiKeyVariable, codesample.APIKeyPlaceholder), scheme.ParamLocation
+			if in == "cookie" {
+				key, value, in = "Cookie", scheme.ParamName+"="+value, "header"
+			}
+
+			return &Auth{Type: "apikey", APIKey: attributes("key", key, "value", value, "in", in)}
+		case scheme.IsBasic:
+			return &Auth{Type: "basic", Basic: attributes(
+				"username", b.variable(usernameVariable, codesample.UsernamePlaceholder),
+				"password", b.variable(passwordVariable, codesample.PasswordPlaceholder),
+			)}
+		case scheme.IsBearer:
+			return &Auth{Type: "bearer", Bearer: attributes(
+				"token", b.variable(accessTokenVariable, codesample.AccessTokenPlaceholder),
+			)}
+		case scheme.IsOAuth2:
+			return &Auth{Type: "oauth2", OAuth2: b.oauth2(scheme, sec.Scopes)}
+		}
+	}
+
+	return nil
+}
+
+// oauth2 returns the settings of an OAuth2 scheme, requesting the scopes given.
+func (b *builder) oauth2(scheme *spec.SecurityScheme, scopes map[string]string) []AuthAttribute {
+	attrs := attributes(
+		"accessToken", b.variable(accessTokenVariable, codesample.AccessTokenPlaceholder),
+		"addTokenTo", "header",
+	)
+
+	if grantType, ok := oauth2GrantTypes[scheme.OAuth2Flow]; ok {
+		attrs = append(attrs, attributes("grant_type", grantType)...)
+	}
+
+	if scheme.AuthorizationURL != "" {
+		attrs = append(attrs, attributes("authUrl", scheme.AuthorizationURL)...)
+	}
+
+	if scheme.TokenURL != "" {
+		attrs = append(attrs, attributes("accessTokenUrl", scheme.TokenURL)...)
+	}
+
+	if len(scopes) > 0 {
+		names := make([]string, 0, len(scopes))
+		for s := range scopes {
+			names = append(names, s)
+		}
+
+		sort.Strings(names)
+
+		attrs = append(attrs, attributes("scope", strings.Join(names, " "))...)
+	}
+
+	return attrs
+}
+
+// variable records the collection variable key, seeded with value, and returns a reference to it.
+func (b *builder) variable(key, value string) string {
+	b.variables[key] = value
+
+	return "{{" + key + "}}"
+}
+
+// attributes builds the settings of an Auth from pairs of keys and values.
+func attributes(pairs ...string) []AuthAttribute {
+	attrs := make([]AuthAttribute, 0, len(pairs)/2)
+
+	for i := 0; i+1 < len(pairs); i += 2 {
+		attrs = append(attrs, AuthAttribute{Key: pairs[i], Value: pairs[i+1], Type: "string"})
+	}
+
+	return attrs
+}
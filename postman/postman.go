@@ -0,0 +1,289 @@
+// Package postman builds Postman collections of the methods documented by a specification.
+package postman
+
+import (
+	"strings"
+
+	"github.com/kenjones-cisco/dapperdox/codesample"
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// SchemaURL identifies the version of the collection format, v2.1.
+const SchemaURL = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// BaseURLVariable is the collection variable the URLs of the requests start with.
+const BaseURLVariable = "baseUrl"
+
+const (
+	formURLEncoded = "application/x-www-form-urlencoded"
+	formMultipart  = "multipart/form-data"
+)
+
+// Collection is a Postman collection, holding a folder of requests per API group.
+type Collection struct {
+	Info     Info       `json:"info"`
+	Item     []Item     `json:"item"`
+	Auth     *Auth      `json:"auth,omitempty"`
+	Variable []Variable `json:"variable,omitempty"`
+}
+
+// Info describes a collection.
+type Info struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// Item is either a folder, holding further items, or a request.
+type Item struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Item        []Item   `json:"item,omitempty"`
+	Request     *Request `json:"request,omitempty"`
+}
+
+// Request is a request of a collection.
+type Request struct {
+	Method      string     `json:"method"`
+	Header      []KeyValue `json:"header"`
+	Body        *Body      `json:"body,omitempty"`
+	URL         URL        `json:"url"`
+	Auth        *Auth      `json:"auth,omitempty"`
+	Description string     `json:"description,omitempty"`
+}
+
+// URL is the URL of a request, split into its parts.
+type URL struct {
+	Raw      string     `json:"raw"`
+	Host     []string   `json:"host"`
+	Path     []string   `json:"path,omitempty"`
+	Query    []KeyValue `json:"query,omitempty"`
+	Variable []KeyValue `json:"variable,omitempty"`
+}
+
+// KeyValue is a query parameter, header, path variable or form field. Optional parameters are disabled.
+type KeyValue struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Type        string `json:"type,omitempty"` // Type of a form field, text or file
+	Src         string `json:"src,omitempty"`  // Src is the path of the file uploaded by a form field
+	Description string `json:"description,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+// Body is the body of a request, raw or the fields of a form.
+type Body struct {
+	Mode       string       `json:"mode"` // Mode is raw, urlencoded or formdata
+	Raw        string       `json:"raw,omitempty"`
+	URLEncoded []KeyValue   `json:"urlencoded,omitempty"`
+	FormData   []KeyValue   `json:"formdata,omitempty"`
+	Options    *BodyOptions `json:"options,omitempty"`
+}
+
+// BodyOptions gives the language of a raw body, highlighted by Postman.
+type BodyOptions struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
+}
+
+// Variable is a collection variable.
+type Variable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+// New builds the collection of the methods of the current version of each API group of a specification,
+// in folders named after the groups. Operations hidden by x-visibility are not documented, so are left
+// out of the collection too. Requests give every parameter a placeholder value, optional parameters being
+// disabled, and send the example of their body. They are authorised by the security schemes of their
+// methods, with credentials held by collection variables alongside the baseUrl of the API.
+func New(specification *spec.APISpecification) *Collection {
+	b := &builder{variables: make(map[string]string)}
+
+	c := &Collection{
+		Info: Info{
+			Name:        specification.APIInfo.Title,
+			Description: specification.APIInfo.Description,
+			Schema:      SchemaURL,
+		},
+		Item: []Item{},
+		Auth: b.auth(specification.DefaultSecurity),
+	}
+
+	baseURL := ""
+
+	for _, api := range specification.APIs {
+		if len(api.Methods) == 0 {
+			continue
+		}
+
+		if baseURL == "" && api.URL != nil {
+			baseURL = api.URL.String()
+		}
+
+		folder := Item{Name: api.Name}
+
+		for _, method := range api.Methods {
+			folder.Item = append(folder.Item, b.item(method))
+		}
+
+		c.Item = append(c.Item, folder)
+	}
+
+	c.Variable = append(c.Variable, Variable{Key: BaseURLVariable, Value: baseURL, Type: "string"})
+
+	for _, key := range []string{apiKeyVariable, accessTokenVariable, usernameVariable, passwordVariable} {
+		if value, ok := b.variables[key]; ok {
+			c.Variable = append(c.Variable, Variable{Key: key, Value: value, Type: "string"})
+		}
+	}
+
+	return c
+}
+
+// builder builds the items of a collection, recording the variables holding the credentials they use.
+type builder struct {
+	variables map[string]string
+}
+
+// item builds the request of a method.
+func (b *builder) item(method spec.Method) Item {
+	req := &Request{
+		Method:      strings.ToUpper(method.Method),
+		Header:      []KeyValue{},
+		URL:         newURL(method),
+		Auth:        b.auth(method.Security),
+		Description: method.Description,
+	}
+
+	if req.Auth == nil {
+		req.Auth = &Auth{Type: "noauth"}
+	}
+
+	if len(method.Produces) > 0 {
+		req.Header = append(req.Header, KeyValue{Key: "Accept", Value: method.Produces[0]})
+	}
+
+	req.Header = append(req.Header, keyValues(method.HeaderParams)...)
+
+	var cookies []string
+
+	for _, p := range method.CookieParams {
+		if p.Required {
+			cookies = append(cookies, p.Name+"="+codesample.Placeholder(p))
+		}
+	}
+
+	if len(cookies) > 0 {
+		req.Header = append(req.Header, KeyValue{Key: "Cookie", Value: strings.Join(cookies, "; ")})
+	}
+
+	switch {
+	case method.BodyParam != nil:
+		mediaType := codesample.BodyMediaType(method.Consumes)
+
+		req.Header = append(req.Header, KeyValue{Key: "Content-Type", Value: mediaType})
+		req.Body = &Body{Mode: "raw", Raw: codesample.BodyExample(method.BodyParam), Options: &BodyOptions{}}
+
+		req.Body.Options.Raw.Language = "text"
+		if strings.Contains(mediaType, "json") {
+			req.Body.Options.Raw.Language = "json"
+		}
+	case len(method.FormParams) > 0:
+		req.Body = formBody(method)
+	}
+
+	name := method.Name
+	if name == "" {
+		name = method.ID
+	}
+
+	return Item{Name: name, Request: req}
+}
+
+// newURL builds the URL of a method, whose path parameters are path variables.
+func newURL(method spec.Method) URL {
+	u := URL{Host: []string{"{{" + BaseURLVariable + "}}"}}
+
+	for _, segment := range strings.Split(strings.Trim(method.Path, "/"), "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segment = ":" + strings.Trim(segment, "{}")
+		}
+
+		if segment != "" {
+			u.Path = append(u.Path, segment)
+		}
+	}
+
+	u.Query = keyValues(method.QueryParams)
+	u.Variable = keyValues(method.PathParams)
+
+	u.Raw = u.Host[0] + "/" + strings.Join(u.Path, "/")
+
+	var query []string
+
+	for _, q := range u.Query {
+		if !q.Disabled {
+			query = append(query, q.Key+"="+q.Value)
+		}
+	}
+
+	if len(query) > 0 {
+		u.Raw += "?" + strings.Join(query, "&")
+	}
+
+	return u
+}
+
+// formBody builds the body of a method sending form parameters, as multipart/form-data when it uploads
+// files or consumes it.
+func formBody(method spec.Method) *Body {
+	multipart := false
+
+	for _, c := range method.Consumes {
+		multipart = multipart || c == formMultipart
+	}
+
+	for _, p := range method.FormParams {
+		multipart = multipart || codesample.IsFile(p)
+	}
+
+	if !multipart {
+		return &Body{Mode: "urlencoded", URLEncoded: keyValues(method.FormParams)}
+	}
+
+	body := &Body{Mode: "formdata"}
+
+	for _, p := range method.FormParams {
+		field := KeyValue{Key: p.Name, Type: "text", Description: p.Description, Disabled: !p.Required}
+
+		if codesample.IsFile(p) {
+			field.Type = "file"
+			field.Src = codesample.FilePlaceholder
+		} else {
+			field.Value = codesample.Placeholder(p)
+		}
+
+		body.FormData = append(body.FormData, field)
+	}
+
+	return body
+}
+
+// keyValues gives each parameter its placeholder value, disabling the optional parameters.
+func keyValues(params []spec.Parameter) []KeyValue {
+	var list []KeyValue
+
+	for _, p := range params {
+		list = append(list, KeyValue{
+			Key:         p.Name,
+			Value:       codesample.Placeholder(p),
+			Description: p.Description,
+			Disabled:    !p.Required && p.In != "path",
+		})
+	}
+
+	return list
+}
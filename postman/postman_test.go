@@ -0,0 +1,168 @@
+package postman
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+func TestNew(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.SpecDir, "../fixtures/")
+	cfg.Set(config.SpecFilename, []string{"postman_api.json"})
+
+	suite, err := spec.LoadSpecifications(cfg, nil)
+	if err != nil {
+		t.Fatalf("LoadSpecifications() error = %v", err)
+	}
+
+	c := New(suite.Specs["rooms"])
+
+	if c.Info.Name != "Rooms" || c.Info.Schema != SchemaURL {
+		t.Errorf("New() info = %+v", c.Info)
+	}
+
+	wantVariables := []Variable{
+		{Key: BaseURLVariable, Value: "https://rooms.example.com", Type: "string"},
+		{Key: apiKeyVariable, Value: "YOUR_API_KEY", Type: "string"},
+		{Key: usernameVariable, Value: "YOUR_USERNAME", Type: "string"},
+		{Key: passwordVariable, Value: "YOUR_PASSWORD", Type: "string"},
+	}
+	if !reflect.DeepEqual(c.Variable, wantVariables) {
+		t.Errorf("New() variables = %+v, want %+v", c.Variable, wantVariables)
+	}
+
+	if c.Auth == nil || c.Auth.Type != "apikey" {
+		t.Errorf("New() auth = %+v, want apikey", c.Auth)
+	}
+
+	if len(c.Item) != 1 || c.Item[0].Name != "Rooms" {
+		t.Fatalf("New() folders = %+v, want Rooms", c.Item)
+	}
+
+	requests := make(map[string]*Request)
+	for _, item := range c.Item[0].Item {
+		requests[item.Name] = item.Request
+	}
+
+	if len(requests) != 2 {
+		t.Errorf("New() requests = %v, want the private operations left out", requests)
+	}
+
+	get := requests["Get a room"]
+	if get == nil {
+		t.Fatal("New() did not build the request of Get a room")
+	}
+
+	if want := "{{baseUrl}}/v1/rooms/:roomId"; get.URL.Raw != want {
+		t.Errorf("New() url = %s, want %s", get.URL.Raw, want)
+	}
+
+	if want := []KeyValue{{Key: "roomId", Value: "1"}}; !reflect.DeepEqual(get.URL.Variable, want) {
+		t.Errorf("New() path variables = %+v, want %+v", get.URL.Variable, want)
+	}
+
+	if want := []KeyValue{{Key: "expand", Value: "bookings", Disabled: true}}; !reflect.DeepEqual(get.URL.Query, want) {
+		t.Errorf("New() query = %+v, want %+v", get.URL.Query, want)
+	}
+
+	if want := (KeyValue{Key: "X-Request-Id", Value: "X_REQUEST_ID"}); !containsKeyValue(get.Header, want) {
+		t.Errorf("New() headers = %+v, want %+v", get.Header, want)
+	}
+
+	if want := attributes("key", "X-API-Key", "value", "{{apiKey}}", "in", "header"); get.Auth == nil || !reflect.DeepEqual(get.Auth.APIKey, want) {
+		t.Errorf("New() auth = %+v, want api key %+v", get.Auth, want)
+	}
+
+	put := requests["Update a room"]
+	if put == nil {
+		t.Fatal("New() did not build the request of Update a room")
+	}
+
+	if put.Method != "PUT" || put.Auth == nil || put.Auth.Type != "basic" {
+		t.Errorf("New() request = %s with %+v, want PUT with basic auth", put.Method, put.Auth)
+	}
+
+	if put.Body == nil || put.Body.Mode != "raw" || put.Body.Options.Raw.Language != "json" || !strings.Contains(put.Body.Raw, `"name": "Boardroom"`) {
+		t.Errorf("New() body = %+v, want the JSON example of a room", put.Body)
+	}
+}
+
+func TestNew_Auth(t *testing.T) {
+	tests := []struct {
+		name     string
+		security map[string]spec.Security
+		want     *Auth
+	}{
+		{
+			name: "none",
+			want: &Auth{Type: "noauth"},
+		},
+		{
+			name: "api key in cookie",
+			security: map[string]spec.Security{
+				"apiKey": {Scheme: &spec.SecurityScheme{IsAPIKey: true, ParamName: "session", ParamLocation: "cookie"}},
+			},
+			want: &Auth{Type: "apikey", APIKey: attributes("key", "Cookie", "value", "session={{apiKey}}", "in", "header")},
+		},
+		{
+			name: "bearer",
+			security: map[string]spec.Security{
+				"bearer": {Scheme: &spec.SecurityScheme{IsBearer: true}},
+			},
+			want: &Auth{Type: "bearer", Bearer: attributes("token", "{{accessToken}}")},
+		},
+		{
+			name: "oauth2 access code",
+			security: map[string]spec.Security{
+				"oauth2": {
+					Scheme: &spec.SecurityScheme{IsOAuth2: true, OAuth2Scheme: spec.OAuth2Scheme{
+						OAuth2Flow:       "accessCode",
+						AuthorizationURL: "https://auth.example.com/authorize",
+						TokenURL:         "https://auth.example.com/token",
+					}},
+					Scopes: map[string]string{"write": "Write access", "read": "Read access"},
+				},
+			},
+			want: &Auth{Type: "oauth2", OAuth2: attributes(
+				"accessToken", "{{accessToken}}",
+				"addTokenTo", "header",
+				"grant_type", "authorization_code",
+				"authUrl", "https://auth.example.com/authorize",
+				"accessTokenUrl", "https://auth.example.com/token",
+				"scope", "read write",
+			)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specification := &spec.APISpecification{
+				APIs: spec.APISet{{
+					Name:    "Bookings",
+					URL:     &url.URL{Scheme: "https", Host: "bookings.example.com"},
+					Methods: []spec.Method{{Name: "List bookings", Method: "get", Path: "/bookings", Security: tt.security}},
+				}},
+			}
+
+			c := New(specification)
+
+			if got := c.Item[0].Item[0].Request.Auth; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("New() auth = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func containsKeyValue(list []KeyValue, kv KeyValue) bool {
+	for _, v := range list {
+		if v == kv {
+			return true
+		}
+	}
+
+	return false
+}
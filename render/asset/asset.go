@@ -37,24 +37,59 @@ import (
 
 	"github.com/kenjones-cisco/dapperdox/config"
 	"github.com/kenjones-cisco/dapperdox/formatter"
+	"github.com/kenjones-cisco/dapperdox/i18n"
 )
 
 var (
-	_bindata      = map[string][]byte{}
-	_metadata     = map[string]map[string]string{}
+	sectionSplitRegex   = regexp.MustCompile(`\[\[[\w\-/]+\]\]`)
+	templateActionRegex = regexp.MustCompile(`(?s)\[:.*?:\]`)
+	gfmMapSplit         = regexp.MustCompile(":")
+)
+
+// Store holds the assets compiled from the template, static and theme directories. Assets are
+// only ever added to a Store, so a reload compiles them into a new one.
+type Store struct {
+	cfg           *viper.Viper
+	locale        string          // locale the assets are compiled for
+	locales       map[string]bool // locales configured, whose variants of the assets are told apart
+	bindata       map[string][]byte
+	metadata      map[string]map[string]string
+	text          map[string]string // plain text of the documents, to be searched
 	guideReplacer *strings.Replacer
 	gfmReplace    []*gfmReplacer
-)
+}
 
-var (
-	sectionSplitRegex = regexp.MustCompile(`\[\[[\w\-/]+\]\]`)
-	gfmMapSplit       = regexp.MustCompile(":")
-)
+// NewStore creates an empty Store, locating and rewriting its assets as configured.
+func NewStore(cfg *viper.Viper) *Store {
+	return NewLocalizedStore(cfg, "")
+}
+
+// NewLocalizedStore creates an empty Store compiling the assets of a locale. An asset named with a
+// locale configured before its extension, such as guide.fr.md, is a variant of the asset named without
+// it: it replaces that asset in a Store of its locale, and is left out of the others.
+func NewLocalizedStore(cfg *viper.Viper, locale string) *Store {
+	s := &Store{
+		cfg:      cfg,
+		locale:   locale,
+		locales:  map[string]bool{},
+		bindata:  map[string][]byte{},
+		metadata: map[string]map[string]string{},
+		text:     map[string]string{},
+	}
+
+	for _, l := range i18n.Locales(cfg) {
+		if l != "" {
+			s.locales[l] = true
+		}
+	}
+
+	return s
+}
 
 // Asset returns asset content.
-func Asset(name string) ([]byte, error) {
+func (s *Store) Asset(name string) ([]byte, error) {
 	cannonicalName := strings.ReplaceAll(name, "\\", "/")
-	if a, ok := _bindata[cannonicalName]; ok {
+	if a, ok := s.bindata[cannonicalName]; ok {
 		return a, nil
 	}
 
@@ -62,9 +97,9 @@ func Asset(name string) ([]byte, error) {
 }
 
 // Names returns all asset names.
-func Names() []string {
-	names := make([]string, 0, len(_bindata))
-	for name := range _bindata {
+func (s *Store) Names() []string {
+	names := make([]string, 0, len(s.bindata))
+	for name := range s.bindata {
 		names = append(names, name)
 	}
 
@@ -72,8 +107,8 @@ func Names() []string {
 }
 
 // MetaData returns file metadata.
-func MetaData(filename, name string) string {
-	if md, ok := _metadata[filename]; ok {
+func (s *Store) MetaData(filename, name string) string {
+	if md, ok := s.metadata[filename]; ok {
 		if val, ok := md[strings.ToLower(name)]; ok {
 			return val
 		}
@@ -82,18 +117,23 @@ func MetaData(filename, name string) string {
 	return ""
 }
 
+// Text returns the plain text of a markdown or template document, without its markup or template actions.
+func (s *Store) Text(name string) string {
+	return s.text[strings.ReplaceAll(name, "\\", "/")]
+}
+
 // Compile specs.
-func Compile(dir, prefix string) {
+func (s *Store) Compile(dir, prefix string) {
 	// Build a replacer to search/replace Document URLs in the documents.
-	if guideReplacer == nil {
+	if s.guideReplacer == nil {
 		var replacements []string
 
 		// Configure the replacer with key=value pairs
-		for k, v := range viper.GetStringMapString(config.DocumentRewriteURL) {
+		for k, v := range s.cfg.GetStringMapString(config.DocumentRewriteURL) {
 			replacements = append(replacements, k, v)
 		}
 
-		guideReplacer = strings.NewReplacer(replacements...)
+		s.guideReplacer = strings.NewReplacer(replacements...)
 	}
 
 	dir, err := filepath.Abs(dir)
@@ -121,12 +161,19 @@ func Compile(dir, prefix string) {
 			return nil
 		}
 
+		name, ok := s.localize(path)
+		if !ok {
+			log().Tracef("  - Skipping %s, compiled for another locale", path)
+
+			return nil
+		}
+
 		buf, err := os.ReadFile(path)
 		if err != nil {
 			panic(err)
 		}
 
-		relative, err := filepath.Rel(dir, path)
+		relative, err := filepath.Rel(dir, name)
 		if err != nil {
 			panic(err)
 		}
@@ -153,53 +200,87 @@ func Compile(dir, prefix string) {
 				}
 
 				for i, heading := range headings {
-					buf = processMarkdown([]byte(sections[i]))
+					buf = s.processMarkdown([]byte(sections[i]))
 
 					relative = filepath.Join(mdname, heading, "overlay.tmpl")
-					storeTemplate(prefix, relative, guideReplacer.Replace(string(buf)), meta)
+					s.storeTemplate(prefix, relative, s.guideReplacer.Replace(string(buf)), meta)
 				}
 			} else {
-				buf = processMarkdown(buf) // Convert markdown into HTML
+				buf = s.processMarkdown(buf) // Convert markdown into HTML
 
 				relative = mdname + ".tmpl"
-				storeTemplate(prefix, relative, guideReplacer.Replace(string(buf)), meta)
+				if s.storeTemplate(prefix, relative, s.guideReplacer.Replace(string(buf)), meta) {
+					s.storeText(prefix, relative, buf)
+				}
 			}
 		case ".tmpl":
 			buf, meta = processMetadata(buf)
-			storeTemplate(prefix, relative, guideReplacer.Replace(string(buf)), meta)
+			if s.storeTemplate(prefix, relative, s.guideReplacer.Replace(string(buf)), meta) {
+				s.storeText(prefix, relative, templateActionRegex.ReplaceAll(buf, nil))
+			}
 
 		case ".html":
 			log().Panicf("  * Error - Refusing to process .html files. Expects HTML template fragments with .tmpl extension. File %s", relative)
 
 		default:
-			storeTemplate(prefix, relative, guideReplacer.Replace(string(buf)), meta)
+			s.storeTemplate(prefix, relative, s.guideReplacer.Replace(string(buf)), meta)
 		}
 
 		return nil
 	})
 }
 
-func storeTemplate(prefix, name, template string, meta map[string]string) {
+// localize returns the path a file is compiled as, and whether it is compiled at all: the variant of an
+// asset for the locale of the Store is compiled as the asset, which is left out along with the variants
+// for other locales.
+func (s *Store) localize(path string) (string, bool) {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+
+	if locale := strings.TrimPrefix(filepath.Ext(stem), "."); s.locales[locale] {
+		return strings.TrimSuffix(stem, "."+locale) + ext, locale == s.locale
+	}
+
+	if s.locale != "" {
+		if _, err := os.Stat(stem + "." + s.locale + ext); err == nil {
+			return path, false
+		}
+	}
+
+	return path, true
+}
+
+// storeTemplate stores an asset, returning whether it was stored, as the first asset compiled of a name is kept.
+func (s *Store) storeTemplate(prefix, name, template string, meta map[string]string) bool {
 	newname := filepath.ToSlash(filepath.Join(prefix, name))
 
-	if _, ok := _bindata[newname]; !ok {
+	if _, ok := s.bindata[newname]; !ok {
 		log().Debugf("  + Import %s", newname)
 		// Store the template, doing and search/replaces on the way
-		_bindata[newname] = []byte(template)
+		s.bindata[newname] = []byte(template)
 
 		if len(meta) > 0 {
 			log().Trace("    + Adding metadata")
 
-			_metadata[newname] = meta
+			s.metadata[newname] = meta
 		}
+
+		return true
 	}
+
+	return false
+}
+
+// storeText records the plain text of a document stored, from its HTML.
+func (s *Store) storeText(prefix, name string, doc []byte) {
+	s.text[filepath.ToSlash(filepath.Join(prefix, name))] = formatter.PlainText(doc)
 }
 
 // processMarkdown Returns rendered markdown.
-func processMarkdown(doc []byte) []byte {
+func (s *Store) processMarkdown(doc []byte) []byte {
 	html := formatter.Markdown(doc)
 	// Apply any HTML substitutions
-	for _, rep := range gfmReplace {
+	for _, rep := range s.gfmReplace {
 		html = rep.Regexp.ReplaceAll(html, rep.Replace)
 	}
 
@@ -274,11 +355,11 @@ func splitOnSection(text string) ([]string, []string) {
 }
 
 // CompileGFMMap github markdown.
-func CompileGFMMap() {
+func (s *Store) CompileGFMMap() {
 	var mapfile string
 
-	if viper.GetString(config.AssetsDir) != "" {
-		mapfile = filepath.Join(viper.GetString(config.AssetsDir), "gfm.map")
+	if s.cfg.GetString(config.AssetsDir) != "" {
+		mapfile = filepath.Join(s.cfg.GetString(config.AssetsDir), "gfm.map")
 		log().Tracef("Looking in assets dir for %s", mapfile)
 
 		if _, err := os.Stat(mapfile); os.IsNotExist(err) {
@@ -286,8 +367,8 @@ func CompileGFMMap() {
 		}
 	}
 
-	if mapfile == "" && viper.GetString(config.ThemeDir) != "" {
-		mapfile = filepath.Join(viper.GetString(config.ThemeDir), viper.GetString(config.Theme), "gfm.map")
+	if mapfile == "" && s.cfg.GetString(config.ThemeDir) != "" {
+		mapfile = filepath.Join(s.cfg.GetString(config.ThemeDir), s.cfg.GetString(config.Theme), "gfm.map")
 		log().Tracef("Looking in theme dir for %s", mapfile)
 
 		if _, err := os.Stat(mapfile); os.IsNotExist(err) {
@@ -296,7 +377,7 @@ func CompileGFMMap() {
 	}
 
 	if mapfile == "" {
-		mapfile = filepath.Join(viper.GetString(config.DefaultAssetsDir), "themes", viper.GetString(config.Theme), "gfm.map")
+		mapfile = filepath.Join(s.cfg.GetString(config.DefaultAssetsDir), "themes", s.cfg.GetString(config.Theme), "gfm.map")
 		log().Tracef("Looking in default theme dir for %s", mapfile)
 
 		if _, err := os.Stat(mapfile); os.IsNotExist(err) {
@@ -305,7 +386,7 @@ func CompileGFMMap() {
 	}
 
 	if mapfile == "" {
-		mapfile = filepath.Join(viper.GetString(config.DefaultAssetsDir), "themes", "default", "gfm.map")
+		mapfile = filepath.Join(s.cfg.GetString(config.DefaultAssetsDir), "themes", "default", "gfm.map")
 		log().Tracef("Looking in default theme for %s", mapfile)
 
 		if _, err := os.Stat(mapfile); os.IsNotExist(err) {
@@ -337,7 +418,7 @@ func CompileGFMMap() {
 		rep := &gfmReplacer{}
 		if rep.Parse(line) != nil {
 			log().Tracef("GFM replace %s with %s", rep.Regexp, rep.Replace)
-			gfmReplace = append(gfmReplace, rep)
+			s.gfmReplace = append(s.gfmReplace, rep)
 		}
 	}
 
@@ -20,12 +20,11 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package render
 
 import (
-	"bufio"
 	"bytes"
 	"html/template"
 	"io"
 	"math"
-	"net/http"
+	"os"
 	"path/filepath"
 	"reflect"
 	"strconv"
@@ -35,6 +34,7 @@ import (
 	"github.com/unrolled/render"
 
 	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/i18n"
 	"github.com/kenjones-cisco/dapperdox/render/asset"
 	"github.com/kenjones-cisco/dapperdox/spec"
 )
@@ -44,68 +44,126 @@ const (
 	sizeSixtyFour = 64
 )
 
-var (
-	// global instance of github.com/unrolled/render.Render.
-	_render *render.Render
+// Renderer renders the pages documenting a Suite, with the templates and assets compiled for it.
+// A Renderer is built for each Suite, so that a reload never alters the one serving requests.
+type Renderer struct {
+	cfg      *viper.Viper
+	suite    *spec.Suite
+	locale   string       // locale the pages are rendered in, empty when no locales are configured
+	messages i18n.Catalog // messages of the theme, in locale
+	assets   *asset.Store
+	render   *render.Render
+	guides   map[string]GuideType // Guides are per specification-id, or 'top-level'.
+
+	counter int // template counter, only used while rendering, which the render.Render serializes
+}
 
-	counter int
-)
+// New creates a Renderer for a Suite, compiling the templates and assets of its specifications
+// from the directories configured.
+func New(cfg *viper.Viper, suite *spec.Suite) *Renderer {
+	return NewLocalized(cfg, suite, "")
+}
+
+// NewLocalized creates a Renderer for a Suite as New does, rendering its pages in a locale with the
+// variants of the assets and the messages of the theme for it.
+func NewLocalized(cfg *viper.Viper, suite *spec.Suite, locale string) *Renderer {
+	log().Debugf("initializing Render for locale %q", locale)
+
+	r := &Renderer{
+		cfg:    cfg,
+		suite:  suite,
+		locale: locale,
+		assets: asset.NewLocalizedStore(cfg, locale),
+		guides: map[string]GuideType{},
+	}
+	r.messages = r.loadMessages()
+	r.render = r.newRender()
+
+	return r
+}
+
+// Config returns the configuration the Renderer was created with.
+func (r *Renderer) Config() *viper.Viper {
+	return r.cfg
+}
+
+// Suite returns the specifications documented by the Renderer.
+func (r *Renderer) Suite() *spec.Suite {
+	return r.suite
+}
+
+// Locale returns the locale the Renderer renders pages in, empty when no locales are configured.
+func (r *Renderer) Locale() string {
+	return r.locale
+}
+
+// lang returns the language of the pages, that of the default theme when no locale is configured.
+func (r *Renderer) lang() string {
+	if r.locale == "" {
+		return "en"
+	}
 
-// Register is alias for initializing new render.Render.
-func Register() {
-	log().Debug("initializing Render")
+	return r.locale
+}
+
+// Message returns a message of the theme in the locale of the Renderer, formatted with args.
+func (r *Renderer) Message(id string, args ...interface{}) string {
+	return r.messages.Message(id, args...)
+}
 
-	_render = newRender()
+// Assets returns the assets compiled for the Renderer.
+func (r *Renderer) Assets() *asset.Store {
+	return r.assets
 }
 
 // HTML is an alias to github.com/unrolled/render.Render.HTML.
-func HTML(w io.Writer, status int, name string, binding interface{}, htmlOpt ...render.HTMLOptions) {
-	_ = _render.HTML(w, status, name, binding, htmlOpt...)
+func (r *Renderer) HTML(w io.Writer, status int, name string, binding interface{}, htmlOpt ...render.HTMLOptions) {
+	_ = r.render.HTML(w, status, name, binding, htmlOpt...)
 }
 
 // TemplateLookup is an alias to github.com/unrolled/render.TemplateLookup.
-func TemplateLookup(t string) *template.Template {
-	return _render.TemplateLookup(t)
+func (r *Renderer) TemplateLookup(t string) *template.Template {
+	return r.render.TemplateLookup(t)
 }
 
-func newRender() *render.Render {
+func (r *Renderer) newRender() *render.Render {
 	log().Trace("creating instance of render.Render")
 
-	asset.CompileGFMMap()
+	r.assets.CompileGFMMap()
 
 	// XXX Order of directory importing is IMPORTANT XXX
-	if viper.GetString(config.AssetsDir) != "" {
-		asset.Compile(filepath.Join(viper.GetString(config.AssetsDir), "templates"), "assets/templates")
-		asset.Compile(filepath.Join(viper.GetString(config.AssetsDir), "static"), "assets/static")
-		asset.Compile(filepath.Join(viper.GetString(config.AssetsDir), "themes", viper.GetString(config.Theme)), "assets")
-		compileSections(viper.GetString(config.AssetsDir))
+	if r.cfg.GetString(config.AssetsDir) != "" {
+		r.assets.Compile(filepath.Join(r.cfg.GetString(config.AssetsDir), "templates"), "assets/templates")
+		r.assets.Compile(filepath.Join(r.cfg.GetString(config.AssetsDir), "static"), "assets/static")
+		r.assets.Compile(filepath.Join(r.cfg.GetString(config.AssetsDir), "themes", r.cfg.GetString(config.Theme)), "assets")
+		r.compileSections(r.cfg.GetString(config.AssetsDir))
 	}
 
 	// Import custom theme from custom directory (if defined)
-	if viper.GetString(config.Theme) != "" {
-		dir := filepath.Join(viper.GetString(config.DefaultAssetsDir), "themes")
-		if viper.GetString(config.ThemeDir) != "" {
-			dir = viper.GetString(config.ThemeDir)
+	if r.cfg.GetString(config.Theme) != "" {
+		dir := filepath.Join(r.cfg.GetString(config.DefaultAssetsDir), "themes")
+		if r.cfg.GetString(config.ThemeDir) != "" {
+			dir = r.cfg.GetString(config.ThemeDir)
 		}
 
-		asset.Compile(filepath.Join(dir, viper.GetString(config.Theme)), "assets")
+		r.assets.Compile(filepath.Join(dir, r.cfg.GetString(config.Theme)), "assets")
 	}
 
-	if viper.GetString(config.Theme) != "default" {
+	if r.cfg.GetString(config.Theme) != "default" {
 		// The default theme underpins all others
-		asset.Compile(filepath.Join(viper.GetString(config.DefaultAssetsDir), "themes", "default"), "assets")
+		r.assets.Compile(filepath.Join(r.cfg.GetString(config.DefaultAssetsDir), "themes", "default"), "assets")
 	}
 
-	compileSections(viper.GetString(config.DefaultAssetsDir))
+	r.compileSections(r.cfg.GetString(config.DefaultAssetsDir))
 
 	// Fallback to local templates directory
-	asset.Compile(filepath.Join(viper.GetString(config.DefaultAssetsDir), "templates"), "assets/templates")
+	r.assets.Compile(filepath.Join(r.cfg.GetString(config.DefaultAssetsDir), "templates"), "assets/templates")
 	// Fallback to local static directory
-	asset.Compile(filepath.Join(viper.GetString(config.DefaultAssetsDir), "static"), "assets/static")
+	r.assets.Compile(filepath.Join(r.cfg.GetString(config.DefaultAssetsDir), "static"), "assets/static")
 
 	return render.New(render.Options{
-		Asset:      asset.Asset,
-		AssetNames: asset.Names,
+		Asset:      r.assets.Asset,
+		AssetNames: r.assets.Names,
 		Directory:  "assets/templates",
 		Delims:     render.Delims{Left: "[:", Right: ":]"},
 		Layout:     "layout",
@@ -118,51 +176,73 @@ func newRender() *render.Render {
 			"uc":            strings.ToUpper,
 			"join":          strings.Join,
 			"concat":        func(a, b string) string { return a + b },
-			"counter_set":   func(a int) int { counter = a; return counter },
-			"counter_add":   func(a int) int { counter += a; return counter },
+			"counter_set":   func(a int) int { r.counter = a; return r.counter },
+			"counter_add":   func(a int) int { r.counter += a; return r.counter },
 			"mod":           func(a int, m int) int { return a % m },
 			"sub":           func(a, b interface{}) int64 { return toInt64(a) - toInt64(b) },
 			"safehtml":      func(s string) template.HTML { return template.HTML(s) },
-			"haveTemplate":  TemplateLookup,
-			"overlay":       func(n string, d ...interface{}) template.HTML { return overlayFunc(n, d) },
+			"t":             r.Message,
+			"lang":          r.lang,
+			"haveTemplate":  r.TemplateLookup,
+			"overlay":       func(n string, d ...interface{}) template.HTML { return r.overlayFunc(n, d) },
 			"getAssetPaths": func(s string, d ...interface{}) []string { return getAssetPaths(s, d) },
 		}},
 	})
 }
 
-func compileSections(assetsDir string) {
-	// specification specific guides
-	for _, specification := range spec.APISuite {
-		log().Debugf("- Specification assets for %q", specification.APIInfo.Title)
-		compileSectionPart(specification.ID, assetsDir, "templates", "assets/templates/")
-		compileSectionPart(specification.ID, assetsDir, "static", "assets/static/")
+// loadMessages loads the messages of the theme, from the messages.csv files of the default theme, the
+// theme and the assets directory, each replacing the messages of the one before. The messages of the
+// locale, from their messages.<locale>.csv files, then replace those.
+func (r *Renderer) loadMessages() i18n.Catalog {
+	dirs := []string{
+		filepath.Join(r.cfg.GetString(config.DefaultAssetsDir), "themes", "default"),
+		filepath.Join(r.cfg.GetString(config.DefaultAssetsDir), "themes", r.cfg.GetString(config.Theme)),
 	}
-}
 
-func compileSectionPart(id, assetsDir, part, prefix string) {
-	stem := filepath.Join(id, part)
-	asset.Compile(filepath.Join(assetsDir, "sections", stem), filepath.Join(prefix, stem))
-}
+	if r.cfg.GetString(config.ThemeDir) != "" {
+		dirs = append(dirs, filepath.Join(r.cfg.GetString(config.ThemeDir), r.cfg.GetString(config.Theme)))
+	}
 
-// htmlWriter implements an HTML Writer interface.
-type htmlWriter struct {
-	h *bufio.Writer
-}
+	if r.cfg.GetString(config.AssetsDir) != "" {
+		dirs = append(dirs, r.cfg.GetString(config.AssetsDir))
+	}
+
+	names := []string{"messages.csv"}
+	if r.locale != "" {
+		names = append(names, "messages."+r.locale+".csv")
+	}
+
+	messages := i18n.Catalog{}
+
+	for _, name := range names {
+		for _, dir := range dirs {
+			file := filepath.Join(dir, name)
 
-// Header provides empty implementation.
-func (w htmlWriter) Header() http.Header { return http.Header{} }
+			if err := messages.Load(file); err != nil && !os.IsNotExist(err) {
+				log().Errorf("Error loading messages from %s: %s", file, err)
+			}
+		}
+	}
 
-// WriteHeader provides empty implementation.
-func (w htmlWriter) WriteHeader(int) {}
+	return messages
+}
 
-// Write provides empty implementation.
-func (w htmlWriter) Write(data []byte) (int, error) { return w.h.Write(data) }
+func (r *Renderer) compileSections(assetsDir string) {
+	// specification specific guides
+	for _, specification := range r.suite.Specs {
+		log().Debugf("- Specification assets for %q", specification.APIInfo.Title)
+		r.compileSectionPart(specification.ID, assetsDir, "templates", "assets/templates/")
+		r.compileSectionPart(specification.ID, assetsDir, "static", "assets/static/")
+	}
+}
 
-// Flush provides empty implementation.
-func (w htmlWriter) Flush() { _ = w.h.Flush() }
+func (r *Renderer) compileSectionPart(id, assetsDir, part, prefix string) {
+	stem := filepath.Join(id, part)
+	r.assets.Compile(filepath.Join(assetsDir, "sections", stem), filepath.Join(prefix, stem))
+}
 
 // XXX WHY ARRAY of DATA?
-func overlayFunc(name string, data []interface{}) template.HTML { // TODO Will be specification specific
+func (r *Renderer) overlayFunc(name string, data []interface{}) template.HTML { // TODO Will be specification specific
 	if len(data) == 0 || data[0] == nil {
 		log().Debug("Data nil")
 
@@ -184,14 +264,15 @@ func overlayFunc(name string, data []interface{}) template.HTML { // TODO Will b
 	for _, op := range overlayPaths(name, datamap) {
 		log().Tracef("Overlay: Does %q exist?", op)
 
-		if TemplateLookup(op) != nil {
+		if t := r.TemplateLookup(op); t != nil {
 			log().Tracef("Applying overlay %q", op)
 
-			writer := htmlWriter{h: bufio.NewWriter(&b)}
-
+			// The overlay is executed within the page being rendered, which holds the lock of
+			// render.Render.HTML, so it is executed directly rather than through it.
 			// data is a single item array (though I've not figured out why yet!)
-			_ = newRender().HTML(writer, http.StatusOK, op, data[0], render.HTMLOptions{Layout: ""})
-			writer.Flush()
+			if err := t.Execute(&b, data[0]); err != nil {
+				log().Errorf("Error applying overlay %q: %s", op, err)
+			}
 
 			break
 		}
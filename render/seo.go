@@ -0,0 +1,67 @@
+package render
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/formatter"
+	"github.com/kenjones-cisco/dapperdox/i18n"
+)
+
+// metaDescriptionLength is the length meta descriptions are cut to, search engines showing no more.
+const metaDescriptionLength = 160
+
+// SiteURL returns the public URL of the documentation, without a trailing slash.
+func (r *Renderer) SiteURL() string {
+	return strings.TrimSuffix(r.cfg.GetString(config.SiteURL), "/")
+}
+
+// CanonicalURL returns the public URL of the page requested, prefixed by the locale of the Renderer
+// unless it is the default one. The v query parameter selecting a version is kept when it selects one of
+// the versions of the page other than current, the version documented by the unversioned URL.
+func (r *Renderer) CanonicalURL(req *http.Request, current string, versions []string) string {
+	u := r.SiteURL() + r.LocalePrefix() + req.URL.EscapedPath()
+
+	if v := req.FormValue("v"); v != "" && v != current {
+		for _, version := range versions {
+			if version == v {
+				return u + "?" + url.Values{"v": {v}}.Encode()
+			}
+		}
+	}
+
+	return u
+}
+
+// LocalePrefix returns the prefix of the paths of the pages in the locale of the Renderer, empty for the
+// default locale.
+func (r *Renderer) LocalePrefix() string {
+	if r.locale == "" || r.locale == i18n.Locales(r.cfg)[0] {
+		return ""
+	}
+
+	return "/" + r.locale
+}
+
+// MetaDescription returns the plain text of an HTML description, cut at a space to the length of a meta
+// description.
+func MetaDescription(description string) string {
+	text := formatter.PlainText([]byte(description))
+	if len(text) <= metaDescriptionLength {
+		return text
+	}
+
+	end := metaDescriptionLength
+	if space := strings.LastIndexByte(text[:end], ' '); space > 0 {
+		end = space
+	}
+
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+
+	return text[:end] + "…"
+}
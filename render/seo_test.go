@@ -0,0 +1,129 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+)
+
+func TestRenderer_CanonicalURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		siteURL  string
+		locale   string
+		target   string
+		current  string
+		versions []string
+		want     string
+	}{
+		{
+			name:    "unversioned",
+			siteURL: "https://docs.example.com/",
+			target:  "/bookings/reference/rooms",
+			current: "v2",
+			want:    "https://docs.example.com/bookings/reference/rooms",
+		},
+		{
+			name:    "current version",
+			siteURL: "https://docs.example.com",
+			target:  "/bookings/reference/rooms?v=v2",
+			current: "v2",
+			want:    "https://docs.example.com/bookings/reference/rooms",
+		},
+		{
+			name:     "other version",
+			siteURL:  "https://docs.example.com/",
+			target:   "/bookings/reference/rooms?v=v1",
+			current:  "v2",
+			versions: []string{"v1", "v2"},
+			want:     "https://docs.example.com/bookings/reference/rooms?v=v1",
+		},
+		{
+			name:     "unknown version",
+			siteURL:  "https://docs.example.com/",
+			target:   "/bookings/reference/rooms?v=nope",
+			current:  "v2",
+			versions: []string{"v1", "v2"},
+			want:     "https://docs.example.com/bookings/reference/rooms",
+		},
+		{
+			name:     "version escaped",
+			siteURL:  "https://docs.example.com/",
+			target:   "/bookings/reference/rooms?v=" + url.QueryEscape(`v1"><script>`),
+			current:  "v2",
+			versions: []string{`v1"><script>`, "v2"},
+			want:     "https://docs.example.com/bookings/reference/rooms?v=v1%22%3E%3Cscript%3E",
+		},
+		{
+			name:    "other query parameters",
+			siteURL: "https://docs.example.com/docs/",
+			target:  "/bookings/resources/room?utm_source=mail",
+			current: "latest",
+			want:    "https://docs.example.com/docs/bookings/resources/room",
+		},
+		{
+			name:    "default locale",
+			siteURL: "https://docs.example.com",
+			locale:  "en",
+			target:  "/bookings/resources/room",
+			current: "latest",
+			want:    "https://docs.example.com/bookings/resources/room",
+		},
+		{
+			name:    "other locale",
+			siteURL: "https://docs.example.com",
+			locale:  "fr",
+			target:  "/bookings/resources/room",
+			current: "latest",
+			want:    "https://docs.example.com/fr/bookings/resources/room",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Set(config.SiteURL, tt.siteURL)
+			cfg.Set(config.Locales, []string{"en", "fr"})
+
+			r := &Renderer{cfg: cfg, locale: tt.locale}
+
+			if got := r.CanonicalURL(httptest.NewRequest(http.MethodGet, tt.target, nil), tt.current, tt.versions); got != tt.want {
+				t.Errorf("CanonicalURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetaDescription(t *testing.T) {
+	long := strings.Repeat("booking ", 30)
+
+	tests := []struct {
+		name        string
+		description string
+		want        string
+	}{
+		{
+			name:        "html",
+			description: "<p>Lists the <em>bookings</em> of a room.</p>\n",
+			want:        "Lists the bookings of a room.",
+		},
+		{
+			name:        "cut at a space",
+			description: long,
+			want:        long[:159] + "…",
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MetaDescription(tt.description); got != tt.want {
+				t.Errorf("MetaDescription() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,49 +3,63 @@ package render
 import (
 	"net/http"
 
-	"github.com/spf13/viper"
-
 	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/i18n"
 	"github.com/kenjones-cisco/dapperdox/navigation"
 	"github.com/kenjones-cisco/dapperdox/spec"
 )
 
-// Guides are per specification-id, or 'top-level'.
-var guides = map[string]GuideType{}
-
 // GuideType defines an array of Navigation for guides.
 type GuideType []*navigation.Node
 
+// templateConfig holds the configurations used within template files.
+type templateConfig struct {
+	ShowAssets bool
+}
+
 // Vars is a map of variables.
 type Vars map[string]interface{}
 
 // DefaultVars adds the default vars (config, specs, others....) to the data map.
-func DefaultVars(req *http.Request, s *spec.APISpecification, m Vars) map[string]interface{} {
+func (r *Renderer) DefaultVars(req *http.Request, s *spec.APISpecification, m Vars) map[string]interface{} {
 	if m == nil {
 		log().Trace("creating new template data map")
 
 		m = make(map[string]interface{})
 	}
 
-	m["Config"] = config.C
-	m["APISuite"] = spec.APISuite
-	m["APISuiteGroups"] = spec.APISuiteGroups
+	m["Config"] = templateConfig{ShowAssets: r.cfg.GetBool(config.ShowAssets)}
+
+	// Readers may switch between locales when there are several, to the same page in another locale.
+	if locales := i18n.Locales(r.cfg); len(locales) > 1 {
+		m["Locale"] = r.locale
+		m["Locales"] = locales
+		m["Path"] = req.URL.Path
+	}
+
+	// Pages documenting versions give their canonical URL themselves, knowing the current version.
+	if _, ok := m["Canonical"]; !ok {
+		m["Canonical"] = r.CanonicalURL(req, "", nil)
+	}
+
+	m["APISuite"] = r.suite.Specs
+	m["APISuiteGroups"] = r.suite.Groups
 
 	// If we have a multiple specifications or are forcing a parent "root" page for the single specification
 	// then set MultipleSpecs to true to enable navigation back to the root page.
-	if viper.GetBool(config.ForceSpecList) || len(spec.APISuite) > 1 {
+	if r.cfg.GetBool(config.ForceSpecList) || len(r.suite.Specs) > 1 {
 		m["MultipleSpecs"] = true
 	}
 
 	if s == nil {
-		m["NavigationGuides"] = guides[""] // Global guides
+		m["NavigationGuides"] = r.guides[""] // Global guides
 		m["SpecPath"] = ""
 
 		return m
 	}
 
 	// Per specification defaults
-	m["NavigationGuides"] = guides[s.ID]
+	m["NavigationGuides"] = r.guides[s.ID]
 
 	m["ID"] = s.ID
 	m["SpecPath"] = "/" + s.ID
@@ -54,16 +68,23 @@ func DefaultVars(req *http.Request, s *spec.APISpecification, m Vars) map[string
 	m["Resources"] = s.ResourceList
 	m["Info"] = s.APIInfo
 	m["SpecURL"] = s.URL
+	m["Servers"] = s.Servers
+	m["Errors"] = s.Errors
+
+	// Pages without a description of their own are described by the specification.
+	if d, _ := m["MetaDescription"].(string); d == "" {
+		m["MetaDescription"] = MetaDescription(s.APIInfo.Description)
+	}
 
 	return m
 }
 
 // SetGuidesNavigation adds api to navigation.
-func SetGuidesNavigation(s *spec.APISpecification, guidesnav []*navigation.Node) {
+func (r *Renderer) SetGuidesNavigation(s *spec.APISpecification, guidesnav []*navigation.Node) {
 	id := ""
 	if s != nil {
 		id = s.ID
 	}
 
-	guides[id] = guidesnav
+	r.guides[id] = guidesnav
 }
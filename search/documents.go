@@ -0,0 +1,169 @@
+package search
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/kenjones-cisco/dapperdox/formatter"
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// latestVersion is the version of the resources documented when their page selects none.
+const latestVersion = "latest"
+
+// specificationDocuments returns the documents of a specification: its summary, its API groups, the
+// methods of each version of the groups and the resources of each version.
+func specificationDocuments(specification *spec.APISpecification) []*Document {
+	specPath := "/" + specification.ID
+
+	summary := &Document{
+		Kind:  KindSpecification,
+		Title: specification.APIInfo.Title,
+		URL:   specPath + "/reference",
+		Spec:  specification.ID,
+		Text:  plainText(specification.APIInfo.Description),
+	}
+	summary.add(titleWeight, summary.Title)
+	summary.add(descriptionWeight, summary.Text)
+
+	docs := []*Document{summary}
+
+	for _, api := range specification.APIs {
+		group := &Document{
+			Kind:    KindAPI,
+			Title:   api.Name,
+			URL:     specPath + "/reference/" + api.ID,
+			Spec:    specification.ID,
+			Group:   api.ID,
+			Version: api.CurrentVersion,
+		}
+		group.add(titleWeight, group.Title)
+
+		docs = append(docs, group)
+
+		for _, method := range api.Methods {
+			docs = append(docs, methodDocument(specification, api, method, api.CurrentVersion))
+		}
+
+		for version, methods := range api.Versions {
+			if version == api.CurrentVersion {
+				continue
+			}
+
+			for _, method := range methods {
+				docs = append(docs, methodDocument(specification, api, method, version))
+			}
+		}
+	}
+
+	for version, resources := range specification.ResourceList {
+		for id, resource := range resources {
+			docs = append(docs, resourceDocument(specification, id, resource, version))
+		}
+	}
+
+	return docs
+}
+
+// methodDocument returns the document of a version of a method, found by its name, path, parameters
+// and description.
+func methodDocument(specification *spec.APISpecification, api spec.APIGroup, method spec.Method, version string) *Document {
+	doc := &Document{
+		Kind:    KindOperation,
+		Title:   method.Name,
+		URL:     versionURL("/"+specification.ID+"/reference/"+api.ID+"/"+method.ID, version, api.CurrentVersion),
+		Spec:    specification.ID,
+		Group:   api.ID,
+		Version: version,
+		Text:    plainText(method.Description),
+	}
+
+	if doc.Title == "" {
+		doc.Title = method.ID
+	}
+
+	doc.add(titleWeight, doc.Title, method.OperationName, method.ID)
+	doc.add(pathWeight, strings.ToUpper(method.Method)+" "+method.Path)
+	doc.add(descriptionWeight, doc.Text)
+
+	params := [][]spec.Parameter{method.PathParams, method.QueryParams, method.HeaderParams, method.CookieParams, method.FormParams}
+	if method.BodyParam != nil {
+		params = append(params, []spec.Parameter{*method.BodyParam})
+	}
+
+	for _, list := range params {
+		for _, p := range list {
+			doc.add(nameWeight, p.Name)
+			doc.add(descriptionWeight, plainText(p.Description))
+		}
+	}
+
+	return doc
+}
+
+// resourceDocument returns the document of a version of a resource, found by its title, the names of its
+// properties and its description.
+func resourceDocument(specification *spec.APISpecification, id string, resource *spec.Resource, version string) *Document {
+	doc := &Document{
+		Kind:    KindResource,
+		Title:   resource.Title,
+		URL:     versionURL("/"+specification.ID+"/resources/"+id, version, latestVersion),
+		Spec:    specification.ID,
+		Version: version,
+		Text:    plainText(resource.Description),
+	}
+
+	if doc.Title == "" {
+		doc.Title = id
+	}
+
+	doc.add(titleWeight, doc.Title, id)
+	doc.add(descriptionWeight, doc.Text)
+	addProperties(doc, resource, make(map[*spec.Resource]bool))
+
+	return doc
+}
+
+// addProperties indexes the names and descriptions of the properties of a resource, and of theirs.
+func addProperties(doc *Document, resource *spec.Resource, seen map[*spec.Resource]bool) {
+	if seen[resource] {
+		return
+	}
+
+	seen[resource] = true
+
+	for name, property := range resource.Properties {
+		doc.add(nameWeight, name)
+		doc.add(descriptionWeight, plainText(property.Description))
+		addProperties(doc, property, seen)
+	}
+}
+
+// guideDocuments returns the documents of guides, found by their title and text.
+func guideDocuments(guides []Guide) []*Document {
+	docs := make([]*Document, 0, len(guides))
+
+	for _, g := range guides {
+		doc := &Document{Kind: KindGuide, Title: g.Title, URL: g.Route, Spec: g.Spec, Text: g.Text}
+		doc.add(titleWeight, doc.Title)
+		doc.add(descriptionWeight, doc.Text)
+
+		docs = append(docs, doc)
+	}
+
+	return docs
+}
+
+// versionURL returns the URL of the page of a version, selecting it unless it is the one the page documents
+// by default.
+func versionURL(path, version, defaultVersion string) string {
+	if version == defaultVersion {
+		return path
+	}
+
+	return path + "?" + url.Values{"v": {version}}.Encode()
+}
+
+func plainText(description string) string {
+	return formatter.PlainText([]byte(description))
+}
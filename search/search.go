@@ -0,0 +1,426 @@
+// Package search indexes the reference documentation and guides of a Suite for full-text search.
+package search
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+// Kinds of the documents indexed.
+const (
+	KindSpecification = "specification"
+	KindAPI           = "api"
+	KindOperation     = "operation"
+	KindResource      = "resource"
+	KindGuide         = "guide"
+)
+
+// Weights of the fields of a document, scoring the terms matched in them.
+const (
+	titleWeight       = 8
+	pathWeight        = 4
+	nameWeight        = 3
+	descriptionWeight = 1
+)
+
+// guidesSegment keys the segment of the guides among those of the specifications.
+const guidesSegment = ""
+
+// snippetLength is the length of text around the first match given as the highlight of a result.
+const snippetLength = 160
+
+// kindOrder orders the results of equal score.
+var kindOrder = map[string]int{
+	KindOperation:     0,
+	KindResource:      1,
+	KindAPI:           2,
+	KindGuide:         3,
+	KindSpecification: 4,
+}
+
+// Document is a page of the documentation found by a search.
+type Document struct {
+	Kind    string `json:"kind"`
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Spec    string `json:"spec,omitempty"`    // Spec is the ID of the specification documented
+	Group   string `json:"group,omitempty"`   // Group is the ID of the API group documented
+	Version string `json:"version,omitempty"` // Version is the version of the API documented
+	Text    string `json:"-"`                 // Text is the plain text of the document, highlighted in results
+
+	terms map[string]float64 // weight of each term of the document
+}
+
+// Guide is a guide page to be indexed.
+type Guide struct {
+	Spec  string // Spec is the ID of the specification the guide belongs to, empty for top level guides
+	Route string
+	Title string
+	Text  string
+}
+
+// Query is a search of the documents of an index, optionally restricted to a specification, API group or
+// version.
+type Query struct {
+	Text    string
+	Spec    string
+	Group   string
+	Version string
+}
+
+// Result is a document matching a query, with the matches of its title and text highlighted.
+type Result struct {
+	*Document
+	Score          float64 `json:"score"`
+	TitleHighlight string  `json:"titleHighlight"` // HTML of the title, matches marked
+	Highlight      string  `json:"highlight"`      // HTML of the text around the first match, matches marked
+}
+
+// Index is the full-text index of the documents of a Suite, in a segment per specification along with
+// a segment of the guides. An Index is never modified once built, so it is safe for concurrent use.
+type Index struct {
+	segments map[string]*segment
+}
+
+// segment indexes a set of documents, whose revision is identified by a digest.
+type segment struct {
+	digest string
+	docs   []*Document
+	terms  map[string][]int // documents holding each term
+	sorted []string         // terms in order, for prefix matching
+}
+
+// New builds the index of the documents of a suite and of its guides. The segments of previous, which
+// may be nil, are reused for the specifications and guides that have not changed since, so reloading
+// the documentation only indexes what changed.
+func New(suite *spec.Suite, guides []Guide, previous *Index) *Index {
+	idx := &Index{segments: make(map[string]*segment)}
+	reused := 0
+
+	for id, specification := range suite.Specs {
+		if seg := previous.segment(id, specification.Digest); seg != nil {
+			idx.segments[id] = seg
+			reused++
+
+			continue
+		}
+
+		idx.segments[id] = newSegment(specification.Digest, specificationDocuments(specification))
+	}
+
+	digest := guidesDigest(guides)
+	if seg := previous.segment(guidesSegment, digest); seg != nil {
+		idx.segments[guidesSegment] = seg
+	} else {
+		idx.segments[guidesSegment] = newSegment(digest, guideDocuments(guides))
+	}
+
+	log().Debugf("Indexed %d documents, reusing the index of %d specifications", idx.Len(), reused)
+
+	return idx
+}
+
+// Len returns the number of documents indexed.
+func (idx *Index) Len() int {
+	n := 0
+	for _, seg := range idx.segments {
+		n += len(seg.docs)
+	}
+
+	return n
+}
+
+// segment returns the segment of a revision, nil when the index does not hold it.
+func (idx *Index) segment(id, digest string) *segment {
+	if idx == nil || digest == "" {
+		return nil
+	}
+
+	if seg, ok := idx.segments[id]; ok && seg.digest == digest {
+		return seg
+	}
+
+	return nil
+}
+
+// Search returns the documents matching every term of a query, best first. A term matches the terms of
+// a document it is a prefix of, scoring less than an exact match.
+func (idx *Index) Search(q Query) []Result {
+	terms := queryTerms(q.Text)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var results []Result
+
+	for _, seg := range idx.segments {
+		for doc, score := range seg.search(terms) {
+			if (q.Spec != "" && doc.Spec != q.Spec) || (q.Group != "" && doc.Group != q.Group) ||
+				(q.Version != "" && doc.Version != q.Version) {
+				continue
+			}
+
+			results = append(results, Result{Document: doc, Score: score})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+
+		switch {
+		case a.Score != b.Score:
+			return a.Score > b.Score
+		case a.Kind != b.Kind:
+			return kindOrder[a.Kind] < kindOrder[b.Kind]
+		case a.Title != b.Title:
+			return a.Title < b.Title
+		}
+
+		return a.URL < b.URL
+	})
+
+	h := newHighlighter(terms)
+	for i := range results {
+		results[i].TitleHighlight = h.mark(results[i].Title)
+		results[i].Highlight = h.mark(h.snippet(results[i].Text))
+	}
+
+	return results
+}
+
+func newSegment(digest string, docs []*Document) *segment {
+	seg := &segment{digest: digest, docs: docs, terms: make(map[string][]int)}
+
+	for i, doc := range docs {
+		for term := range doc.terms {
+			seg.terms[term] = append(seg.terms[term], i)
+		}
+	}
+
+	seg.sorted = make([]string, 0, len(seg.terms))
+	for term := range seg.terms {
+		seg.sorted = append(seg.sorted, term)
+	}
+
+	sort.Strings(seg.sorted)
+
+	return seg
+}
+
+// search returns the score of each document of the segment matching every term.
+func (seg *segment) search(terms []string) map[*Document]float64 {
+	var scores map[int]float64
+
+	for _, term := range terms {
+		termScores := make(map[int]float64)
+
+		for i := sort.SearchStrings(seg.sorted, term); i < len(seg.sorted) && strings.HasPrefix(seg.sorted[i], term); i++ {
+			match := seg.sorted[i]
+
+			for _, d := range seg.terms[match] {
+				score := seg.docs[d].terms[match]
+				if match != term {
+					score /= 2
+				}
+
+				if score > termScores[d] {
+					termScores[d] = score
+				}
+			}
+		}
+
+		if scores == nil {
+			scores = termScores
+
+			continue
+		}
+
+		for d := range scores {
+			if s, ok := termScores[d]; ok {
+				scores[d] += s
+			} else {
+				delete(scores, d)
+			}
+		}
+	}
+
+	matches := make(map[*Document]float64, len(scores))
+	for d, score := range scores {
+		matches[seg.docs[d]] = score
+	}
+
+	return matches
+}
+
+// add indexes the terms of texts in a document, with the weight of the field they are in.
+func (doc *Document) add(weight float64, texts ...string) {
+	if doc.terms == nil {
+		doc.terms = make(map[string]float64)
+	}
+
+	for _, text := range texts {
+		for _, term := range terms(text) {
+			if weight > doc.terms[term] {
+				doc.terms[term] = weight
+			}
+		}
+	}
+}
+
+// terms returns the lower case words of a text, along with the parts of words in camel case, such as
+// pet and id of petId.
+func terms(text string) []string {
+	var list []string
+
+	for _, word := range strings.FieldsFunc(text, isSeparator) {
+		list = append(list, strings.ToLower(word))
+
+		if parts := camelParts(word); len(parts) > 1 {
+			list = append(list, parts...)
+		}
+	}
+
+	return list
+}
+
+// queryTerms returns the distinct lower case words of a query.
+func queryTerms(text string) []string {
+	var list []string
+
+	seen := make(map[string]bool)
+
+	for _, word := range strings.FieldsFunc(text, isSeparator) {
+		word = strings.ToLower(word)
+		if !seen[word] {
+			seen[word] = true
+			list = append(list, word)
+		}
+	}
+
+	return list
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// camelParts returns the lower case parts of a word in camel case.
+func camelParts(word string) []string {
+	var (
+		parts []string
+		start int
+	)
+
+	runes := []rune(word)
+
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsUpper(runes[i]) && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			parts = append(parts, strings.ToLower(string(runes[start:i])))
+			start = i
+		}
+	}
+
+	return append(parts, strings.ToLower(string(runes[start:])))
+}
+
+// highlighter marks the words of a text starting with the terms of a query.
+type highlighter struct {
+	re *regexp.Regexp // matches a word starting with a term as its second group
+}
+
+func newHighlighter(terms []string) *highlighter {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+
+	return &highlighter{
+		re: regexp.MustCompile(`(?i)(^|[^\pL\pN])((?:` + strings.Join(quoted, "|") + `)[\pL\pN]*)`),
+	}
+}
+
+// mark escapes text as HTML, with the words starting with a term marked.
+func (h *highlighter) mark(text string) string {
+	var b strings.Builder
+
+	last := 0
+
+	for _, m := range h.re.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:m[4]]))
+		b.WriteString("<mark>" + html.EscapeString(text[m[4]:m[5]]) + "</mark>")
+		last = m[5]
+	}
+
+	b.WriteString(html.EscapeString(text[last:]))
+
+	return b.String()
+}
+
+// snippet returns the text around the first word starting with a term, cut at spaces.
+func (h *highlighter) snippet(text string) string {
+	if len(text) <= snippetLength {
+		return text
+	}
+
+	first := 0
+	if m := h.re.FindStringSubmatchIndex(text); m != nil {
+		first = m[4]
+	}
+
+	// Start a little before the match, or early enough for the snippet to end with the text.
+	start := first - snippetLength/4
+	if start+snippetLength > len(text) {
+		start = len(text) - snippetLength
+	}
+
+	if start <= 0 {
+		start = 0
+	} else if space := strings.IndexByte(text[start:first], ' '); space >= 0 {
+		start += space + 1
+	}
+
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start++
+	}
+
+	end := start + snippetLength
+	if end >= len(text) {
+		end = len(text)
+	} else if space := strings.LastIndexByte(text[first:end], ' '); space > 0 {
+		end = first + space
+	}
+
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end--
+	}
+
+	s := text[start:end]
+	if start > 0 {
+		s = "… " + s
+	}
+
+	if end < len(text) {
+		s += " …"
+	}
+
+	return s
+}
+
+// guidesDigest identifies the revision of a set of guides.
+func guidesDigest(guides []Guide) string {
+	h := sha256.New()
+
+	for _, g := range guides {
+		_, _ = fmt.Fprintf(h, "%q %q %q %q\n", g.Spec, g.Route, g.Title, g.Text)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
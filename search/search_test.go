@@ -0,0 +1,168 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+	"github.com/kenjones-cisco/dapperdox/spec"
+)
+
+var testGuides = []Guide{
+	{Route: "/guides/getting-started", Title: "Getting started", Text: "Request an API key before making your first booking of a room."},
+	{Spec: "bookings", Route: "/bookings/guides/cancelling", Title: "Cancelling", Text: "Bookings are cancelled by the legacy API only."},
+}
+
+func loadSuite(t *testing.T, file string) *spec.Suite {
+	t.Helper()
+
+	cfg := config.New()
+	cfg.Set(config.SpecDir, "../fixtures/")
+	cfg.Set(config.SpecFilename, []string{file})
+
+	suite, err := spec.LoadSpecifications(cfg, nil)
+	if err != nil {
+		t.Fatalf("LoadSpecifications() error = %v", err)
+	}
+
+	return suite
+}
+
+func TestIndex_Search(t *testing.T) {
+	idx := New(loadSuite(t, "bookings_api.json"), testGuides, nil)
+
+	tests := []struct {
+		name      string
+		query     Query
+		wantURLs  []string // URLs of the results, in order
+		wantFirst string   // highlighted title of the first result
+	}{
+		{
+			name:  "group name, parameter and property names, and text",
+			query: Query{Text: "room"},
+			wantURLs: []string{
+				"/bookings/reference/bookings-of-rooms",
+				"/bookings/reference/bookings-of-rooms/list-bookings-v2",
+				"/bookings/resources/booking",
+				"/guides/getting-started",
+				"/bookings/reference",
+			},
+			wantFirst: "Bookings of <mark>rooms</mark>",
+		},
+		{
+			name:  "every term matched",
+			query: Query{Text: "list bookings v1"},
+			wantURLs: []string{
+				"/bookings/reference/legacy-bookings/list-bookings-v1",
+			},
+			wantFirst: "<mark>List</mark> <mark>bookings</mark>",
+		},
+		{
+			name:  "prefix",
+			query: Query{Text: "cancel"},
+			wantURLs: []string{
+				"/bookings/guides/cancelling",
+			},
+			wantFirst: "<mark>Cancelling</mark>",
+		},
+		{
+			name:  "path",
+			query: Query{Text: "v1", Group: "legacy-bookings"},
+			wantURLs: []string{
+				"/bookings/reference/legacy-bookings/list-bookings-v1",
+			},
+		},
+		{
+			name:     "filtered by specification",
+			query:    Query{Text: "room", Spec: "another"},
+			wantURLs: nil,
+		},
+		{
+			name:  "filtered by version",
+			query: Query{Text: "booking", Version: "latest", Group: "bookings-of-rooms"},
+			wantURLs: []string{
+				"/bookings/reference/bookings-of-rooms/list-bookings-v2",
+				"/bookings/reference/bookings-of-rooms",
+			},
+		},
+		{
+			name:     "no terms",
+			query:    Query{Text: " - "},
+			wantURLs: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := idx.Search(tt.query)
+
+			urls := make([]string, len(results))
+			for i, r := range results {
+				urls[i] = r.URL
+			}
+
+			if len(urls) != len(tt.wantURLs) {
+				t.Fatalf("Search() = %v, want %v", urls, tt.wantURLs)
+			}
+
+			for i := range urls {
+				if urls[i] != tt.wantURLs[i] {
+					t.Errorf("Search() = %v, want %v", urls, tt.wantURLs)
+
+					break
+				}
+			}
+
+			if tt.wantFirst != "" && results[0].TitleHighlight != tt.wantFirst {
+				t.Errorf("Search() first title = %s, want %s", results[0].TitleHighlight, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestNew_Reuse(t *testing.T) {
+	suite := loadSuite(t, "bookings_api.json")
+	previous := New(suite, testGuides, nil)
+
+	reloaded := loadSuite(t, "bookings_api.json")
+	idx := New(reloaded, testGuides, previous)
+
+	if idx.segments["bookings"] != previous.segments["bookings"] {
+		t.Error("New() rebuilt the index of an unchanged specification")
+	}
+
+	if idx.segments[guidesSegment] != previous.segments[guidesSegment] {
+		t.Error("New() rebuilt the index of unchanged guides")
+	}
+
+	reloaded.Specs["bookings"].Digest = "changed"
+	changedGuides := append([]Guide{{Route: "/guides/new", Title: "New"}}, testGuides...)
+
+	idx = New(reloaded, changedGuides, previous)
+
+	if idx.segments["bookings"] == previous.segments["bookings"] {
+		t.Error("New() reused the index of a changed specification")
+	}
+
+	if idx.segments[guidesSegment] == previous.segments[guidesSegment] {
+		t.Error("New() reused the index of changed guides")
+	}
+
+	if got := len(idx.Search(Query{Text: "new"})); got != 1 {
+		t.Errorf("Search() found %d results of the guide added, want 1", got)
+	}
+}
+
+func TestHighlighter_snippet(t *testing.T) {
+	long := "Rooms are booked by the hour. " +
+		"A booking holds the room from its start to its end, during which no other booking of the room is accepted. " +
+		"Bookings are confirmed by email, which gives the <reference> of the booking needed to cancel it."
+
+	h := newHighlighter([]string{"cancel"})
+
+	got := h.mark(h.snippet(long))
+	want := "… end, during which no other booking of the room is accepted. Bookings are confirmed by email, which gives the " +
+		"&lt;reference&gt; of the booking needed to <mark>cancel</mark> it."
+
+	if got != want {
+		t.Errorf("snippet() = %q, want %q", got, want)
+	}
+}
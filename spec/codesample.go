@@ -0,0 +1,47 @@
+package spec
+
+import (
+	"github.com/go-openapi/spec"
+)
+
+// CodeSample is a request snippet declared by an operation, replacing the one generated for its language.
+type CodeSample struct {
+	Lang   string
+	Label  string
+	Source string
+}
+
+// getCodeSamples returns the code samples declared by the x-codeSamples extension of an operation,
+// or by its older x-code-samples spelling. Samples without a language or source are ignored.
+func getCodeSamples(exts spec.Extensions) []CodeSample {
+	declared, ok := exts[codeSamplesExt].([]interface{})
+	if !ok {
+		if declared, ok = exts[codeSamplesLegacyExt].([]interface{}); !ok {
+			return nil
+		}
+	}
+
+	var samples []CodeSample
+
+	for _, d := range declared {
+		sample, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		cs := CodeSample{}
+		cs.Lang, _ = sample["lang"].(string)
+		cs.Label, _ = sample["label"].(string)
+		cs.Source, _ = sample["source"].(string)
+
+		if cs.Lang == "" || cs.Source == "" {
+			log().Warnf("Ignoring code sample without lang or source: %v", sample)
+
+			continue
+		}
+
+		samples = append(samples, cs)
+	}
+
+	return samples
+}
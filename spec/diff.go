@@ -0,0 +1,451 @@
+package spec
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+)
+
+// all defined kinds of Change.
+const (
+	OperationRemoved        = "operation-removed"
+	OperationAdded          = "operation-added"
+	ParameterAdded          = "parameter-added"
+	ParameterRemoved        = "parameter-removed"
+	ParameterRequired       = "parameter-required"
+	TypeChanged             = "type-changed"
+	EnumNarrowed            = "enum-narrowed"
+	EnumWidened             = "enum-widened"
+	PropertyAdded           = "property-added"
+	PropertyRemoved         = "property-removed"
+	PropertyRequired        = "property-required"
+	ResponseRemoved         = "response-removed"
+	ResponseAdded           = "response-added"
+	SecurityChanged         = "security-changed"
+	RequestBodyAdded        = "request-body-added"
+	RequestBodyRemoved      = "request-body-removed"
+	ResponseResourceRemoved = "response-resource-removed"
+)
+
+// Change is a difference found between two revisions of a specification, located by the
+// IDs the documentation uses for the API group, method and resource.
+type Change struct {
+	Kind     string `json:"kind"`
+	Breaking bool   `json:"breaking"`
+	API      string `json:"api"`
+	Method   string `json:"method"`
+	Resource string `json:"resource,omitempty"`
+	Location string `json:"location,omitempty"` // Parameter, property or status code concerned
+	Message  string `json:"message"`
+}
+
+// Diff lists the changes between two revisions of a specification.
+type Diff struct {
+	Old     string   `json:"old"`
+	New     string   `json:"new"`
+	Changes []Change `json:"changes"`
+}
+
+// Breaking returns the changes that may break clients of the old revision.
+func (d *Diff) Breaking() []Change {
+	var changes []Change
+
+	for _, c := range d.Changes {
+		if c.Breaking {
+			changes = append(changes, c)
+		}
+	}
+
+	return changes
+}
+
+// LoadSpecification loads a single specification from a file or URL, outside of any Suite.
+// Problems found while loading are recorded in its Errors.
+func LoadSpecification(cfg *viper.Viper, location string) *APISpecification {
+	document, err := loadSpec(location, newReplacer(cfg), "")
+	if err != nil {
+		return newBrokenSpecification(location, err)
+	}
+
+	specification := &APISpecification{
+		statusCodes: loadStatusCodes(cfg, ""),
+		defaultHost: cfg.GetString(config.SpecDefaultHost),
+	}
+	specification.safeLoad(location, document)
+
+	return specification
+}
+
+// Compare reports the changes from the old to the new revision of a specification. Methods are
+// matched by API group and method ID, as they are in the reference documentation routes.
+func Compare(oldSpec, newSpec *APISpecification) *Diff {
+	d := &Diff{
+		Old:     oldSpec.URL,
+		New:     newSpec.URL,
+		Changes: []Change{},
+	}
+
+	oldMethods := methodsByRoute(oldSpec)
+	newMethods := methodsByRoute(newSpec)
+
+	for _, route := range sortedKeys(oldMethods) {
+		om := oldMethods[route]
+
+		nm, ok := newMethods[route]
+		if !ok {
+			d.add(om, true, OperationRemoved, "", "", "operation %s %s was removed", strings.ToUpper(om.Method), om.Path)
+
+			continue
+		}
+
+		d.compareMethods(om, nm)
+	}
+
+	for _, route := range sortedKeys(newMethods) {
+		if _, ok := oldMethods[route]; !ok {
+			nm := newMethods[route]
+			d.add(nm, false, OperationAdded, "", "", "operation %s %s was added", strings.ToUpper(nm.Method), nm.Path)
+		}
+	}
+
+	return d
+}
+
+func (d *Diff) add(m *Method, breaking bool, kind, resource, location, format string, args ...interface{}) {
+	d.Changes = append(d.Changes, Change{
+		Kind:     kind,
+		Breaking: breaking,
+		API:      m.APIGroup.ID,
+		Method:   m.ID,
+		Resource: resource,
+		Location: location,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *Diff) compareMethods(om, nm *Method) {
+	d.compareParameters(nm, parametersByLocation(om), parametersByLocation(nm))
+	d.compareRequestBody(om, nm)
+	d.compareResponses(om, nm)
+	d.compareSecurity(nm, om.Security, nm.Security)
+}
+
+func (d *Diff) compareParameters(m *Method, oldParams, newParams map[string]Parameter) {
+	for _, key := range sortedKeys(oldParams) {
+		op := oldParams[key]
+
+		np, ok := newParams[key]
+		if !ok {
+			d.add(m, false, ParameterRemoved, "", key, "%s parameter %s was removed", op.In, op.Name)
+
+			continue
+		}
+
+		if !op.Required && np.Required {
+			d.add(m, true, ParameterRequired, "", key, "%s parameter %s is now required", np.In, np.Name)
+		}
+
+		if ot, nt := strings.Join(op.Type, " of "), strings.Join(np.Type, " of "); ot != nt {
+			d.add(m, true, TypeChanged, "", key, "%s parameter %s changed type from %s to %s", np.In, np.Name, ot, nt)
+		}
+
+		d.compareEnums(m, "", key, fmt.Sprintf("%s parameter %s", np.In, np.Name), op.Enum, np.Enum, true)
+	}
+
+	for _, key := range sortedKeys(newParams) {
+		if _, ok := oldParams[key]; ok {
+			continue
+		}
+
+		np := newParams[key]
+		if np.Required {
+			d.add(m, true, ParameterAdded, "", key, "required %s parameter %s was added", np.In, np.Name)
+		} else {
+			d.add(m, false, ParameterAdded, "", key, "optional %s parameter %s was added", np.In, np.Name)
+		}
+	}
+}
+
+func (d *Diff) compareRequestBody(om, nm *Method) {
+	switch {
+	case om.BodyParam == nil && nm.BodyParam == nil:
+		return
+	case om.BodyParam == nil:
+		d.add(nm, nm.BodyParam.Required, RequestBodyAdded, nm.BodyParam.Resource.ID, "body", "request body %s was added", nm.BodyParam.Resource.ID)
+
+		return
+	case nm.BodyParam == nil:
+		d.add(nm, false, RequestBodyRemoved, om.BodyParam.Resource.ID, "body", "request body %s was removed", om.BodyParam.Resource.ID)
+
+		return
+	}
+
+	if !om.BodyParam.Required && nm.BodyParam.Required {
+		d.add(nm, true, ParameterRequired, nm.BodyParam.Resource.ID, "body", "request body is now required")
+	}
+
+	if om.BodyParam.IsArray != nm.BodyParam.IsArray {
+		d.add(nm, true, TypeChanged, nm.BodyParam.Resource.ID, "body", "request body changed from %s to %s",
+			arrayDescription(om.BodyParam.IsArray), arrayDescription(nm.BodyParam.IsArray))
+	}
+
+	d.compareProperties(nm, nm.BodyParam.Resource.ID, "", "", om.BodyParam.Resource, nm.BodyParam.Resource, true)
+}
+
+func (d *Diff) compareResponses(om, nm *Method) {
+	oldResponses := responsesByStatus(om)
+	newResponses := responsesByStatus(nm)
+
+	for _, status := range sortedKeys(oldResponses) {
+		or := oldResponses[status]
+
+		nr, ok := newResponses[status]
+		if !ok {
+			d.add(nm, true, ResponseRemoved, "", status, "response %s was removed", status)
+
+			continue
+		}
+
+		d.compareResponse(nm, status, &or, &nr)
+	}
+
+	for _, status := range sortedKeys(newResponses) {
+		if _, ok := oldResponses[status]; !ok {
+			d.add(nm, false, ResponseAdded, "", status, "response %s was added", status)
+		}
+	}
+
+	if om.DefaultResponse != nil && nm.DefaultResponse != nil {
+		d.compareResponse(nm, "default", om.DefaultResponse, nm.DefaultResponse)
+	}
+}
+
+func (d *Diff) compareResponse(m *Method, location string, or, nr *Response) {
+	if or.Resource == nil {
+		return
+	}
+
+	if nr.Resource == nil {
+		d.add(m, true, ResponseResourceRemoved, or.Resource.ID, location, "response %s no longer returns a %s resource", location, or.Resource.ID)
+
+		return
+	}
+
+	if or.IsArray != nr.IsArray {
+		d.add(m, true, TypeChanged, nr.Resource.ID, location, "response %s changed from %s to %s", location, arrayDescription(or.IsArray), arrayDescription(nr.IsArray))
+	}
+
+	d.compareProperties(m, nr.Resource.ID, location, "", or.Resource, nr.Resource, false)
+}
+
+// compareProperties compares the properties of a resource, recursively. Changes that make a request
+// stricter, or a response looser, than clients of the old revision expect are breaking. The status
+// code is that of the response the resource is returned by, empty for a request body.
+func (d *Diff) compareProperties(m *Method, resource, status, parent string, or, nr *Resource, isRequest bool) {
+	for _, name := range sortedKeys(or.Properties) {
+		op := or.Properties[name]
+		location, subject := propertyLocation(status, parent, name)
+
+		np, ok := nr.Properties[name]
+		if !ok {
+			// Clients may rely on a response property, but a server ignoring a request property does not break them.
+			d.add(m, !isRequest, PropertyRemoved, resource, location, "%s was removed", subject)
+
+			continue
+		}
+
+		if isRequest && !op.Required && np.Required {
+			d.add(m, true, PropertyRequired, resource, location, "%s is now required", subject)
+		}
+
+		if ot, nt := strings.Join(op.Type, " of "), strings.Join(np.Type, " of "); ot != nt {
+			d.add(m, true, TypeChanged, resource, location, "%s changed type from %s to %s", subject, ot, nt)
+		}
+
+		d.compareEnums(m, resource, location, subject, op.Enum, np.Enum, isRequest)
+		d.compareProperties(m, resource, status, parent+name+".", op, np, isRequest)
+	}
+
+	for _, name := range sortedKeys(nr.Properties) {
+		if _, ok := or.Properties[name]; ok {
+			continue
+		}
+
+		location, subject := propertyLocation(status, parent, name)
+
+		if isRequest && nr.Properties[name].Required {
+			d.add(m, true, PropertyAdded, resource, location, "required %s was added", subject)
+		} else {
+			d.add(m, false, PropertyAdded, resource, location, "%s was added", subject)
+		}
+	}
+}
+
+// compareEnums reports enum values removed from, or added to, a parameter or property. Fewer
+// values break clients sending requests, more values break clients reading responses.
+func (d *Diff) compareEnums(m *Method, resource, location, subject string, oldEnum, newEnum []string, isRequest bool) {
+	switch {
+	case len(newEnum) == 0:
+		// No enum, or an enum removed altogether, accepts any value.
+		if len(oldEnum) > 0 {
+			d.add(m, !isRequest, EnumWidened, resource, location, "%s is no longer restricted to %s", subject, strings.Join(oldEnum, ", "))
+		}
+
+		return
+	case len(oldEnum) == 0:
+		d.add(m, isRequest, EnumNarrowed, resource, location, "%s is now restricted to %s", subject, strings.Join(newEnum, ", "))
+
+		return
+	}
+
+	if removed := missing(oldEnum, newEnum); len(removed) > 0 {
+		d.add(m, isRequest, EnumNarrowed, resource, location, "%s no longer allows %s", subject, strings.Join(removed, ", "))
+	}
+
+	if added := missing(newEnum, oldEnum); len(added) > 0 {
+		d.add(m, !isRequest, EnumWidened, resource, location, "%s now allows %s", subject, strings.Join(added, ", "))
+	}
+}
+
+// compareSecurity reports security schemes and scopes added to, or removed from, a method.
+// Additional requirements break clients, fewer do not. Schemes are compared by name, as a
+// method may require a different scheme of the same type.
+func (d *Diff) compareSecurity(m *Method, oldSecurity, newSecurity map[string]Security) {
+	oldSec := securityByName(oldSecurity)
+	newSec := securityByName(newSecurity)
+
+	for _, name := range sortedKeys(newSec) {
+		ns := newSec[name]
+
+		os, ok := oldSec[name]
+		if !ok {
+			d.add(m, true, SecurityChanged, "", name, "%s security is now required", name)
+
+			continue
+		}
+
+		if added := missing(sortedKeys(ns.Scopes), sortedKeys(os.Scopes)); len(added) > 0 {
+			d.add(m, true, SecurityChanged, "", name, "%s security now requires the %s scope(s)", name, strings.Join(added, ", "))
+		}
+
+		if removed := missing(sortedKeys(os.Scopes), sortedKeys(ns.Scopes)); len(removed) > 0 {
+			d.add(m, false, SecurityChanged, "", name, "%s security no longer requires the %s scope(s)", name, strings.Join(removed, ", "))
+		}
+	}
+
+	for _, name := range sortedKeys(oldSec) {
+		if _, ok := newSec[name]; !ok {
+			d.add(m, false, SecurityChanged, "", name, "%s security is no longer required", name)
+		}
+	}
+}
+
+// methodsByRoute returns the methods of the current version of a specification keyed by
+// their reference documentation route: API group ID/method ID.
+func methodsByRoute(s *APISpecification) map[string]*Method {
+	methods := make(map[string]*Method)
+
+	for i := range s.APIs {
+		api := &s.APIs[i]
+
+		for j := range api.Methods {
+			m := &api.Methods[j]
+			methods[api.ID+"/"+m.ID] = m
+		}
+	}
+
+	return methods
+}
+
+// parametersByLocation returns the non-body parameters of a method keyed by location and name.
+func parametersByLocation(m *Method) map[string]Parameter {
+	params := make(map[string]Parameter)
+
+	for _, list := range [][]Parameter{m.PathParams, m.QueryParams, m.HeaderParams, m.CookieParams, m.FormParams} {
+		for _, p := range list {
+			params[p.In+":"+p.Name] = p
+		}
+	}
+
+	return params
+}
+
+// responsesByStatus returns the responses of a method keyed by status code or, for OpenAPI 3.0
+// specifications, by status code range such as 2XX.
+func responsesByStatus(m *Method) map[string]Response {
+	responses := make(map[string]Response, len(m.Responses)+len(m.ResponseRanges))
+
+	for status, r := range m.Responses {
+		responses[strconv.Itoa(status)] = r
+	}
+
+	for code, r := range m.ResponseRanges {
+		responses[code] = r
+	}
+
+	return responses
+}
+
+// securityByName returns the security requirements of a method keyed by scheme name.
+func securityByName(security map[string]Security) map[string]Security {
+	byName := make(map[string]Security, len(security))
+
+	for _, sec := range security {
+		byName[sec.Scheme.Name] = sec
+	}
+
+	return byName
+}
+
+// missing returns the values of a not found in b.
+func missing(a, b []string) []string {
+	found := make(map[string]bool, len(b))
+	for _, v := range b {
+		found[v] = true
+	}
+
+	var values []string
+
+	for _, v := range a {
+		if !found[v] {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// propertyLocation returns the location of a property, prefixed by the status code of the response
+// returning it, and the subject of the messages describing its changes.
+func propertyLocation(status, parent, name string) (string, string) {
+	if status == "" {
+		return parent + name, "property " + parent + name
+	}
+
+	return status + ":" + parent + name, fmt.Sprintf("property %s of response %s", parent+name, status)
+}
+
+func arrayDescription(isArray bool) string {
+	if isArray {
+		return "an array"
+	}
+
+	return "a single resource"
+}
+
+// sortedKeys returns the keys of a map, sorted.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
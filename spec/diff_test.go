@@ -0,0 +1,106 @@
+package spec
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kenjones-cisco/dapperdox/config"
+)
+
+func TestCompare(t *testing.T) {
+	cfg := config.New()
+
+	oldSpec := LoadSpecification(cfg, testSpecDir+"diff_v1_api.json")
+	newSpec := LoadSpecification(cfg, testSpecDir+"diff_v2_api.json")
+
+	if len(oldSpec.Errors) > 0 || len(newSpec.Errors) > 0 {
+		t.Fatalf("LoadSpecification() errors = %v, %v", oldSpec.Errors, newSpec.Errors)
+	}
+
+	d := Compare(oldSpec, newSpec)
+
+	tests := []struct {
+		name     string
+		method   string
+		kind     string
+		location string
+		breaking bool
+	}{
+		{name: "removed operation", method: "delete-order", kind: OperationRemoved, breaking: true},
+		{name: "added operation", method: "cancel-order", kind: OperationAdded},
+		{name: "new required parameter", method: "list-orders", kind: ParameterAdded, location: "query:region", breaking: true},
+		{name: "new optional parameter", method: "list-orders", kind: ParameterAdded, location: "query:page"},
+		{name: "changed parameter type", method: "list-orders", kind: TypeChanged, location: "query:limit", breaking: true},
+		{name: "narrowed request enum", method: "list-orders", kind: EnumNarrowed, location: "query:status", breaking: true},
+		{name: "widened response enum", method: "get-order", kind: EnumWidened, location: "200:status", breaking: true},
+		{name: "removed response property", method: "get-order", kind: PropertyRemoved, location: "200:total", breaking: true},
+		{name: "added response property", method: "get-order", kind: PropertyAdded, location: "200:created"},
+		{name: "added response", method: "get-order", kind: ResponseAdded, location: "410"},
+		{name: "removed response", method: "create-order", kind: ResponseRemoved, location: "400", breaking: true},
+		{name: "request property now required", method: "create-order", kind: PropertyRequired, location: "quantity", breaking: true},
+		{name: "removed request property", method: "create-order", kind: PropertyRemoved, location: "note"},
+		{name: "added optional request property", method: "create-order", kind: PropertyAdded, location: "gift"},
+		{name: "added security scope", method: "list-orders", kind: SecurityChanged, location: "oauth", breaking: true},
+		{name: "replaced security scheme", method: "get-order", kind: SecurityChanged, location: "partner_key", breaking: true},
+		{name: "removed security scheme", method: "get-order", kind: SecurityChanged, location: "api_key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, c := range d.Changes {
+				if c.Method != tt.method || c.Kind != tt.kind || c.Location != tt.location {
+					continue
+				}
+
+				if c.API != "orders" {
+					t.Errorf("API = %q, want %q", c.API, "orders")
+				}
+
+				if c.Breaking != tt.breaking {
+					t.Errorf("Breaking = %v, want %v: %s", c.Breaking, tt.breaking, c.Message)
+				}
+
+				return
+			}
+
+			t.Errorf("change not found in %+v", d.Changes)
+		})
+	}
+
+	if got := len(d.Breaking()); got != 14 {
+		t.Errorf("Breaking() = %d changes, want 14", got)
+	}
+
+	if same := Compare(oldSpec, oldSpec); len(same.Changes) != 0 {
+		t.Errorf("Compare() of a specification with itself = %+v, want no changes", same.Changes)
+	}
+}
+
+func TestCompare_responseRanges(t *testing.T) {
+	cfg := config.New()
+
+	oldSpec := LoadSpecification(cfg, testSpecDir+"openapi3_api.json")
+	newSpec := LoadSpecification(cfg, testSpecDir+"openapi3_api.json")
+
+	if len(oldSpec.Errors) > 0 || len(newSpec.Errors) > 0 {
+		t.Fatalf("LoadSpecification() errors = %v, %v", oldSpec.Errors, newSpec.Errors)
+	}
+
+	m, ok := methodsByRoute(newSpec)["pets/list-pets"]
+	if !ok {
+		t.Fatalf("method pets/list-pets not found in %v", methodsByRoute(newSpec))
+	}
+
+	m.ResponseRanges["5XX"] = m.ResponseRanges["4XX"]
+	delete(m.ResponseRanges, "4XX")
+
+	d := Compare(oldSpec, newSpec)
+
+	want := []Change{
+		{Kind: ResponseRemoved, Breaking: true, API: "pets", Method: "list-pets", Location: "4XX", Message: "response 4XX was removed"},
+		{Kind: ResponseAdded, API: "pets", Method: "list-pets", Location: "5XX", Message: "response 5XX was added"},
+	}
+
+	if !reflect.DeepEqual(d.Changes, want) {
+		t.Errorf("Compare() = %+v, want %+v", d.Changes, want)
+	}
+}
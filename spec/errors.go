@@ -0,0 +1,107 @@
+package spec
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// LoadError describes a problem found while loading a specification.
+type LoadError struct {
+	Spec    string // Location of the specification
+	Pointer string // JSON pointer to the offending member, empty if the problem concerns the whole document
+	Message string
+}
+
+// Error implements the error interface.
+func (e *LoadError) Error() string {
+	if e.Pointer == "" {
+		return fmt.Sprintf("%s: %s", e.Spec, e.Message)
+	}
+
+	return fmt.Sprintf("%s#%s: %s", e.Spec, e.Pointer, e.Message)
+}
+
+// LoadErrors lists the problems found while loading specifications.
+type LoadErrors []*LoadError
+
+// Error implements the error interface.
+func (e LoadErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Errors returns the problems found while loading the specifications of the Suite,
+// ordered by specification ID.
+func (s *Suite) Errors() LoadErrors {
+	ids := make([]string, 0, len(s.Specs))
+	for id := range s.Specs {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	var errs LoadErrors
+	for _, id := range ids {
+		errs = append(errs, s.Specs[id].Errors...)
+	}
+
+	return errs
+}
+
+// addError records a problem found at the JSON pointer location of the specification.
+func (c *APISpecification) addError(pointer, format string, args ...interface{}) {
+	err := &LoadError{
+		Spec:    c.URL,
+		Pointer: pointer,
+		Message: fmt.Sprintf(format, args...),
+	}
+
+	log().Errorf("Error: %s", err)
+
+	c.Errors = append(c.Errors, err)
+}
+
+// newBrokenSpecification creates a specification for a document that could not be loaded at all,
+// so that its problems can still be reported.
+func newBrokenSpecification(specLocation string, err error) *APISpecification {
+	name := locationName(specLocation)
+
+	c := &APISpecification{
+		ID:      titleToKebab(name),
+		URL:     specLocation,
+		GroupBy: "default",
+	}
+	c.APIInfo.Title = name
+	c.addError("", "%s", err)
+
+	return c
+}
+
+// locationName derives a name for a specification from its location, for use when the
+// specification does not provide a title. Discovered specifications are named after the
+// service they were discovered from.
+func locationName(specLocation string) string {
+	name := path.Base(strings.TrimSuffix(specLocation, "/api.json"))
+
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// JSONPointer builds a JSON pointer (RFC 6901) from unescaped reference tokens.
+func JSONPointer(tokens ...string) string {
+	var b strings.Builder
+
+	for _, t := range tokens {
+		b.WriteString("/")
+		b.WriteString(pointerEscaper.Replace(t))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,195 @@
+package spec
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/kenjones-cisco/dapperdox/spec/openapi3"
+)
+
+// formatExamples holds example values of the well known string formats.
+var formatExamples = map[string]string{
+	"date":      "2019-08-24",
+	"date-time": "2019-08-24T14:15:22Z",
+	"time":      "14:15:22Z",
+	"duration":  "P3DT4H",
+	"uuid":      "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"email":     "user@example.com",
+	"hostname":  "api.example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"uri":       "https://example.com/path",
+	"url":       "https://example.com/path",
+	"uri-ref":   "/path",
+	"byte":      "U3dhZ2dlciByb2Nrcw==",
+	"binary":    "<binary>",
+	"password":  "********",
+}
+
+// numberFormats holds the formats of the integer and number types.
+var numberFormats = map[string]bool{
+	"int32":  true,
+	"int64":  true,
+	"float":  false,
+	"double": false,
+}
+
+// Example is a named example of a resource.
+type Example struct {
+	Name        string
+	Summary     string
+	Description string
+	Value       string
+}
+
+// exampleValue returns an example value for a property of a primitive type: the example or default
+// value it declares, its first enum value, a value suited to its format, or a value of its type
+// respecting its bounds. A string of a format without a known example is a placeholder naming it.
+func exampleValue(s *spec.Schema) interface{} {
+	switch {
+	case s.Example != nil:
+		return s.Example
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	}
+
+	if example, ok := formatExamples[s.Format]; ok {
+		return example
+	}
+
+	kind := ""
+
+	for _, t := range s.Type {
+		switch t {
+		case "integer", "number", "boolean", "string", "file":
+			kind = t
+		}
+	}
+
+	// A format may be declared without a type.
+	if isInteger, ok := numberFormats[s.Format]; ok && kind == "" {
+		kind = "number"
+		if isInteger {
+			kind = "integer"
+		}
+	}
+
+	switch kind {
+	case "integer":
+		return int64(numberExample(s, 1))
+	case "number":
+		return numberExample(s, 0.5)
+	case "boolean":
+		return true
+	case "string":
+		if s.Format != "" {
+			return "<" + s.Format + ">"
+		}
+
+		return stringExample(s)
+	case "file":
+		return formatExamples["binary"]
+	}
+
+	return nil
+}
+
+// setGeneratedExample sets the example of a resource that declares none to the JSON representation
+// generated from its schema.
+func (r *Resource) setGeneratedExample() {
+	if r.Example == "" {
+		r.Example = r.Schema
+	}
+}
+
+// numberExample returns a number within the bounds of a schema, zero if it has none.
+func numberExample(s *spec.Schema, step float64) float64 {
+	v := 0.0
+
+	switch {
+	case s.Minimum != nil:
+		v = *s.Minimum
+		if s.ExclusiveMinimum {
+			v += step
+		}
+	case s.Maximum != nil && *s.Maximum <= v:
+		v = *s.Maximum
+		if s.ExclusiveMaximum {
+			v -= step
+		}
+	}
+
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		v = math.Ceil(v / *s.MultipleOf) * *s.MultipleOf
+	}
+
+	if s.Maximum != nil && v > *s.Maximum && s.Minimum != nil {
+		v = (*s.Minimum + *s.Maximum) / 2
+	}
+
+	return v
+}
+
+// stringExample returns a string whose length is within the bounds of a schema.
+func stringExample(s *spec.Schema) string {
+	example := "string"
+
+	if s.MinLength != nil && int64(len(example)) < *s.MinLength {
+		example = strings.Repeat(example, int(*s.MinLength)/len(example)+1)[:*s.MinLength]
+	}
+
+	if s.MaxLength != nil && int64(len(example)) > *s.MaxLength {
+		example = example[:*s.MaxLength]
+	}
+
+	return example
+}
+
+// namedExamples returns the named examples declared by the x-examples extension of a schema, a map
+// of name to OpenAPI 3.0 Example Object, ordered by name. Converted OpenAPI 3.0 documents carry the
+// examples of a media type in it.
+func namedExamples(s *spec.Schema) []Example {
+	declared, ok := s.Extensions[openapi3.ExamplesExt].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	examples := make([]Example, 0, len(names))
+
+	for _, name := range names {
+		ex, ok := declared[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		value, err := jsonMarshalIndent(ex["value"])
+		if err != nil {
+			log().Errorf("Error encoding example %s json: %s", name, err)
+
+			continue
+		}
+
+		example := Example{
+			Name:  name,
+			Value: string(value),
+		}
+		example.Summary, _ = ex["summary"].(string)
+		example.Description, _ = ex["description"].(string)
+
+		examples = append(examples, example)
+	}
+
+	return examples
+}
@@ -0,0 +1,163 @@
+package spec
+
+import (
+	"encoding/json"
+
+	"github.com/go-openapi/loads"
+	"github.com/go-openapi/spec"
+
+	"github.com/kenjones-cisco/dapperdox/spec/openapi3"
+)
+
+// statusRangeDescriptions describes the status code ranges (e.g. 2XX) of OpenAPI 3.0 responses.
+var statusRangeDescriptions = map[byte]string{
+	'1': "Informational",
+	'2': "Success",
+	'3': "Redirection",
+	'4': "Client error",
+	'5': "Server error",
+}
+
+// Server is a server hosting the API, as declared by an OpenAPI 3.0 specification.
+type Server struct {
+	URL         string
+	Description string
+}
+
+// analyzeDocument parses a raw specification, converting OpenAPI 3.0 documents into the
+// Swagger 2.0 object model, and returns the analyzed document with all references expanded.
+func analyzeDocument(raw []byte) (*loads.Document, error) {
+	document, err := parseDocument(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return expandDocument(document)
+}
+
+// parseDocument parses a raw specification, converting OpenAPI 3.0 documents into the Swagger 2.0
+// object model, and returns the analyzed document, whose references are left to be expanded.
+// Discriminator mappings and the $ref of the definitions are recorded before expansion, while
+// references are still known.
+func parseDocument(raw []byte) (*loads.Document, error) {
+	if openapi3.IsOpenAPI3(raw) {
+		log().Debug("Converting OpenAPI 3.0 specification")
+
+		converted, err := openapi3.Convert(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		raw = converted
+	}
+
+	document, err := loads.Analyzed(json.RawMessage(raw), "")
+	if err != nil {
+		return nil, err
+	}
+
+	mapped := addDiscriminatorMappings(document.Spec())
+	if addDefinitionRefs(document.Spec()) || mapped {
+		if raw, err = json.Marshal(document.Spec()); err != nil {
+			return nil, err
+		}
+
+		if document, err = loads.Analyzed(json.RawMessage(raw), ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return document, nil
+}
+
+// expandDocument expands the references of a document, including those of the OpenAPI 3.0
+// range responses, which are held by a vendor extension unknown to go-openapi.
+func expandDocument(document *loads.Document) (*loads.Document, error) {
+	document, err := document.Expanded()
+	if err != nil {
+		return nil, err
+	}
+
+	root := document.Spec()
+	if root.Paths == nil {
+		return document, nil
+	}
+
+	for _, pathItem := range root.Paths.Paths {
+		for _, op := range []*spec.Operation{pathItem.Get, pathItem.Put, pathItem.Post, pathItem.Delete, pathItem.Options, pathItem.Head, pathItem.Patch} {
+			if op == nil {
+				continue
+			}
+
+			ext, ok := op.Extensions[openapi3.ResponseRangesExt]
+			if !ok {
+				continue
+			}
+
+			raw, err := json.Marshal(ext)
+			if err != nil {
+				return nil, err
+			}
+
+			ranges := make(map[string]spec.Response)
+			if err := json.Unmarshal(raw, &ranges); err != nil {
+				return nil, err
+			}
+
+			for code, response := range ranges {
+				r := response
+				if err := spec.ExpandResponseWithRoot(&r, root, nil); err != nil {
+					return nil, err
+				}
+
+				ranges[code] = r
+			}
+
+			op.Extensions[openapi3.ResponseRangesExt] = ranges
+		}
+	}
+
+	return document, nil
+}
+
+// getServers returns the servers recorded when converting an OpenAPI 3.0 specification.
+func getServers(exts spec.Extensions) []Server {
+	list, ok := exts[openapi3.ServersExt].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	servers := make([]Server, 0, len(list))
+
+	for _, s := range list {
+		server, _ := s.(map[string]interface{})
+		u, _ := server["url"].(string)
+		desc, _ := server["description"].(string)
+
+		servers = append(servers, Server{URL: u, Description: desc})
+	}
+
+	return servers
+}
+
+// getContentTypes returns the media types a response may be returned as.
+func getContentTypes(exts spec.Extensions) []string {
+	list, ok := exts[openapi3.ContentTypesExt].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	types := make([]string, 0, len(list))
+
+	for _, t := range list {
+		if s, ok := t.(string); ok {
+			types = append(types, s)
+		}
+	}
+
+	return types
+}
+
+func httpStatusRangeDescription(code string) string {
+	return statusRangeDescriptions[code[0]]
+}
@@ -0,0 +1,908 @@
+// Package openapi3 converts OpenAPI 3.0 documents into the Swagger 2.0 object model
+// understood by go-openapi, so that both versions are parsed by the same code path.
+// Details of a 3.0 document that have no Swagger 2.0 equivalent are carried across
+// as vendor extensions.
+package openapi3
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/swag"
+	wraperrors "github.com/pkg/errors"
+)
+
+// Vendor extensions holding the OpenAPI 3.0 details without a Swagger 2.0 equivalent.
+const (
+	// VersionExt holds the original OpenAPI version of a converted document.
+	VersionExt = "x-openapi-version"
+	// ServersExt holds the list of servers declared by the document.
+	ServersExt = "x-servers"
+	// ContentTypesExt holds the media types a response may be returned as.
+	ContentTypesExt = "x-content-types"
+	// ResponseRangesExt holds the responses of an operation keyed by status code range (e.g. 2XX).
+	ResponseRangesExt = "x-response-ranges"
+	// DiscriminatorMappingExt holds the discriminator value to schema mapping of a schema.
+	DiscriminatorMappingExt = "x-discriminator-mapping"
+	// BearerFormatExt holds the format of a bearer token security scheme.
+	BearerFormatExt = "x-bearer-format"
+	// OpenIDConnectURLExt holds the discovery URL of an openIdConnect security scheme.
+	OpenIDConnectURLExt = "x-openid-connect-url"
+	// RequestBodyNameExt names the body parameter created from a request body.
+	RequestBodyNameExt = "x-codegen-request-body-name"
+	// ExamplesExt holds the named examples of a media type, on the schema of the request body or response.
+	ExamplesExt = "x-examples"
+)
+
+const (
+	componentsPrefix  = "#/components/"
+	schemasPrefix     = "#/components/schemas/"
+	definitionsPrefix = "#/definitions/"
+
+	defaultBodyName = "body"
+	jsonMediaType   = "application/json"
+	maxRefDepth     = 32
+)
+
+var (
+	methods         = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+	formMediaTypes  = []string{"application/x-www-form-urlencoded", "multipart/form-data"}
+	rangeStatusCode = regexp.MustCompile(`^[1-5][xX][xX]$`)
+	serverVariable  = regexp.MustCompile(`{([^}]+)}`)
+
+	// simpleSchemaKeys are the schema members shared with Swagger 2.0 non-body parameters, items and headers.
+	simpleSchemaKeys = []string{
+		"format", "enum", "default", "maximum", "exclusiveMaximum", "minimum", "exclusiveMinimum",
+		"maxLength", "minLength", "pattern", "maxItems", "minItems", "uniqueItems", "multipleOf",
+	}
+
+	// oauth2Flows maps OpenAPI 3.0 flows to the Swagger 2.0 flow names, in order of preference.
+	oauth2Flows = [][2]string{
+		{"authorizationCode", "accessCode"},
+		{"implicit", "implicit"},
+		{"password", "password"},
+		{"clientCredentials", "application"},
+	}
+)
+
+// IsOpenAPI3 reports whether the raw document, in JSON or YAML, declares an OpenAPI 3.x version.
+func IsOpenAPI3(raw []byte) bool {
+	doc, err := decode(raw)
+	if err != nil {
+		return false
+	}
+
+	version, _ := doc["openapi"].(string)
+
+	return strings.HasPrefix(version, "3.")
+}
+
+// Convert converts a raw OpenAPI 3.0 document, in JSON or YAML, into a Swagger 2.0 JSON document.
+func Convert(raw []byte) (json.RawMessage, error) {
+	doc, err := decode(raw)
+	if err != nil {
+		return nil, wraperrors.Wrap(err, "unable to decode OpenAPI document")
+	}
+
+	c := &converter{components: object(doc["components"])}
+
+	out, err := c.convert(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(out)
+}
+
+// ToJSON returns the JSON of a raw document, in JSON or YAML.
+func ToJSON(raw []byte) (json.RawMessage, error) {
+	data := bytes.TrimSpace(raw)
+
+	if len(data) > 0 && data[0] != '{' {
+		yml, err := swag.BytesToYAMLDoc(data)
+		if err != nil {
+			return nil, err
+		}
+
+		return swag.YAMLToJSON(yml)
+	}
+
+	return data, nil
+}
+
+func decode(raw []byte) (map[string]interface{}, error) {
+	data, err := ToJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+type converter struct {
+	components map[string]interface{}
+}
+
+func (c *converter) convert(doc map[string]interface{}) (map[string]interface{}, error) {
+	out := map[string]interface{}{"swagger": "2.0"}
+
+	copyExtensions(doc, out)
+	copyMembers(doc, out, "info", "tags", "security", "externalDocs")
+
+	out[VersionExt] = doc["openapi"]
+
+	if err := c.convertServers(array(doc["servers"]), out); err != nil {
+		return nil, err
+	}
+
+	if schemas := object(c.components["schemas"]); len(schemas) > 0 {
+		definitions := make(map[string]interface{}, len(schemas))
+		for name, schema := range schemas {
+			definition, ok := convertSchema(schema).(map[string]interface{})
+			if ok && definition["title"] == nil {
+				// a component is identified by its name, which the reference documentation needs as a title
+				definition["title"] = name
+			}
+
+			definitions[name] = definition
+		}
+
+		out["definitions"] = definitions
+	}
+
+	if schemes := object(c.components["securitySchemes"]); len(schemes) > 0 {
+		definitions := make(map[string]interface{}, len(schemes))
+
+		for name, scheme := range schemes {
+			s, err := c.resolve(scheme)
+			if err != nil {
+				return nil, err
+			}
+
+			definitions[name] = ConvertSecurityScheme(s)
+		}
+
+		out["securityDefinitions"] = definitions
+	}
+
+	paths := make(map[string]interface{})
+
+	for path, item := range object(doc["paths"]) {
+		if strings.HasPrefix(strings.ToLower(path), "x-") {
+			paths[path] = item
+
+			continue
+		}
+
+		pathItem, err := c.convertPathItem(item)
+		if err != nil {
+			return nil, wraperrors.Wrapf(err, "path %s", path)
+		}
+
+		paths[path] = pathItem
+	}
+
+	out["paths"] = paths
+
+	return out, nil
+}
+
+// convertServers records every server of the document and derives the Swagger 2.0
+// host, basePath and schemes from the first one.
+func (c *converter) convertServers(servers []interface{}, out map[string]interface{}) error {
+	list := make([]interface{}, 0, len(servers))
+
+	for i, s := range servers {
+		server := object(s)
+		variables := object(server["variables"])
+
+		serverURL, _ := server["url"].(string)
+		serverURL = serverVariable.ReplaceAllStringFunc(serverURL, func(v string) string {
+			if def, ok := object(variables[v[1:len(v)-1]])["default"].(string); ok {
+				return def
+			}
+
+			return v
+		})
+
+		list = append(list, map[string]interface{}{
+			"url":         serverURL,
+			"description": server["description"],
+		})
+
+		if i > 0 {
+			continue
+		}
+
+		u, err := url.Parse(serverURL)
+		if err != nil {
+			return wraperrors.Wrapf(err, "invalid server url %q", serverURL)
+		}
+
+		if u.Host != "" {
+			out["host"] = u.Host
+		}
+
+		if u.Scheme != "" {
+			out["schemes"] = []interface{}{u.Scheme}
+		}
+
+		if basePath := strings.TrimSuffix(u.Path, "/"); basePath != "" {
+			out["basePath"] = basePath
+		}
+	}
+
+	if len(list) > 0 {
+		out[ServersExt] = list
+	}
+
+	return nil
+}
+
+func (c *converter) convertPathItem(item interface{}) (map[string]interface{}, error) {
+	pathItem, err := c.resolve(item)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]interface{})
+	copyExtensions(pathItem, out)
+
+	params, err := c.convertParameters(array(pathItem["parameters"]))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+
+	for _, method := range methods {
+		op, ok := pathItem[method]
+		if !ok {
+			continue
+		}
+
+		operation, err := c.convertOperation(object(op))
+		if err != nil {
+			return nil, wraperrors.Wrapf(err, "operation %s", method)
+		}
+
+		out[method] = operation
+	}
+
+	return out, nil
+}
+
+func (c *converter) convertOperation(op map[string]interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+
+	copyExtensions(op, out)
+	copyMembers(op, out, "tags", "summary", "description", "operationId", "deprecated", "security", "externalDocs")
+
+	params, err := c.convertParameters(array(op["parameters"]))
+	if err != nil {
+		return nil, err
+	}
+
+	if rb, ok := op["requestBody"]; ok {
+		requestBody, err := c.resolve(rb)
+		if err != nil {
+			return nil, err
+		}
+
+		body, consumes, err := c.convertRequestBody(requestBody)
+		if err != nil {
+			return nil, err
+		}
+
+		params = append(params, body...)
+
+		if len(consumes) > 0 {
+			out["consumes"] = consumes
+		}
+	}
+
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+
+	if rs, ok := op["responses"]; ok {
+		responses, ranges, produces, err := c.convertResponses(object(rs))
+		if err != nil {
+			return nil, err
+		}
+
+		out["responses"] = responses
+
+		if len(ranges) > 0 {
+			out[ResponseRangesExt] = ranges
+		}
+
+		if len(produces) > 0 {
+			out["produces"] = produces
+		}
+	}
+
+	return out, nil
+}
+
+func (c *converter) convertParameters(params []interface{}) ([]interface{}, error) {
+	out := make([]interface{}, 0, len(params))
+
+	for _, p := range params {
+		param, err := c.resolve(p)
+		if err != nil {
+			return nil, err
+		}
+
+		converted, err := c.convertParameter(param)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, converted)
+	}
+
+	return out, nil
+}
+
+func (c *converter) convertParameter(param map[string]interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+
+	copyExtensions(param, out)
+	copyMembers(param, out, "name", "in", "description", "required")
+
+	in, _ := param["in"].(string)
+	if in == "path" {
+		out["required"] = true
+	}
+
+	schema := object(param["schema"])
+	if schema == nil {
+		// A parameter may describe its value by a single media type instead of a schema.
+		for _, media := range object(param["content"]) {
+			schema = object(object(media)["schema"])
+
+			break
+		}
+	}
+
+	if err := c.applySimpleSchema(schema, out); err != nil {
+		return nil, wraperrors.Wrapf(err, "parameter %v", param["name"])
+	}
+
+	if example, ok := param["example"]; ok {
+		out["x-example"] = example
+	}
+
+	if out["type"] == "array" {
+		out["collectionFormat"] = collectionFormat(in, param)
+	}
+
+	return out, nil
+}
+
+// convertRequestBody converts a request body into either a body parameter or, when only form
+// media types are accepted, a set of formData parameters. It also returns the accepted media types.
+func (c *converter) convertRequestBody(rb map[string]interface{}) ([]interface{}, []string, error) {
+	content := object(rb["content"])
+	mediaTypes := sortedKeys(content)
+
+	if mt := formMediaType(mediaTypes); mt != "" {
+		params, err := c.convertFormParameters(object(object(content[mt])["schema"]))
+
+		return params, mediaTypes, err
+	}
+
+	mt := preferredMediaType(mediaTypes)
+	media := object(content[mt])
+
+	schema, _ := convertSchema(media["schema"]).(map[string]interface{})
+	if schema == nil {
+		schema = map[string]interface{}{"type": "string"}
+	}
+
+	if err := c.applyMediaExamples(media, schema); err != nil {
+		return nil, nil, err
+	}
+
+	name := defaultBodyName
+	if n, ok := rb[RequestBodyNameExt].(string); ok && n != "" {
+		name = n
+	}
+
+	body := map[string]interface{}{
+		"name":     name,
+		"in":       "body",
+		"required": rb["required"] == true,
+		"schema":   schema,
+	}
+	copyMembers(rb, body, "description")
+
+	return []interface{}{body}, mediaTypes, nil
+}
+
+func (c *converter) convertFormParameters(s map[string]interface{}) ([]interface{}, error) {
+	schema, err := c.resolveSchema(s)
+	if err != nil {
+		return nil, err
+	}
+
+	required := make(map[string]bool)
+	for _, r := range array(schema["required"]) {
+		if name, ok := r.(string); ok {
+			required[name] = true
+		}
+	}
+
+	properties := object(schema["properties"])
+	params := make([]interface{}, 0, len(properties))
+
+	for _, name := range sortedKeys(properties) {
+		prop, err := c.resolveSchema(object(properties[name]))
+		if err != nil {
+			return nil, err
+		}
+
+		param := map[string]interface{}{
+			"name":     name,
+			"in":       "formData",
+			"required": required[name],
+		}
+		copyMembers(prop, param, "description")
+
+		if prop["type"] == "string" && (prop["format"] == "binary" || prop["format"] == "base64") {
+			param["type"] = "file"
+		} else if err := c.applySimpleSchema(prop, param); err != nil {
+			return nil, err
+		}
+
+		if param["type"] == "array" {
+			param["collectionFormat"] = "multi"
+		}
+
+		params = append(params, param)
+	}
+
+	return params, nil
+}
+
+// convertResponses splits the responses into those keyed by status code (and default),
+// and those keyed by a status code range. It also returns the media types produced.
+func (c *converter) convertResponses(rs map[string]interface{}) (map[string]interface{}, map[string]interface{}, []string, error) {
+	responses := make(map[string]interface{})
+	ranges := make(map[string]interface{})
+	produces := make(map[string]interface{})
+
+	for code, r := range rs {
+		if strings.HasPrefix(strings.ToLower(code), "x-") {
+			responses[code] = r
+
+			continue
+		}
+
+		resp, err := c.resolve(r)
+		if err != nil {
+			return nil, nil, nil, wraperrors.Wrapf(err, "response %s", code)
+		}
+
+		response, mediaTypes, err := c.convertResponse(resp)
+		if err != nil {
+			return nil, nil, nil, wraperrors.Wrapf(err, "response %s", code)
+		}
+
+		for _, mt := range mediaTypes {
+			produces[mt] = true
+		}
+
+		if rangeStatusCode.MatchString(code) {
+			ranges[strings.ToUpper(code)] = response
+		} else {
+			responses[code] = response
+		}
+	}
+
+	return responses, ranges, sortedKeys(produces), nil
+}
+
+func (c *converter) convertResponse(resp map[string]interface{}) (map[string]interface{}, []string, error) {
+	out := map[string]interface{}{"description": ""}
+
+	copyExtensions(resp, out)
+	copyMembers(resp, out, "description")
+
+	content := object(resp["content"])
+	mediaTypes := sortedKeys(content)
+
+	if len(mediaTypes) > 0 {
+		media := object(content[preferredMediaType(mediaTypes)])
+
+		if schema, ok := convertSchema(media["schema"]).(map[string]interface{}); ok {
+			if err := c.applyMediaExamples(media, schema); err != nil {
+				return nil, nil, err
+			}
+
+			out["schema"] = schema
+		}
+
+		out[ContentTypesExt] = mediaTypes
+	}
+
+	if hs := object(resp["headers"]); len(hs) > 0 {
+		headers := make(map[string]interface{}, len(hs))
+
+		for name, h := range hs {
+			header, err := c.resolve(h)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			converted := make(map[string]interface{})
+			copyExtensions(header, converted)
+			copyMembers(header, converted, "description")
+
+			if err := c.applySimpleSchema(object(header["schema"]), converted); err != nil {
+				return nil, nil, wraperrors.Wrapf(err, "header %s", name)
+			}
+
+			headers[name] = converted
+		}
+
+		out["headers"] = headers
+	}
+
+	return out, mediaTypes, nil
+}
+
+// applySimpleSchema copies the type information of a schema onto a Swagger 2.0 non-body
+// parameter, header or items object, which cannot reference a schema.
+func (c *converter) applySimpleSchema(s, out map[string]interface{}) error {
+	schema, err := c.resolveSchema(s)
+	if err != nil {
+		return err
+	}
+
+	stype, _ := schema["type"].(string)
+	if stype == "" || stype == "object" {
+		stype = "string"
+	}
+
+	out["type"] = stype
+
+	copyMembers(schema, out, simpleSchemaKeys...)
+
+	if stype == "array" {
+		items := make(map[string]interface{})
+		if err := c.applySimpleSchema(object(schema["items"]), items); err != nil {
+			return err
+		}
+
+		out["items"] = items
+	}
+
+	return nil
+}
+
+// resolve follows a reference to a component other than a schema, as those are not carried
+// across to the converted document.
+func (c *converter) resolve(v interface{}) (map[string]interface{}, error) {
+	obj := object(v)
+
+	for depth := 0; depth < maxRefDepth; depth++ {
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj, nil
+		}
+
+		if !strings.HasPrefix(ref, componentsPrefix) {
+			return nil, wraperrors.Errorf("unsupported reference %q", ref)
+		}
+
+		var target interface{} = c.components
+
+		for _, token := range strings.Split(strings.TrimPrefix(ref, componentsPrefix), "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			target = object(target)[token]
+		}
+
+		if target == nil {
+			return nil, wraperrors.Errorf("unresolved reference %q", ref)
+		}
+
+		obj = object(target)
+	}
+
+	return nil, wraperrors.New("too many nested references")
+}
+
+func (c *converter) resolveSchema(s map[string]interface{}) (map[string]interface{}, error) {
+	if s == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	return c.resolve(s)
+}
+
+// ConvertSecurityScheme converts an OpenAPI 3.0 security scheme into a Swagger 2.0 security definition.
+func ConvertSecurityScheme(s map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{})
+
+	copyExtensions(s, out)
+	copyMembers(s, out, "description")
+
+	stype, _ := s["type"].(string)
+
+	switch stype {
+	case "apiKey":
+		out["type"] = stype
+		copyMembers(s, out, "name", "in")
+	case "http":
+		scheme, _ := s["scheme"].(string)
+
+		switch strings.ToLower(scheme) {
+		case "basic":
+			out["type"] = "basic"
+		case "bearer":
+			out["type"] = "bearer"
+			if format, ok := s["bearerFormat"]; ok {
+				out[BearerFormatExt] = format
+			}
+		default:
+			out["type"] = stype
+			out["name"] = scheme
+		}
+	case "oauth2":
+		out["type"] = stype
+		flows := object(s["flows"])
+		scopes := make(map[string]interface{})
+
+		for _, f := range oauth2Flows {
+			flow, ok := flows[f[0]].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			if _, ok := out["flow"]; !ok {
+				out["flow"] = f[1]
+				copyMembers(flow, out, "authorizationUrl", "tokenUrl")
+			}
+
+			for scope, desc := range object(flow["scopes"]) {
+				scopes[scope] = desc
+			}
+		}
+
+		out["scopes"] = scopes
+	case "openIdConnect":
+		out["type"] = stype
+		out[OpenIDConnectURLExt] = s["openIdConnectUrl"]
+	default:
+		out["type"] = stype
+	}
+
+	return out
+}
+
+// convertSchema rewrites a schema so that it is valid as a Swagger 2.0 schema: references
+// to component schemas become references to definitions, and a discriminator object
+// becomes the discriminator property name plus a mapping extension.
+func convertSchema(v interface{}) interface{} {
+	schema, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+
+	out := make(map[string]interface{}, len(schema))
+
+	for k, val := range schema {
+		switch k {
+		case "$ref":
+			out[k] = convertRef(val)
+		case "discriminator":
+			d, ok := val.(map[string]interface{})
+			if !ok {
+				out[k] = val
+
+				continue
+			}
+
+			out[k] = d["propertyName"]
+
+			if mapping := object(d["mapping"]); len(mapping) > 0 {
+				converted := make(map[string]interface{}, len(mapping))
+				for name, ref := range mapping {
+					converted[name] = convertRef(ref)
+				}
+
+				out[DiscriminatorMappingExt] = converted
+			}
+		case "properties":
+			props := object(val)
+			converted := make(map[string]interface{}, len(props))
+
+			for name, prop := range props {
+				converted[name] = convertSchema(prop)
+			}
+
+			out[k] = converted
+		case "items", "additionalProperties", "not":
+			out[k] = convertSchema(val)
+		case "allOf", "oneOf", "anyOf":
+			list := array(val)
+			converted := make([]interface{}, 0, len(list))
+
+			for _, s := range list {
+				converted = append(converted, convertSchema(s))
+			}
+
+			out[k] = converted
+		default:
+			out[k] = val
+		}
+	}
+
+	return out
+}
+
+func convertRef(v interface{}) interface{} {
+	if ref, ok := v.(string); ok && strings.HasPrefix(ref, schemasPrefix) {
+		return definitionsPrefix + strings.TrimPrefix(ref, schemasPrefix)
+	}
+
+	return v
+}
+
+// applyMediaExamples carries the example and named examples of a media type onto an inline schema
+// without any, the latter as the ExamplesExt extension.
+func (c *converter) applyMediaExamples(media, schema map[string]interface{}) error {
+	if _, ok := schema["$ref"]; ok {
+		return nil
+	}
+
+	if _, ok := schema["example"]; !ok {
+		if example, ok := media["example"]; ok {
+			schema["example"] = example
+		}
+	}
+
+	if _, ok := schema[ExamplesExt]; ok {
+		return nil
+	}
+
+	examples := object(media["examples"])
+	if len(examples) == 0 {
+		return nil
+	}
+
+	converted := make(map[string]interface{}, len(examples))
+
+	for name, ex := range examples {
+		example, err := c.resolve(ex)
+		if err != nil {
+			return wraperrors.Wrapf(err, "example %s", name)
+		}
+
+		converted[name] = example
+	}
+
+	schema[ExamplesExt] = converted
+
+	return nil
+}
+
+func collectionFormat(in string, param map[string]interface{}) string {
+	style, _ := param["style"].(string)
+	if style == "" {
+		style = "simple"
+		if in == "query" || in == "cookie" {
+			style = "form"
+		}
+	}
+
+	explode, ok := param["explode"].(bool)
+	if !ok {
+		explode = style == "form"
+	}
+
+	switch style {
+	case "form":
+		if explode {
+			return "multi"
+		}
+
+		return "csv"
+	case "spaceDelimited":
+		return "ssv"
+	case "pipeDelimited":
+		return "pipes"
+	default:
+		return "csv"
+	}
+}
+
+func formMediaType(mediaTypes []string) string {
+	var form string
+
+	for _, mt := range mediaTypes {
+		if strings.Contains(mt, "json") {
+			return ""
+		}
+
+		for _, f := range formMediaTypes {
+			if mt == f && form == "" {
+				form = mt
+			}
+		}
+	}
+
+	return form
+}
+
+func preferredMediaType(mediaTypes []string) string {
+	for _, mt := range mediaTypes {
+		if mt == jsonMediaType {
+			return mt
+		}
+	}
+
+	for _, mt := range mediaTypes {
+		if strings.Contains(mt, "json") {
+			return mt
+		}
+	}
+
+	if len(mediaTypes) > 0 {
+		return mediaTypes[0]
+	}
+
+	return ""
+}
+
+func copyExtensions(src, dst map[string]interface{}) {
+	for k, v := range src {
+		if strings.HasPrefix(strings.ToLower(k), "x-") {
+			dst[k] = v
+		}
+	}
+}
+
+func copyMembers(src, dst map[string]interface{}, keys ...string) {
+	for _, k := range keys {
+		if v, ok := src[k]; ok {
+			dst[k] = v
+		}
+	}
+}
+
+func object(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+
+	return m
+}
+
+func array(v interface{}) []interface{} {
+	a, _ := v.([]interface{})
+
+	return a
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,180 @@
+package openapi3
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestIsOpenAPI3(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want bool
+	}{
+		{
+			name: "json 3.0 document",
+			raw:  `{"openapi": "3.0.3", "info": {"title": "test"}}`,
+			want: true,
+		},
+		{
+			name: "yaml 3.0 document",
+			raw:  "openapi: 3.0.0\ninfo:\n  title: test\n",
+			want: true,
+		},
+		{
+			name: "swagger 2.0 document",
+			raw:  `{"swagger": "2.0", "info": {"title": "test"}}`,
+			want: false,
+		},
+		{
+			name: "invalid document",
+			raw:  `{"openapi": `,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsOpenAPI3([]byte(tt.raw)); got != tt.want {
+				t.Errorf("IsOpenAPI3() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "json document",
+			raw:  ` {"swagger": "2.0"}` + "\n",
+			want: `{"swagger": "2.0"}`,
+		},
+		{
+			name: "yaml document",
+			raw:  "swagger: \"2.0\"\ninfo:\n  title: test\n",
+			want: `{"swagger":"2.0","info":{"title":"test"}}`,
+		},
+		{
+			name:    "invalid yaml document",
+			raw:     "swagger: [",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToJSON([]byte(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ToJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("ToJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	raw, err := os.ReadFile("../../fixtures/openapi3_api.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := Convert(raw)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Convert() produced invalid JSON: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "swagger version", got: doc["swagger"], want: "2.0"},
+		{name: "host from first server", got: doc["host"], want: "api.petstore.example.com"},
+		{name: "basePath from first server", got: doc["basePath"], want: "/v3"},
+		{name: "schemes from first server", got: doc["schemes"], want: []interface{}{"https"}},
+		{name: "servers extension", got: len(array(doc[ServersExt])), want: 2},
+		{name: "schema reference", got: lookup(doc, "definitions", "Pet", "properties", "owner", "$ref"), want: "#/definitions/Owner"},
+		{name: "component name as title", got: lookup(doc, "definitions", "Pet", "title"), want: "Pet"},
+		{name: "explicit title kept", got: lookup(doc, "definitions", "Problem", "title"), want: "Problem details"},
+		{name: "bearer security scheme", got: lookup(doc, "securityDefinitions", "bearerAuth", "type"), want: "bearer"},
+		{name: "oauth2 flow", got: lookup(doc, "securityDefinitions", "oauth", "flow"), want: "accessCode"},
+		{name: "parameter reference resolved", got: lookup(doc, "paths", "/pets", "get", "parameters", 0, "type"), want: "integer"},
+		{name: "collection format from style", got: lookup(doc, "paths", "/pets", "get", "parameters", 1, "collectionFormat"), want: "csv"},
+		{name: "produces from response content", got: lookup(doc, "paths", "/pets", "get", "produces"), want: []interface{}{"application/json", "application/problem+json", "application/xml"}},
+		{name: "range response", got: lookup(doc, "paths", "/pets", "get", ResponseRangesExt, "4XX", "description"), want: "A problem occurred"},
+		{name: "response header type", got: lookup(doc, "paths", "/pets", "get", "responses", "200", "headers", "X-Next", "format"), want: "uri"},
+		{name: "request body as body parameter", got: lookup(doc, "paths", "/pets", "post", "parameters", 0, "in"), want: "body"},
+		{name: "request body schema", got: lookup(doc, "paths", "/pets", "post", "parameters", 0, "schema", "$ref"), want: "#/definitions/Pet"},
+		{name: "consumes from request body content", got: lookup(doc, "paths", "/pets", "post", "consumes"), want: []interface{}{"application/json", "application/x-www-form-urlencoded"}},
+		{name: "path parameter required", got: lookup(doc, "paths", "/pets/{petId}/photo", "parameters", 0, "required"), want: true},
+		{name: "form file parameter", got: lookup(doc, "paths", "/pets/{petId}/photo", "put", "parameters", 1, "type"), want: "file"},
+		{name: "media example reference resolved", got: lookup(doc, "paths", "/pets/{petId}/photo", "get", "responses", "200", "schema", ExamplesExt, "portrait", "summary"), want: "A portrait photo"},
+		{name: "media example", got: lookup(doc, "paths", "/pets/{petId}/photo", "get", "responses", "200", "schema", ExamplesExt, "landscape", "value", "width"), want: float64(1920)},
+		{name: "form parameter location", got: lookup(doc, "paths", "/pets/{petId}/photo", "put", "parameters", 0, "in"), want: "formData"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("Convert() %s = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{
+			name: "invalid document",
+			raw:  `{"openapi": `,
+		},
+		{
+			name: "unresolved reference",
+			raw:  `{"openapi": "3.0.0", "paths": {"/a": {"get": {"parameters": [{"$ref": "#/components/parameters/missing"}]}}}}`,
+		},
+		{
+			name: "invalid server url",
+			raw:  `{"openapi": "3.0.0", "servers": [{"url": "http://[::1"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Convert([]byte(tt.raw)); err == nil {
+				t.Error("Convert() error = nil, want error")
+			}
+		})
+	}
+}
+
+func lookup(v interface{}, path ...interface{}) interface{} {
+	for _, p := range path {
+		switch key := p.(type) {
+		case string:
+			v = object(v)[key]
+		case int:
+			a := array(v)
+			if key >= len(a) {
+				return nil
+			}
+
+			v = a[key]
+		}
+	}
+
+	return v
+}
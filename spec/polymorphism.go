@@ -0,0 +1,251 @@
+package spec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/kenjones-cisco/dapperdox/spec/openapi3"
+)
+
+// all defined variant kinds of a polymorphic Resource.
+const (
+	VariantsOneOf = "oneOf"
+	VariantsAnyOf = "anyOf"
+)
+
+// DefinitionsRef is the prefix of the $ref of the definitions of a specification.
+const DefinitionsRef = "#/definitions/"
+
+// Discriminator represents the property telling the variants of a polymorphic resource apart.
+type Discriminator struct {
+	PropertyName string
+	Mapping      map[string]string // discriminator value->variant resource ID
+}
+
+// addDiscriminatorMappings records on every definition declaring a discriminator the mapping of
+// discriminator values to the definitions that may take its place. Swagger 2.0 only implies this
+// mapping through definitions extending the base definition with allOf, and references are lost
+// once the document is expanded, so the mapping is made explicit beforehand.
+// It returns true if any definition was changed.
+func addDiscriminatorMappings(s *spec.Swagger) bool {
+	var changed bool
+
+	for name, def := range s.Definitions {
+		if def.Discriminator == "" {
+			continue
+		}
+
+		mapping := make(map[string]interface{})
+		if explicit, ok := def.Extensions[openapi3.DiscriminatorMappingExt].(map[string]interface{}); ok {
+			for value, target := range explicit {
+				mapping[value] = target
+			}
+		}
+
+		// Definitions not mapped explicitly are selected by their discriminator value or name.
+		add := func(target string, sub spec.Schema) {
+			for _, t := range mapping {
+				if t == DefinitionsRef+target || t == target {
+					return
+				}
+			}
+
+			value, ok := sub.Extensions.GetString(discriminatorValueExt)
+			if !ok {
+				value = target
+			}
+
+			if _, ok := mapping[value]; !ok {
+				mapping[value] = DefinitionsRef + target
+			}
+		}
+
+		ref := DefinitionsRef + name
+
+		for subname, sub := range s.Definitions {
+			for _, parent := range sub.AllOf {
+				if parent.Ref.String() == ref {
+					add(subname, sub)
+				}
+			}
+		}
+
+		for _, variant := range append(append([]spec.Schema{}, def.OneOf...), def.AnyOf...) {
+			if target := strings.TrimPrefix(variant.Ref.String(), DefinitionsRef); target != variant.Ref.String() {
+				add(target, s.Definitions[target])
+			}
+		}
+
+		if len(mapping) == 0 {
+			continue
+		}
+
+		def.AddExtension(openapi3.DiscriminatorMappingExt, mapping)
+		s.Definitions[name] = def
+		changed = true
+	}
+
+	return changed
+}
+
+// discriminatorMapping returns the discriminator value->definition name mapping of a schema.
+func discriminatorMapping(s *spec.Schema) map[string]string {
+	mapping := make(map[string]string)
+
+	ext, ok := s.Extensions[openapi3.DiscriminatorMappingExt].(map[string]interface{})
+	if !ok {
+		return mapping
+	}
+
+	for value, target := range ext {
+		if t, ok := target.(string); ok {
+			mapping[value] = strings.TrimPrefix(t, DefinitionsRef)
+		}
+	}
+
+	return mapping
+}
+
+// compileVariants adds to a resource the alternative resources that a polymorphic schema may
+// take the form of, either listed by oneOf/anyOf or mapped by its discriminator. When the
+// resource has no properties of its own, its JSON representation becomes that of the first variant.
+func (c *APISpecification) compileVariants(s *spec.Schema, r *Resource, method *Method, jsonRep map[string]interface{}, isRequestResource bool) {
+	kind := VariantsOneOf
+	schemas := s.OneOf
+
+	if len(schemas) == 0 && len(s.AnyOf) > 0 {
+		kind = VariantsAnyOf
+		schemas = s.AnyOf
+	}
+
+	// value->definition name, and the values of each definition keyed by its title
+	mapping := discriminatorMapping(s)
+	values := make(map[string]string)
+
+	valueKeys := make([]string, 0, len(mapping))
+	for value := range mapping {
+		valueKeys = append(valueKeys, value)
+	}
+
+	sort.Strings(valueKeys)
+
+	for _, value := range valueKeys {
+		title := definitionTitle(mapping[value], c.definitions[mapping[value]])
+		if _, ok := values[title]; !ok {
+			values[title] = value
+		}
+	}
+
+	if len(schemas) == 0 && s.Discriminator != "" {
+		// Swagger 2.0 inheritance: the variants are the definitions mapped by the discriminator.
+		for _, value := range valueKeys {
+			def, ok := c.definitions[mapping[value]]
+			if !ok || values[definitionTitle(mapping[value], def)] != value {
+				continue
+			}
+
+			def.Title = definitionTitle(mapping[value], def)
+			schemas = append(schemas, def)
+		}
+	}
+
+	if len(schemas) == 0 {
+		return
+	}
+
+	r.VariantKind = kind
+
+	if s.Discriminator != "" {
+		r.Discriminator = &Discriminator{
+			PropertyName: s.Discriminator,
+			Mapping:      make(map[string]string),
+		}
+	}
+
+	if c.variantsInProgress == nil {
+		c.variantsInProgress = make(map[string]bool)
+	}
+
+	for i := range schemas {
+		variant := schemas[i]
+		if variant.Title == "" {
+			name := r.Title
+			if name == "" {
+				name = r.ID
+			}
+
+			variant.Title = fmt.Sprintf("%s option %d", name, i+1)
+		}
+
+		// A variant may refer back to the polymorphic resource, so do not expand it again.
+		if c.variantsInProgress[variant.Title] {
+			log().Tracef("Variant %s of %s is already being compiled", variant.Title, r.ID)
+
+			continue
+		}
+
+		c.variantsInProgress[variant.Title] = true
+		vr, vjson, isArray := c.resourceFromSchema(&variant, method, nil, isRequestResource)
+		delete(c.variantsInProgress, variant.Title)
+
+		if vr == nil {
+			continue
+		}
+
+		if value, ok := values[variant.Title]; ok && r.Discriminator != nil {
+			vr.DiscriminatorValue = value
+			vjson[r.Discriminator.PropertyName] = value
+		}
+
+		vr.Schema = jsonResourceToString(vjson, isArray)
+		r.Variants = append(r.Variants, vr)
+
+		if len(r.Properties) == 0 && len(jsonRep) == 0 {
+			for k, v := range vjson {
+				jsonRep[k] = v
+			}
+		}
+	}
+
+	if r.Discriminator != nil {
+		for _, value := range valueKeys {
+			for _, vr := range r.Variants {
+				if vr.Title == definitionTitle(mapping[value], c.definitions[mapping[value]]) {
+					r.Discriminator.Mapping[value] = vr.ID
+				}
+			}
+		}
+	}
+}
+
+// crossLinkVariants cross links the variants found within a resource with the method, so that
+// each variant is documented as a resource of the specification.
+func (c *APISpecification) crossLinkVariants(resource *Resource, method *Method, version string) {
+	for _, variant := range resource.Variants {
+		variant.origin = resource.origin
+		c.crossLinkMethodAndResource(variant, method, version)
+	}
+
+	for _, property := range resource.Properties {
+		c.crossLinkVariants(property, method, version)
+
+		// The resource a recursive property refers back to may be the resource being cross linked.
+		if rec := property.Recursive; rec != nil {
+			if linked, ok := c.ResourceList[version][rec.ID]; !ok || linked.Methods[method.ID] != method {
+				rec.origin = resource.origin
+				c.crossLinkMethodAndResource(rec, method, version)
+			}
+		}
+	}
+}
+
+func definitionTitle(name string, def spec.Schema) string {
+	if def.Title != "" {
+		return def.Title
+	}
+
+	return name
+}
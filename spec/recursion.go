@@ -0,0 +1,210 @@
+package spec
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// recursiveExampleDepth is the number of times a recursive resource is repeated within the JSON
+// example of the resource it refers back to.
+const recursiveExampleDepth = 2
+
+// recursion tracks a definition whose schema is being compiled into a resource. References to a
+// definition that is already being compiled are cycles, which are not expanded again.
+type recursion struct {
+	ref        string                   // $ref identifying the definition
+	resource   *Resource                // resource being compiled from the definition
+	jsonRep    map[string]interface{}   // JSON representation of the resource being compiled
+	topLevel   bool                     // whether the resource is documented in its own right
+	standalone *Resource                // resource documenting the definition, when it is not top level
+	markers    []map[string]interface{} // JSON representations of the recursive references, filled once compiled
+}
+
+// resolveSchema returns a copy of the definition referenced by a schema together with its $ref,
+// or the schema itself and the $ref it was inlined from, if any, when it holds no reference.
+// References remain within an expanded document only where they are circular, so they are
+// resolved against the definitions of the original document, whose own references are still intact.
+func (c *APISpecification) resolveSchema(s *spec.Schema) (*spec.Schema, string) {
+	if s == nil {
+		return s, ""
+	}
+
+	if s.Ref.String() == "" {
+		ref, _ := s.Extensions.GetString(definitionRefExt)
+
+		return s, ref
+	}
+
+	ref := "#" + s.Ref.GetURL().Fragment
+	name := strings.TrimPrefix(ref, DefinitionsRef)
+
+	definitions := c.definitions
+	if orig := c.Swagger(); orig != nil {
+		definitions = orig.Definitions
+	}
+
+	def, ok := definitions[name]
+	if !ok {
+		log().Warnf("Unable to resolve reference %s", s.Ref.String())
+
+		return s, ""
+	}
+
+	// The compilation of a schema alters it, so each reference gets its own copy of the definition.
+	resolved := &spec.Schema{}
+
+	raw, err := json.Marshal(def)
+	if err == nil {
+		err = json.Unmarshal(raw, resolved)
+	}
+
+	if err != nil {
+		log().Errorf("Error copying definition %s: %s", ref, err)
+
+		return s, ""
+	}
+
+	// Keep any type the reference was given, such as that of a map of resources.
+	if len(s.Type) > 0 {
+		resolved.Type = s.Type
+	}
+
+	return resolved, ref
+}
+
+// addDefinitionRefs records on every definition the $ref identifying it. Which of the references
+// of a cycle the expansion of a document leaves in place is not predictable, so this is what tells
+// the definitions it inlined apart from inline schemas. It returns true if any definition was changed.
+func addDefinitionRefs(s *spec.Swagger) bool {
+	for name, def := range s.Definitions {
+		def.AddExtension(definitionRefExt, DefinitionsRef+name)
+		s.Definitions[name] = def
+	}
+
+	return len(s.Definitions) > 0
+}
+
+// findRecursion returns the innermost compilation of the definition identified by ref, if any.
+func (c *APISpecification) findRecursion(ref string) *recursion {
+	if ref == "" {
+		return nil
+	}
+
+	for i := len(c.recursions) - 1; i >= 0; i-- {
+		if c.recursions[i].ref == ref {
+			return c.recursions[i]
+		}
+	}
+
+	return nil
+}
+
+// pushRecursion records that the definition identified by ref is being compiled.
+func (c *APISpecification) pushRecursion(ref string, topLevel bool) *recursion {
+	rec := &recursion{ref: ref, topLevel: topLevel}
+	c.recursions = append(c.recursions, rec)
+
+	return rec
+}
+
+// popRecursion records that a definition has been compiled, completing the JSON representations
+// of the recursive references to it with a depth-limited copy of its own.
+func (c *APISpecification) popRecursion(rec *recursion) {
+	for _, marker := range rec.markers {
+		for k, v := range c.limitedExample(rec.jsonRep, 1) {
+			marker[k] = v
+		}
+	}
+
+	c.recursions = c.recursions[:len(c.recursions)-1]
+}
+
+// recursiveResource returns the resource documenting the definition referred back to, compiling it
+// on its own when it is only being compiled as a property of another resource.
+func (c *APISpecification) recursiveResource(rec *recursion, method *Method, isRequestResource bool) *Resource {
+	if rec.topLevel {
+		return rec.resource
+	}
+
+	if rec.standalone == nil {
+		s := &spec.Schema{SchemaProps: spec.SchemaProps{Ref: spec.MustCreateRef(rec.ref)}}
+		rec.standalone, _, _ = c.resourceFromSchema(s, method, nil, isRequestResource)
+	}
+
+	return rec.standalone
+}
+
+// addRecursiveMarker returns the JSON representation of a recursive reference, completed once the
+// definition it refers back to has been compiled.
+func (rec *recursion) addRecursiveMarker() map[string]interface{} {
+	marker := make(map[string]interface{})
+	rec.markers = append(rec.markers, marker)
+
+	return marker
+}
+
+// limitedExample copies a JSON representation, replacing the recursive references it holds by the
+// representation of the resource they refer back to until recursiveExampleDepth is reached.
+func (c *APISpecification) limitedExample(jsonRep map[string]interface{}, depth int) map[string]interface{} {
+	copied := make(map[string]interface{}, len(jsonRep))
+
+	for k, v := range jsonRep {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			if member := c.limitedMember(val, depth); member != nil {
+				copied[k] = member
+			}
+		case []map[string]interface{}:
+			members := make([]map[string]interface{}, 0, len(val))
+
+			for _, m := range val {
+				if member := c.limitedMember(m, depth); member != nil {
+					members = append(members, member)
+				}
+			}
+
+			copied[k] = members
+		default:
+			copied[k] = v
+		}
+	}
+
+	return copied
+}
+
+// limitedMember copies an object member of a JSON representation, returning nil in place of a
+// recursive reference found beyond recursiveExampleDepth.
+func (c *APISpecification) limitedMember(member map[string]interface{}, depth int) map[string]interface{} {
+	rec := c.markerRecursion(member)
+	if rec == nil {
+		return c.limitedExample(member, depth)
+	}
+
+	if depth >= recursiveExampleDepth {
+		return nil
+	}
+
+	return c.limitedExample(rec.jsonRep, depth+1)
+}
+
+// markerRecursion returns the compilation a JSON representation is a recursive reference to, if any.
+func (c *APISpecification) markerRecursion(member map[string]interface{}) *recursion {
+	if member == nil {
+		return nil
+	}
+
+	p := reflect.ValueOf(member).Pointer()
+
+	for _, rec := range c.recursions {
+		for _, marker := range rec.markers {
+			if reflect.ValueOf(marker).Pointer() == p {
+				return rec
+			}
+		}
+	}
+
+	return nil
+}
@@ -8,28 +8,20 @@ import (
 	"github.com/kenjones-cisco/dapperdox/config"
 )
 
-var specReplacer *strings.Replacer
-
-func loadReplacer() {
-	// Build a replacer to search/replace specification URLs
-	if specReplacer == nil {
-		var replacements []string
-
-		// Configure the replacer with key=value pairs
-		for k, v := range viper.GetStringMapString(config.SpecRewriteURL) {
-			if v != "" {
-				// Map between configured to=from URL pair
-				replacements = append(replacements, k, v)
-			} else {
-				// Map between configured URL and site URL
-				replacements = append(replacements, k, viper.GetString(config.SiteURL))
-			}
+// newReplacer builds a replacer to search/replace the specification URLs configured.
+func newReplacer(cfg *viper.Viper) *strings.Replacer {
+	var replacements []string
+
+	// Configure the replacer with key=value pairs
+	for k, v := range cfg.GetStringMapString(config.SpecRewriteURL) {
+		if v != "" {
+			// Map between configured to=from URL pair
+			replacements = append(replacements, k, v)
+		} else {
+			// Map between configured URL and site URL
+			replacements = append(replacements, k, cfg.GetString(config.SiteURL))
 		}
-
-		specReplacer = strings.NewReplacer(replacements...)
 	}
-}
 
-func replace(data []byte) []byte {
-	return []byte(specReplacer.Replace(string(data)))
+	return strings.NewReplacer(replacements...)
 }
@@ -21,12 +21,14 @@ package spec
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"net/url"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/go-openapi/loads"
@@ -39,21 +41,29 @@ import (
 	"github.com/kenjones-cisco/dapperdox/config"
 	"github.com/kenjones-cisco/dapperdox/discover"
 	"github.com/kenjones-cisco/dapperdox/formatter"
+	"github.com/kenjones-cisco/dapperdox/i18n"
+	"github.com/kenjones-cisco/dapperdox/spec/openapi3"
 )
 
 const (
 	arrayType = "array"
 
-	excludeOpExt     = "x-excludeFromOperations"
-	navMethodNameExt = "x-navigateMethodsByName"
-	opNameExt        = "x-operationName"
-	pathNameExt      = "x-pathName"
-	sortMethodsByExt = "x-sortMethodsBy"
-	groupByExt       = "x-groupby"
-	versionExt       = "x-version"
-	visibilityExt    = "x-visibility"
+	definitionRefExt      = "x-definition-ref"
+	discriminatorValueExt = "x-discriminator-value"
+	excludeOpExt          = "x-excludeFromOperations"
+	navMethodNameExt      = "x-navigateMethodsByName"
+	codeSamplesExt        = "x-codeSamples"
+	codeSamplesLegacyExt  = "x-code-samples"
+	opNameExt             = "x-operationName"
+	pathNameExt           = "x-pathName"
+	groupByExt            = "x-groupby"
+	versionExt            = "x-version"
+	visibilityExt         = "x-visibility"
 )
 
+// SortMethodsByExt is the extension listing the keys the methods of an API are sorted by.
+const SortMethodsByExt = "x-sortMethodsBy"
+
 // all defined ResourceOrigin.
 const (
 	RequestBody ResourceOrigin = iota
@@ -78,11 +88,12 @@ var sortTypes = map[string]bool{
 	"summary":    true,
 }
 
-// APISuite holds multiple apis held by name.
-var APISuite = make(map[string]*APISpecification)
-
-// APISuiteGroups holds multiple apis sorted by groups.
-var APISuiteGroups = make(map[string][]*APISpecification)
+// Suite holds the specifications loaded together. It is not altered once loaded, so it can be
+// read by the handlers serving it while another Suite is being loaded.
+type Suite struct {
+	Specs  map[string]*APISpecification   // Specifications held by ID
+	Groups map[string][]*APISpecification // Specifications sorted by groups
+}
 
 // APISpecification holds the content of a parsed api.
 type APISpecification struct {
@@ -91,11 +102,21 @@ type APISpecification struct {
 	APIInfo Info
 	URL     string
 	GroupBy string
+	Servers []Server // Servers declared by an OpenAPI 3.0 specification
 
 	SecurityDefinitions map[string]SecurityScheme
 	DefaultSecurity     map[string]Security
 	ResourceList        map[string]map[string]*Resource // Version->ResourceName->Resource
 	APIVersions         map[string]APISet               // Version->APISet
+	Errors              LoadErrors                      // Problems found while loading the specification
+	Digest              string                          // SHA-256 of the document loaded, identifying its revision
+
+	document           *loads.Document
+	statusCodes        map[int]string   // descriptions of the HTTP status codes
+	defaultHost        string           // host of the API when the specification declares none
+	definitions        spec.Definitions // expanded definitions, looked up by discriminator mappings
+	variantsInProgress map[string]bool  // titles of the variants being compiled
+	recursions         []*recursion     // definitions being compiled, innermost last
 }
 
 // APISet list of grouped APIs.
@@ -140,7 +161,9 @@ type OAuth2Scheme struct {
 type SecurityScheme struct {
 	IsAPIKey      bool
 	IsBasic       bool
+	IsBearer      bool
 	IsOAuth2      bool
+	Name          string // name of the scheme in the security definitions
 	Type          string
 	Description   string
 	ParamName     string
@@ -168,14 +191,18 @@ type Method struct {
 	PathParams      []Parameter
 	QueryParams     []Parameter
 	HeaderParams    []Parameter
+	CookieParams    []Parameter
 	BodyParam       *Parameter
 	FormParams      []Parameter
 	Responses       map[int]Response
-	DefaultResponse *Response // A ptr to allow of easy checking of its existence in templates
+	ResponseRanges  map[string]Response // Responses keyed by status code range, e.g. 2XX
+	DefaultResponse *Response           // A ptr to allow of easy checking of its existence in templates
 	Resources       []*Resource
 	Security        map[string]Security
 	APIGroup        *APIGroup
 	SortKey         string
+	Pointer         string       // JSON pointer to the operation within the specification
+	CodeSamples     []CodeSample // Request snippets declared by the operation
 }
 
 // Parameter represents an API method parameter.
@@ -198,6 +225,7 @@ type Response struct {
 	StatusDescription string
 	Resource          *Resource
 	Headers           []Header
+	ContentTypes      []string
 	IsArray           bool
 }
 
@@ -219,6 +247,12 @@ type Resource struct {
 	ExcludeFromOperations []string
 	Methods               map[string]*Method
 	Enum                  []string
+	Variants              []*Resource // Alternative resources taking the place of a polymorphic resource
+	VariantKind           string      // VariantsOneOf or VariantsAnyOf when the resource has variants
+	Discriminator         *Discriminator
+	DiscriminatorValue    string    // Discriminator value selecting this resource when it is a variant
+	Examples              []Example // Named examples declared by the schema
+	Recursive             *Resource // Resource documenting the definition a recursive property refers back to
 	origin                ResourceOrigin
 }
 
@@ -241,117 +275,159 @@ func (a SortMethods) Len() int           { return len(a) }
 func (a SortMethods) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a SortMethods) Less(i, j int) bool { return a[i].SortKey < a[j].SortKey }
 
-// LoadSpecifications loads the provided api specifications.
-func LoadSpecifications(d discover.DiscoveryManager) (bool, error) {
-	loadStatusCodes()
-	loadReplacer()
+// LoadSpecifications loads the provided api specifications into a new Suite.
+func LoadSpecifications(cfg *viper.Viper, d discover.DiscoveryManager) (*Suite, error) {
+	return LoadLocalizedSpecifications(cfg, d, "")
+}
 
-	as := make(map[string]*APISpecification)
-	asg := make(map[string][]*APISpecification)
+// LoadLocalizedSpecifications loads the provided api specifications into a new Suite documenting them in
+// a locale, with the fields given by their x-i18n extensions for it and the HTTP status code descriptions
+// of the locale. The empty locale is the default one, taking the fields of the specifications as written.
+func LoadLocalizedSpecifications(cfg *viper.Viper, d discover.DiscoveryManager, locale string) (*Suite, error) {
+	statusCodes := loadStatusCodes(cfg, locale)
+
+	suite := &Suite{
+		Specs:  make(map[string]*APISpecification),
+		Groups: make(map[string][]*APISpecification),
+	}
 
 	var (
-		newspecs bool
-		docs     map[string]*loads.Document
-		err      error
+		docs   map[string]*loads.Document
+		failed map[string]error
+		err    error
 	)
 
-	if viper.GetBool(config.DiscoveryEnabled) {
-		docs, err = getDocsByDiscovery(d)
+	if cfg.GetBool(config.DiscoveryEnabled) {
+		docs, failed, err = getDocsByDiscovery(d, locale)
 	} else {
-		docs, err = getDocsByDir()
+		docs, failed = getDocsByDir(cfg, locale)
 	}
 
 	if err != nil {
-		return newspecs, err
+		return nil, err
 	}
 
-	for specLocation, doc := range docs {
-		var (
-			ok            bool
-			specification *APISpecification
-		)
+	for specLocation, loadErr := range failed {
+		specification := newBrokenSpecification(specLocation, loadErr)
 
-		if specification, ok = as[""]; !ok {
-			specification = &APISpecification{}
-		}
+		suite.Specs[specification.ID] = specification
+		suite.Groups[specification.GroupBy] = append(suite.Groups[specification.GroupBy], specification)
+	}
 
-		specification.load(specLocation, doc)
+	for specLocation, doc := range docs {
+		specification := &APISpecification{
+			statusCodes: statusCodes,
+			defaultHost: cfg.GetString(config.SpecDefaultHost),
+		}
+		specification.safeLoad(specLocation, doc)
 
-		as[specification.ID] = specification
+		suite.Specs[specification.ID] = specification
 
-		if _, exists := asg[specification.GroupBy]; !exists {
-			asg[specification.GroupBy] = make([]*APISpecification, 0)
+		if _, exists := suite.Groups[specification.GroupBy]; !exists {
+			suite.Groups[specification.GroupBy] = make([]*APISpecification, 0)
 		}
 
-		asg[specification.GroupBy] = append(asg[specification.GroupBy], specification)
+		suite.Groups[specification.GroupBy] = append(suite.Groups[specification.GroupBy], specification)
 	}
 
-	log().Infof("loaded [%d] specifications to API spec suite maps", len(as))
-
-	if len(as) > 0 {
-		APISuite = as
-		APISuiteGroups = asg
-
-		newspecs = true
-	}
+	log().Infof("loaded [%d] specifications to API spec suite maps", len(suite.Specs))
 
-	return newspecs, nil
+	return suite, nil
 }
 
-func getDocsByDiscovery(d discover.DiscoveryManager) (map[string]*loads.Document, error) {
+// getDocsByDiscovery analyzes each discovered specification in isolation, localized to locale, returning
+// the documents and the errors of those that could not be analyzed, both keyed by spec location.
+func getDocsByDiscovery(d discover.DiscoveryManager, locale string) (map[string]*loads.Document, map[string]error, error) {
 	if d == nil {
-		return nil, wraperrors.New("no discovery provided to fetch specs")
+		return nil, nil, wraperrors.New("no discovery provided to fetch specs")
 	}
 
 	docs := make(map[string]*loads.Document)
+	failed := make(map[string]error)
 
 	rawspecs := d.Specs()
 
 	for k, data := range rawspecs {
-		document, err := loads.Analyzed(json.RawMessage(data), "")
+		specLocation := fmt.Sprintf("/%s/api.json", k)
+
+		document, err := analyzeDocument(i18n.LocalizeDocument(data, locale))
 		if err != nil {
-			return nil, err
+			log().Errorf("Error: failed to analyze discovered spec [%s]: %s", k, err)
+
+			failed[specLocation] = err
+
+			continue
 		}
 
-		document, err = document.Expanded()
-		if err != nil {
-			return nil, err
+		// The info object is only checked once the document is loaded.
+		if info := document.Spec().Info; info != nil {
+			log().Debugf("  registering spec[%s]", fmt.Sprintf("%s:%s", k, info.Version))
 		}
 
-		log().Debugf("  registering spec[%s]", fmt.Sprintf("%s:%s", k, document.Spec().Info.Version))
-		docs[fmt.Sprintf("/%s/api.json", k)] = document
+		docs[specLocation] = document
 	}
 
-	return docs, nil
+	return docs, failed, nil
 }
 
-func getDocsByDir() (map[string]*loads.Document, error) {
-	log().Infof("configured spec filenames: %v", viper.GetStringSlice(config.SpecFilename))
+// getDocsByDir loads each configured specification in isolation, localized to locale, returning the
+// documents and the errors of those that could not be loaded, both keyed by spec location.
+func getDocsByDir(cfg *viper.Viper, locale string) (map[string]*loads.Document, map[string]error) {
+	log().Infof("configured spec filenames: %v", cfg.GetStringSlice(config.SpecFilename))
 
 	docs := make(map[string]*loads.Document)
+	failed := make(map[string]error)
+	replacer := newReplacer(cfg)
 
-	for _, specLocation := range viper.GetStringSlice(config.SpecFilename) {
+	for _, specLocation := range cfg.GetStringSlice(config.SpecFilename) {
 		log().Infof("specLocation: %s", specLocation)
 
-		document, err := loadSpec(normalizeSpecLocation(specLocation))
-		if err != nil {
-			return nil, err
-		}
+		document, err := loadSpec(normalizeSpecLocation(cfg.GetString(config.SpecDir), specLocation), replacer, locale)
 
 		if isLocalSpecURL(specLocation) && !strings.HasPrefix(specLocation, "/") {
 			specLocation = "/" + specLocation
 		}
 
+		if err != nil {
+			failed[specLocation] = err
+
+			continue
+		}
+
 		docs[specLocation] = document
 	}
 
-	return docs, nil
+	return docs, failed
+}
+
+// safeLoad loads a specification, recording any unexpected failure as a load error so that
+// a broken specification cannot take the others down with it. A specification failing before
+// it is identified is named after its location, as those that cannot be parsed are.
+func (c *APISpecification) safeLoad(specLocation string, document *loads.Document) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.addError("", "unexpected failure: %v", r)
+
+			if c.ID == "" {
+				c.ID = titleToKebab(locationName(specLocation))
+				c.APIInfo.Title = locationName(specLocation)
+			}
+
+			if c.GroupBy == "" {
+				c.GroupBy = "default"
+			}
+		}
+	}()
+
+	c.load(specLocation, document)
 }
 
 func (c *APISpecification) load(specLocation string, document *loads.Document) {
 	apispec := document.Spec()
 
 	c.URL = specLocation
+	c.document = document
+	c.Digest = fmt.Sprintf("%x", sha256.Sum256(document.Raw()))
 
 	basePath := apispec.BasePath
 	basePathLen := len(basePath)
@@ -367,7 +443,7 @@ func (c *APISpecification) load(specLocation string, document *loads.Document) {
 
 	host := apispec.Host
 	if host == "" {
-		host = viper.GetString(config.SpecDefaultHost)
+		host = c.defaultHost
 	}
 
 	u := &url.URL{
@@ -379,12 +455,15 @@ func (c *APISpecification) load(specLocation string, document *loads.Document) {
 	c.APIInfo.Title = apispec.Info.Title
 
 	if c.APIInfo.Title == "" {
-		log().Panicf("Error: Specification %s does not have a info.title member.", c.URL)
+		c.addError("/info/title", "specification does not have an info.title member")
+		c.APIInfo.Title = locationName(c.URL)
 	}
 
 	log().Tracef("Parse OpenAPI specification %q", c.APIInfo.Title)
 
-	c.ID = titleToKebab(c.APIInfo.Title)
+	c.ID = titleToKebab(sourceOf(apispec.Info.Extensions, "title", c.APIInfo.Title))
+	c.Servers = getServers(apispec.Extensions)
+	c.definitions = apispec.Definitions
 
 	c.getSecurityDefinitions(apispec)
 	c.getDefaultSecurity(apispec)
@@ -402,7 +481,7 @@ func (c *APISpecification) load(specLocation string, document *loads.Document) {
 
 	var methodSortBy []string
 
-	if sortByList, ok := apispec.Extensions[sortMethodsByExt].([]interface{}); ok {
+	if sortByList, ok := apispec.Extensions[SortMethodsByExt].([]interface{}); ok {
 		for _, sortBy := range sortByList {
 			keyname, _ := sortBy.(string)
 			if _, ok := sortTypes[keyname]; !ok {
@@ -435,12 +514,18 @@ func (c *APISpecification) load(specLocation string, document *loads.Document) {
 			name = tag.Name
 		}
 
+		// The ID is that of the group in the default locale, whatever the locale of its name.
+		id := titleToKebab(sourceOf(tag.Extensions, "description", tag.Description))
+		if id == "" {
+			id = titleToKebab(tag.Name)
+		}
+
 		log().Tracef("    - %s", name)
 
 		// If we're grouping by TAGs, then build the API at the tag level
 		if groupingByTag {
 			api = &APIGroup{
-				ID:                     titleToKebab(name),
+				ID:                     id,
 				Name:                   name,
 				URL:                    u,
 				Info:                   &c.APIInfo,
@@ -460,6 +545,8 @@ func (c *APISpecification) load(specLocation string, document *loads.Document) {
 				continue
 			}
 
+			pointer := JSONPointer("paths", path)
+
 			if basePathLen > 0 {
 				path = basePath + path
 			}
@@ -467,7 +554,7 @@ func (c *APISpecification) load(specLocation string, document *loads.Document) {
 			// If not grouping by tag, then build the API at the path level
 			if !groupingByTag {
 				api = &APIGroup{
-					ID:                     titleToKebab(name),
+					ID:                     id,
 					Name:                   name,
 					URL:                    u,
 					Info:                   &c.APIInfo,
@@ -486,7 +573,7 @@ func (c *APISpecification) load(specLocation string, document *loads.Document) {
 			api.CurrentVersion = ver
 
 			pi := pathItem
-			c.getMethods(tag, api, &api.Methods, &pi, path, ver) // Current version
+			c.getMethods(tag, api, &api.Methods, &pi, path, pointer, ver) // Current version
 
 			// If API was populated (will not be if tags do not match), add to set
 			if !groupingByTag && len(api.Methods) > 0 {
@@ -520,17 +607,41 @@ func (c *APISpecification) load(specLocation string, document *loads.Document) {
 	}
 }
 
-func (c *APISpecification) getMethods(tag spec.Tag, api *APIGroup, methods *[]Method, pi *spec.PathItem, path, version string) {
-	c.getMethod(tag, api, methods, version, pi, pi.Get, path, "get")
-	c.getMethod(tag, api, methods, version, pi, pi.Post, path, "post")
-	c.getMethod(tag, api, methods, version, pi, pi.Put, path, "put")
-	c.getMethod(tag, api, methods, version, pi, pi.Delete, path, "delete")
-	c.getMethod(tag, api, methods, version, pi, pi.Head, path, "head")
-	c.getMethod(tag, api, methods, version, pi, pi.Options, path, "options")
-	c.getMethod(tag, api, methods, version, pi, pi.Patch, path, "patch")
+// Swagger returns the specification as declared, before its references were expanded.
+// It returns nil if the specification could not be parsed.
+func (c *APISpecification) Swagger() *spec.Swagger {
+	if c.document == nil {
+		return nil
+	}
+
+	return c.document.OrigSpec()
+}
+
+// SortMethodsByValues returns the values accepted by the x-sortMethodsBy extension.
+func SortMethodsByValues() []string {
+	values := make([]string, 0, len(sortTypes))
+	for v := range sortTypes {
+		values = append(values, v)
+	}
+
+	sort.Strings(values)
+
+	return values
+}
+
+func (c *APISpecification) getMethods(tag spec.Tag, api *APIGroup, methods *[]Method, pi *spec.PathItem, path, pointer, version string) {
+	c.getMethod(tag, api, methods, version, pi, pi.Get, path, pointer, "get")
+	c.getMethod(tag, api, methods, version, pi, pi.Post, path, pointer, "post")
+	c.getMethod(tag, api, methods, version, pi, pi.Put, path, pointer, "put")
+	c.getMethod(tag, api, methods, version, pi, pi.Delete, path, pointer, "delete")
+	c.getMethod(tag, api, methods, version, pi, pi.Head, path, pointer, "head")
+	c.getMethod(tag, api, methods, version, pi, pi.Options, path, pointer, "options")
+	c.getMethod(tag, api, methods, version, pi, pi.Patch, path, pointer, "patch")
 }
 
-func (c *APISpecification) getMethod(tag spec.Tag, api *APIGroup, methods *[]Method, version string, pathitem *spec.PathItem, operation *spec.Operation, path, methodname string) {
+func (c *APISpecification) getMethod(tag spec.Tag, api *APIGroup, methods *[]Method, version string, pathitem *spec.PathItem, operation *spec.Operation,
+	path, pointer, methodname string,
+) {
 	if operation == nil {
 		log().Tracef("Skipping %s %s - Operation is nil.", path, methodname)
 
@@ -554,15 +665,17 @@ func (c *APISpecification) getMethod(tag spec.Tag, api *APIGroup, methods *[]Met
 			return
 		}
 
-		method := c.processMethod(api, pathitem, operation, path, methodname, version)
-		*methods = append(*methods, *method)
+		if method := c.processMethod(api, pathitem, operation, path, pointer, methodname, version); method != nil {
+			*methods = append(*methods, *method)
+		}
 	} else {
 		log().Trace("    > Check tags")
 		for _, t := range operation.Tags {
 			log().Tracef("      - Compare tag %q with %q", tag.Name, t)
 			if tag.Name == "" || t == tag.Name {
-				method := c.processMethod(api, pathitem, operation, path, methodname, version)
-				*methods = append(*methods, *method)
+				if method := c.processMethod(api, pathitem, operation, path, pointer, methodname, version); method != nil {
+					*methods = append(*methods, *method)
+				}
 			}
 		}
 	}
@@ -577,6 +690,7 @@ func (c *APISpecification) getSecurityDefinitions(s *spec.Swagger) {
 		stype := d.Type
 
 		def := &SecurityScheme{
+			Name:          n,
 			Description:   string(formatter.Markdown([]byte(d.Description))),
 			Type:          stype,  // basic, apiKey or oauth2
 			ParamName:     d.Name, // name of header to be used if ParamLocation is 'header'
@@ -591,6 +705,10 @@ func (c *APISpecification) getSecurityDefinitions(s *spec.Swagger) {
 			def.IsBasic = true
 		}
 
+		if stype == "bearer" {
+			def.IsBearer = true
+		}
+
 		if stype == "oauth2" {
 			def.IsOAuth2 = true
 			def.OAuth2Flow = d.Flow                   // implicit, password (explicit) application or accessCode
@@ -612,7 +730,11 @@ func (c *APISpecification) getDefaultSecurity(s *spec.Swagger) {
 	c.processSecurity(s.Security, c.DefaultSecurity)
 }
 
-func (c *APISpecification) processMethod(api *APIGroup, pathItem *spec.PathItem, o *spec.Operation, path, methodname, version string) *Method {
+// processMethod builds the Method of an operation, returning nil if the operation is invalid.
+// The problems found are recorded against the operation JSON pointer, built from the path item pointer.
+func (c *APISpecification) processMethod(api *APIGroup, pathItem *spec.PathItem, o *spec.Operation, path, pointer, methodname, version string) *Method {
+	opPointer := pointer + JSONPointer(methodname)
+
 	var (
 		opname    string
 		gotOpname bool
@@ -628,9 +750,9 @@ func (c *APISpecification) processMethod(api *APIGroup, pathItem *spec.PathItem,
 	if id == "" {
 		// No ID, use x-operationName, if we have it...
 		if gotOpname {
-			id = titleToKebab(opname)
+			id = titleToKebab(sourceOf(o.Extensions, opNameExt, opname))
 		} else {
-			id = titleToKebab(o.Summary) // No opname, use summary
+			id = titleToKebab(sourceOf(o.Extensions, "summary", o.Summary)) // No opname, use summary
 			if id == "" {
 				id = methodname // Last chance. Method name.
 			}
@@ -655,6 +777,8 @@ func (c *APISpecification) processMethod(api *APIGroup, pathItem *spec.PathItem,
 		OperationName:  operationName,
 		APIGroup:       api,
 		SortKey:        sortkey,
+		Pointer:        opPointer,
+		CodeSamples:    getCodeSamples(o.Extensions),
 	}
 
 	if len(o.Consumes) > 0 {
@@ -676,32 +800,36 @@ func (c *APISpecification) processMethod(api *APIGroup, pathItem *spec.PathItem,
 	//
 	if pathname, ok := pathItem.Extensions[pathNameExt].(string); ok {
 		api.Name = pathname
-		api.ID = titleToKebab(api.Name)
+		api.ID = titleToKebab(sourceOf(pathItem.Extensions, pathNameExt, api.Name))
 	}
 
 	if api.Name == "" {
 		name := o.Summary
 		if name == "" {
-			log().Panicf("Error: Operation %q does not have an operationId or summary member.", id)
+			c.addError(opPointer, "operation %q does not have an operationId or summary member", id)
+
+			return nil
 		}
 
 		api.Name = name
-		api.ID = titleToKebab(name)
+		api.ID = titleToKebab(sourceOf(o.Extensions, "summary", name))
+	}
+
+	if o.Responses == nil {
+		c.addError(opPointer, "operation %s %s is missing a responses declaration", methodname, path)
+
+		return nil
 	}
 
 	if c.ResourceList == nil {
 		c.ResourceList = make(map[string]map[string]*Resource)
 	}
 
-	c.processParameters(pathItem.Parameters, method, version)
+	c.processParameters(pathItem.Parameters, method, pointer+"/parameters", version)
 
-	c.processParameters(o.Parameters, method, version)
+	c.processParameters(o.Parameters, method, opPointer+"/parameters", version)
 
 	// Compile resources from response declaration
-	if o.Responses == nil {
-		log().Panicf("Error: Operation %s %s is missing a responses declaration.", methodname, path)
-	}
-	// FIXME - Dies if there are no responses...
 	for status, response := range o.Responses.StatusCodeResponses {
 		log().Tracef("Response for status %d", status)
 
@@ -714,16 +842,28 @@ func (c *APISpecification) processMethod(api *APIGroup, pathItem *spec.PathItem,
 		}
 
 		r := response
-		rsp := c.buildResponse(&r, method, version)
-		rsp.StatusDescription = httpStatusDescription(status)
+		rsp := c.buildResponse(&r, method, opPointer+JSONPointer("responses", strconv.Itoa(status)), version)
+		rsp.StatusDescription = c.statusCodes[status]
 		method.Responses[status] = *rsp
 	}
 
 	if o.Responses.Default != nil {
-		rsp := c.buildResponse(o.Responses.Default, method, version)
+		rsp := c.buildResponse(o.Responses.Default, method, opPointer+"/responses/default", version)
 		method.DefaultResponse = rsp
 	}
 
+	// OpenAPI 3.0 responses keyed by a status code range, such as 2XX
+	if ranges, ok := o.Extensions[openapi3.ResponseRangesExt].(map[string]spec.Response); ok {
+		method.ResponseRanges = make(map[string]Response)
+
+		for code, response := range ranges {
+			r := response
+			rsp := c.buildResponse(&r, method, opPointer+JSONPointer("responses", code), version)
+			rsp.StatusDescription = httpStatusRangeDescription(code)
+			method.ResponseRanges[code] = *rsp
+		}
+	}
+
 	// If no Security given for operation, then the global defaults are appled.
 	method.Security = make(map[string]Security)
 	if !c.processSecurity(o.Security, method.Security) {
@@ -733,8 +873,8 @@ func (c *APISpecification) processMethod(api *APIGroup, pathItem *spec.PathItem,
 	return method
 }
 
-func (c *APISpecification) processParameters(params []spec.Parameter, method *Method, version string) {
-	for _, param := range params {
+func (c *APISpecification) processParameters(params []spec.Parameter, method *Method, pointer, version string) {
+	for i, param := range params {
 		p := Parameter{
 			Name:        param.Name,
 			In:          param.In,
@@ -751,24 +891,35 @@ func (c *APISpecification) processParameters(params []spec.Parameter, method *Me
 			method.PathParams = append(method.PathParams, p)
 		case "body":
 			if param.Schema == nil {
-				log().Panicf("Error: 'in body' parameter %s is missing a schema declaration.", param.Name)
+				c.addError(pointer+JSONPointer(strconv.Itoa(i)), "'in body' parameter %s is missing a schema declaration", param.Name)
+
+				continue
 			}
 
 			var body map[string]interface{}
-			p.Resource, body, p.IsArray = c.resourceFromSchema(param.Schema, method, nil, true)
+			if p.Resource, body, p.IsArray = c.resourceFromSchema(param.Schema, method, nil, true); p.Resource == nil {
+				c.addError(pointer+JSONPointer(strconv.Itoa(i), "schema"), "%s %s references a model definition that does not have a title member",
+					strings.ToUpper(method.Method), method.Path)
+
+				continue
+			}
+
 			p.Resource.Schema = jsonResourceToString(body, p.IsArray)
+			p.Resource.setGeneratedExample()
 			p.Resource.origin = RequestBody
 			method.BodyParam = &p
 			c.crossLinkMethodAndResource(p.Resource, method, version)
 		case "header":
 			method.HeaderParams = append(method.HeaderParams, p)
+		case "cookie":
+			method.CookieParams = append(method.CookieParams, p)
 		case "query":
 			method.QueryParams = append(method.QueryParams, p)
 		}
 	}
 }
 
-func (c *APISpecification) buildResponse(resp *spec.Response, method *Method, version string) *Response {
+func (c *APISpecification) buildResponse(resp *spec.Response, method *Method, pointer, version string) *Response {
 	var response *Response
 
 	if resp != nil {
@@ -784,15 +935,19 @@ func (c *APISpecification) buildResponse(resp *spec.Response, method *Method, ve
 
 			if r != nil {
 				r.Schema = jsonResourceToString(exampleJSON, false)
+				r.setGeneratedExample()
 				r.origin = MethodResponse
 				vres = c.crossLinkMethodAndResource(r, method, version)
+			} else {
+				c.addError(pointer+"/schema", "%s %s references a model definition that does not have a title member", strings.ToUpper(method.Method), method.Path)
 			}
 		}
 
 		response = &Response{
-			Description: string(formatter.Markdown([]byte(resp.Description))),
-			Resource:    vres,
-			IsArray:     isArray,
+			Description:  string(formatter.Markdown([]byte(resp.Description))),
+			Resource:     vres,
+			ContentTypes: getContentTypes(resp.Extensions),
+			IsArray:      isArray,
 		}
 		method.Resources = append(method.Resources, response.Resource) // Add the resource to the method which uses it
 
@@ -855,6 +1010,8 @@ func (c *APISpecification) crossLinkMethodAndResource(resource *Resource, method
 		c.ResourceList[version][resource.ID] = vres // If we've already got the resource, this does nothing
 	}
 
+	c.crossLinkVariants(resource, method, version)
+
 	return vres
 }
 
@@ -893,6 +1050,8 @@ func (c *APISpecification) resourceFromSchema(s *spec.Schema, method *Method, fq
 		return nil, nil, false
 	}
 
+	s, ref := c.resolveSchema(s)
+
 	stype := checkPropertyType(s)
 	log().Tracef("resourceFromSchema: Schema type: %s", stype)
 	log().Tracef("FQNS: %s", fqNS)
@@ -935,6 +1094,10 @@ func (c *APISpecification) resourceFromSchema(s *spec.Schema, method *Method, fq
 			log().Tracef("got s.Items.Schemas[0] for %s", s.Title)
 		}
 
+		if items, itemsRef := c.resolveSchema(s); itemsRef != "" {
+			s, ref = items, itemsRef
+		}
+
 		if s.Type == nil {
 			log().Tracef("Got array of objects or object. Name %s", s.Title)
 			s.Type = stringorarray // Put back original type
@@ -953,26 +1116,30 @@ func (c *APISpecification) resourceFromSchema(s *spec.Schema, method *Method, fq
 		log().Tracef("REMAP SCHEMA (Type is now %s)", s.Type)
 	}
 
+	// The type of the resource is given by its format, when it has one. The schema keeps its own
+	// type, which its example value is of.
+	rtype := s.Type
 	if len(s.Format) > 0 {
-		s.Type[len(s.Type)-1] = s.Format
+		rtype = append(append(spec.StringOrArray{}, s.Type[:len(s.Type)-1]...), s.Format)
 	}
 
-	id := titleToKebab(s.Title)
+	id := titleToKebab(sourceOf(s.Extensions, "title", s.Title))
 
+	// Top level resources are identified by their title, callers report when it is missing.
 	if len(fqNS) == 0 && id == "" {
-		log().Panicf("Error: %s %s references a model definition that does not have a title member.", strings.ToUpper(method.Method), method.Path)
+		return nil, nil, false
 	}
 
 	// Ignore ID (from title element) for all but child-objects...
 	// This prevents the title-derived ID being added onto the end of the FQNS.property as
 	// FQNS.property.ID, if title is given for the property in the spec.
-	if len(fqNS) > 0 && !s.Type.Contains("object") {
+	if len(fqNS) > 0 && !rtype.Contains("object") {
 		id = ""
 	}
 
 	var isArray bool
 
-	if strings.EqualFold(s.Type[0], arrayType) {
+	if strings.EqualFold(rtype[0], arrayType) {
 		fqNSlen := len(fqNS)
 		if fqNSlen > 0 {
 			fqNS = append(fqNS[0:fqNSlen-1], fqNS[fqNSlen-1]+"[]")
@@ -996,7 +1163,7 @@ func (c *APISpecification) resourceFromSchema(s *spec.Schema, method *Method, fq
 	resourceFQNS := myFQNS
 	// If we are dealing with an object, then adjust the resource FQNS and id
 	// so that the last element of the FQNS is chopped off and used as the ID
-	if !chopped && s.Type.Contains("object") {
+	if !chopped && rtype.Contains("object") {
 		if len(resourceFQNS) > 0 {
 			id = resourceFQNS[len(resourceFQNS)-1]
 			resourceFQNS = resourceFQNS[:len(resourceFQNS)-1]
@@ -1005,6 +1172,21 @@ func (c *APISpecification) resourceFromSchema(s *spec.Schema, method *Method, fq
 		}
 	}
 
+	// A reference to a definition already being compiled is a cycle, which is documented by
+	// linking back to the resource of the definition rather than by expanding it again. A top level
+	// resource is compiled in its own right when the definition is only being compiled as a property.
+	cycle := c.findRecursion(ref)
+	if cycle != nil && !cycle.topLevel && len(fqNS) == 0 {
+		cycle = nil
+	}
+
+	var rec *recursion
+
+	if ref != "" && cycle == nil {
+		rec = c.pushRecursion(ref, len(fqNS) == 0)
+		defer c.popRecursion(rec)
+	}
+
 	// If there is no description... the case where we have an array of objects. See issue/11
 	var description string
 	if originalS.Description != "" {
@@ -1023,7 +1205,7 @@ func (c *APISpecification) resourceFromSchema(s *spec.Schema, method *Method, fq
 		ID:          id,
 		Title:       s.Title,
 		Description: description,
-		Type:        s.Type,
+		Type:        rtype,
 		Properties:  make(map[string]*Resource),
 		FQNS:        resourceFQNS,
 	}
@@ -1037,6 +1219,8 @@ func (c *APISpecification) resourceFromSchema(s *spec.Schema, method *Method, fq
 		r.Example = string(example)
 	}
 
+	r.Examples = namedExamples(s)
+
 	if len(s.Enum) > 0 {
 		for _, e := range s.Enum {
 			r.Enum = append(r.Enum, fmt.Sprintf("%s", e))
@@ -1055,16 +1239,32 @@ func (c *APISpecification) resourceFromSchema(s *spec.Schema, method *Method, fq
 		}
 	}
 
+	if cycle != nil {
+		log().Tracef("Resource %s refers back to %s", id, cycle.ref)
+
+		r.Recursive = c.recursiveResource(cycle, method, isRequestResource)
+
+		return r, cycle.addRecursiveMarker(), isArray
+	}
+
 	required := make(map[string]bool)
 	jsonRepresentation := make(map[string]interface{})
 
+	if rec != nil {
+		rec.resource = r
+		rec.jsonRep = jsonRepresentation
+	}
+
 	log().Trace("Call compileproperties...")
 	c.compileproperties(s, r, method, id, required, jsonRepresentation, myFQNS, chopped, isRequestResource)
 
 	for allof := range s.AllOf {
-		c.compileproperties(&s.AllOf[allof], r, method, id, required, jsonRepresentation, myFQNS, chopped, isRequestResource)
+		member, _ := c.resolveSchema(&s.AllOf[allof])
+		c.compileproperties(member, r, method, id, required, jsonRepresentation, myFQNS, chopped, isRequestResource)
 	}
 
+	c.compileVariants(s, r, method, jsonRepresentation, isRequestResource)
+
 	log().Trace("resourceFromSchema done")
 
 	return r, jsonRepresentation, isArray
@@ -1157,13 +1357,12 @@ func (c *APISpecification) processProperty(s *spec.Schema, name string, r *Resou
 
 					// If here, we have no jsonResource returned from resourceFromSchema, then the property
 					// is an array of primitive, so construct either an array of string or array of object
-					// as appropriate.
-					if len(jsonResource) > 0 {
+					// as appropriate. The jsonResource of a recursive property is only completed later on.
+					if len(jsonResource) > 0 || resource.Recursive != nil {
 						var arrayObj []map[string]interface{}
 						arrayObj = append(arrayObj, jsonResource)
 						jsonRep[name] = arrayObj
 					} else {
-						var arrayObj []string
 						// We stored the real type of the primitive in Type array index 1 (see the note in
 						// resourceFromSchema). There is a special case of an array of object where EVERY
 						// member of the object is read-only and filtered out due to isRequestResource being true.
@@ -1174,9 +1373,10 @@ func (c *APISpecification) processProperty(s *spec.Schema, name string, r *Resou
 						// value of nil. This shouldn't happen often, as a more correct spec will declare the
 						// array member as readOnly!
 						//
+						var arrayObj []interface{}
 						if len(r.Properties[name].Type) > 1 {
 							// Got an array of primitives
-							arrayObj = append(arrayObj, r.Properties[name].Type[1])
+							arrayObj = append(arrayObj, exampleValue(s.Items.Schema))
 						}
 
 						jsonRep[name] = arrayObj
@@ -1195,16 +1395,21 @@ func (c *APISpecification) processProperty(s *spec.Schema, name string, r *Resou
 			if strings.EqualFold(r.Properties[name].Type[1], "object") {
 				jsonRep[name] = jsonResource // A map of objects
 			} else {
-				jsonRep[name] = r.Properties[name].Type[1] // map of primitive
+				jsonRep[name] = exampleValue(s) // map of primitive
 			}
 		} else {
 			// We're NOT an array, map or object, so a primitive
-			jsonRep[name] = r.Properties[name].Type[0]
+			jsonRep[name] = exampleValue(s)
 		}
 	} else {
 		// We're an object
 		jsonRep[name] = jsonResource
 	}
+
+	// An example declared by an object or array property is more realistic than one built from its properties.
+	if s.Example != nil {
+		jsonRep[name] = s.Example
+	}
 }
 
 func (p *Parameter) setType(src spec.Parameter) {
@@ -1415,12 +1620,30 @@ func titleToKebab(s string) string {
 		" ", "-")
 }
 
+// sourceOf returns the value a field had in the default locale, as recorded when the specification was
+// localized, so that the IDs derived from it are the same in every locale. Fields that were not localized
+// have their value as is.
+func sourceOf(extensions spec.Extensions, field, value string) string {
+	source, ok := extensions[i18n.SourceExtension].(map[string]interface{})
+	if !ok {
+		return value
+	}
+
+	if original, ok := source[field]; ok {
+		s, _ := original.(string)
+
+		return s
+	}
+
+	return value
+}
+
 // camelToKebab converts camel case to kebab.
 func camelToKebab(s string) string {
 	return strings.ReplaceAll(snaker.CamelToSnake(s), "_", "-")
 }
 
-func loadSpec(location string) (*loads.Document, error) {
+func loadSpec(location string, replacer *strings.Replacer, locale string) (*loads.Document, error) {
 	log().Infof("Importing OpenAPI specifications from %s", location)
 
 	raw, err := swag.LoadFromFileOrHTTP(location)
@@ -1430,14 +1653,14 @@ func loadSpec(location string) (*loads.Document, error) {
 		return nil, err
 	}
 
-	document, err := loads.Analyzed(json.RawMessage(replace(raw)), "")
+	document, err := parseDocument(i18n.LocalizeDocument([]byte(replacer.Replace(string(raw))), locale))
 	if err != nil {
 		log().Errorf("Error: go-openapi/loads failed to analyze spec: %s", err)
 
 		return nil, err
 	}
 
-	document, err = document.Expanded()
+	document, err = expandDocument(document)
 	if err != nil {
 		log().Errorf("Error: go-openapi/loads failed to expand spec: %s", err)
 	}
@@ -1465,11 +1688,11 @@ func isLocalSpecURL(specURL string) bool {
 	return !match
 }
 
-func normalizeSpecLocation(specLocation string) string {
+func normalizeSpecLocation(specDir, specLocation string) string {
 	if isLocalSpecURL(specLocation) {
-		log().Debugf("SpecDir = %s", viper.GetString(config.SpecDir))
+		log().Debugf("SpecDir = %s", specDir)
 
-		base, err := filepath.Abs(viper.GetString(config.SpecDir))
+		base, err := filepath.Abs(specDir)
 		if err != nil {
 			log().Errorf("Error forming specification path: %s", err)
 		}
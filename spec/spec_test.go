@@ -1,18 +1,20 @@
 package spec
 
 import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
-	"github.com/spf13/viper"
-
 	"github.com/kenjones-cisco/dapperdox/config"
 )
 
 const testSpecDir = "../fixtures/"
 
 func TestLoadSpecifications(t *testing.T) {
-	config.Restore()
-	viper.Set(config.SpecDir, testSpecDir)
+	cfg := config.New()
+	cfg.Set(config.SpecDir, testSpecDir)
 
 	tests := []struct {
 		name    string
@@ -34,12 +36,37 @@ func TestLoadSpecifications(t *testing.T) {
 			specLoc: "depth_api.json",
 			wantErr: false,
 		},
+		{
+			name:    "success - load OpenAPI 3.0 specifications",
+			specLoc: "openapi3_api.json",
+			wantErr: false,
+		},
+		{
+			name:    "success - load specifications with use of discriminator",
+			specLoc: "polymorphic_api.json",
+			wantErr: false,
+		},
+		{
+			name:    "success - load OpenAPI 3.0 specifications with use of oneOf and anyOf",
+			specLoc: "polymorphic_openapi3_api.json",
+			wantErr: false,
+		},
+		{
+			name:    "success - load specifications with use of recursive definitions",
+			specLoc: "recursive_api.json",
+			wantErr: false,
+		},
+		{
+			name:    "success - load specifications with synthesized examples",
+			specLoc: "examples_api.json",
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			viper.Set(config.SpecFilename, tt.specLoc)
+			cfg.Set(config.SpecFilename, tt.specLoc)
 
-			if _, err := LoadSpecifications(nil); (err != nil) != tt.wantErr {
+			if _, err := LoadSpecifications(cfg, nil); (err != nil) != tt.wantErr {
 				t.Errorf("LoadSpecifications() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -47,8 +74,8 @@ func TestLoadSpecifications(t *testing.T) {
 }
 
 func TestLoadSpecifications_AutoDiscovery(t *testing.T) {
-	config.Restore()
-	viper.Set(config.DiscoveryEnabled, true)
+	cfg := config.New()
+	cfg.Set(config.DiscoveryEnabled, true)
 
 	tests := []struct {
 		name       string
@@ -81,6 +108,35 @@ func TestLoadSpecifications_AutoDiscovery(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "success - OpenAPI 3.0 api in discovery cache",
+			specsCache: map[string][]byte{
+				"/path/specs/openapi3": specToByteSlice("../fixtures/openapi3_api.json"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "success - api with use of discriminator in discovery cache",
+			specsCache: map[string][]byte{
+				"/path/specs/polymorphic": specToByteSlice("../fixtures/polymorphic_api.json"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "success - api with use of recursive definitions in discovery cache",
+			specsCache: map[string][]byte{
+				"/path/specs/recursive": specToByteSlice("../fixtures/recursive_api.json"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "success - invalid api in discovery cache",
+			specsCache: map[string][]byte{
+				"/path/specs/common":  specToByteSlice("../fixtures/common_api.json"),
+				"/path/specs/invalid": []byte(`{"swagger": `),
+			},
+			wantErr: false,
+		},
 		{
 			name: "success - multiple specs in discovery cache",
 			specsCache: map[string][]byte{
@@ -97,9 +153,484 @@ func TestLoadSpecifications_AutoDiscovery(t *testing.T) {
 				specs: tt.specsCache,
 			}
 
-			if _, err := LoadSpecifications(d); (err != nil) != tt.wantErr {
+			if _, err := LoadSpecifications(cfg, d); (err != nil) != tt.wantErr {
 				t.Errorf("LoadSpecifications() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func TestLoadSpecifications_OpenAPI3(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.SpecDir, testSpecDir)
+	cfg.Set(config.SpecFilename, "openapi3_api.json")
+
+	suite, err := LoadSpecifications(cfg, nil)
+	if err != nil {
+		t.Fatalf("LoadSpecifications() error = %v", err)
+	}
+
+	specification, ok := suite.Specs["pet-store-3"]
+	if !ok {
+		t.Fatalf("LoadSpecifications() specification not loaded: %v", suite.Specs)
+	}
+
+	if got := len(specification.Servers); got != 2 {
+		t.Errorf("Servers = %d, want 2", got)
+	}
+
+	if got := specification.Servers[0].URL; got != "https://api.petstore.example.com/v3" {
+		t.Errorf("Servers[0].URL = %s, want server variables substituted", got)
+	}
+
+	if !specification.SecurityDefinitions["bearerAuth"].IsBearer {
+		t.Errorf("SecurityDefinitions[bearerAuth] = %+v, want bearer scheme", specification.SecurityDefinitions["bearerAuth"])
+	}
+
+	methods := make(map[string]Method)
+
+	for _, api := range specification.APIs {
+		for _, m := range api.Methods {
+			methods[m.ID] = m
+		}
+	}
+
+	list := methods["list-pets"]
+	if list.Path != "/v3/pets" {
+		t.Errorf("list-pets Path = %s, want /v3/pets", list.Path)
+	}
+
+	if len(list.CookieParams) != 1 || len(list.QueryParams) != 2 {
+		t.Errorf("list-pets params = cookie %d query %d, want cookie 1 query 2", len(list.CookieParams), len(list.QueryParams))
+	}
+
+	if got := list.Responses[200].ContentTypes; len(got) != 2 {
+		t.Errorf("list-pets response content types = %v, want 2", got)
+	}
+
+	if _, ok := list.ResponseRanges["4XX"]; !ok {
+		t.Errorf("list-pets ResponseRanges = %v, want 4XX", list.ResponseRanges)
+	}
+
+	create := methods["create-pet"]
+	if create.BodyParam == nil || create.BodyParam.Resource.ID != "pet" {
+		t.Errorf("create-pet BodyParam = %+v, want pet resource", create.BodyParam)
+	}
+
+	if got := create.ResponseRanges["2XX"].Resource; got == nil || got.ID != "pet" {
+		t.Errorf("create-pet ResponseRanges[2XX].Resource = %+v, want pet resource", got)
+	}
+
+	upload := methods["upload-photo"]
+	if len(upload.FormParams) != 2 || len(upload.PathParams) != 1 {
+		t.Errorf("upload-photo params = form %d path %d, want form 2 path 1", len(upload.FormParams), len(upload.PathParams))
+	}
+
+	photo := methods["describe-photo"].Responses[200].Resource
+	if photo == nil || len(photo.Examples) != 2 {
+		t.Fatalf("describe-photo response resource = %+v, want 2 named examples", photo)
+	}
+
+	if got := photo.Examples[1]; got.Name != "portrait" || got.Summary != "A portrait photo" || !strings.Contains(got.Value, `"width": 1080`) {
+		t.Errorf("describe-photo Examples[1] = %+v, want resolved portrait example", got)
+	}
+}
+
+func TestLoadSpecifications_Polymorphism(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.SpecDir, testSpecDir)
+
+	tests := []struct {
+		name              string
+		specLoc           string
+		specID            string
+		resourceID        string
+		wantKind          string
+		wantVariants      []string
+		wantDiscriminator string
+		wantMapping       map[string]string
+	}{
+		{
+			name:              "discriminator with allOf inheritance",
+			specLoc:           "polymorphic_api.json",
+			specID:            "pet-shelter",
+			resourceID:        "animal",
+			wantKind:          VariantsOneOf,
+			wantVariants:      []string{"cat", "dog"},
+			wantDiscriminator: "kind",
+			wantMapping:       map[string]string{"Cat": "cat", "dog": "dog"},
+		},
+		{
+			name:              "oneOf with discriminator mapping",
+			specLoc:           "polymorphic_openapi3_api.json",
+			specID:            "payments",
+			resourceID:        "payment",
+			wantKind:          VariantsOneOf,
+			wantVariants:      []string{"card-payment", "bank-payment"},
+			wantDiscriminator: "method",
+			wantMapping:       map[string]string{"card": "card-payment", "bank": "bank-payment"},
+		},
+		{
+			name:       "no variants",
+			specLoc:    "polymorphic_openapi3_api.json",
+			specID:     "payments",
+			resourceID: "event",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Set(config.SpecFilename, tt.specLoc)
+
+			suite, err := LoadSpecifications(cfg, nil)
+			if err != nil {
+				t.Fatalf("LoadSpecifications() error = %v", err)
+			}
+
+			r, ok := suite.Specs[tt.specID].ResourceList["latest"][tt.resourceID]
+			if !ok {
+				t.Fatalf("resource %s not found", tt.resourceID)
+			}
+
+			if r.VariantKind != tt.wantKind {
+				t.Errorf("VariantKind = %q, want %q", r.VariantKind, tt.wantKind)
+			}
+
+			var variants []string
+			for _, v := range r.Variants {
+				variants = append(variants, v.ID)
+			}
+
+			if !reflect.DeepEqual(variants, tt.wantVariants) {
+				t.Errorf("Variants = %v, want %v", variants, tt.wantVariants)
+			}
+
+			if tt.wantDiscriminator == "" {
+				if r.Discriminator != nil {
+					t.Errorf("Discriminator = %+v, want nil", r.Discriminator)
+				}
+
+				return
+			}
+
+			if r.Discriminator == nil || r.Discriminator.PropertyName != tt.wantDiscriminator {
+				t.Fatalf("Discriminator = %+v, want property %s", r.Discriminator, tt.wantDiscriminator)
+			}
+
+			if !reflect.DeepEqual(r.Discriminator.Mapping, tt.wantMapping) {
+				t.Errorf("Discriminator.Mapping = %v, want %v", r.Discriminator.Mapping, tt.wantMapping)
+			}
+
+			for _, v := range r.Variants {
+				if !strings.Contains(v.Schema, `"`+tt.wantDiscriminator+`": "`+v.DiscriminatorValue+`"`) {
+					t.Errorf("variant %s example = %s, want discriminator value %q", v.ID, v.Schema, v.DiscriminatorValue)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadSpecifications_Errors(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.SpecDir, testSpecDir)
+	cfg.Set(config.SpecFilename, []string{"common_api.json", "invalid_api.json", "missing_api.json"})
+
+	suite, err := LoadSpecifications(cfg, nil)
+	if err != nil {
+		t.Fatalf("LoadSpecifications() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		specID   string
+		wantErrs []string
+	}{
+		{
+			name:   "good specification still loaded",
+			specID: "aws-service",
+		},
+		{
+			name:   "invalid specification loaded with errors",
+			specID: "invalid_api",
+			wantErrs: []string{
+				"/invalid_api.json#/info/title: specification does not have an info.title member",
+				"/invalid_api.json#/paths/~1things/get: operation \"get\" does not have an operationId or summary member",
+				"/invalid_api.json#/paths/~1things/post/parameters/0: 'in body' parameter thing is missing a schema declaration",
+				"/invalid_api.json#/paths/~1things/post/responses/201/schema: POST /v1/things references a model definition that does not have a title member",
+				"/invalid_api.json#/paths/~1things~1{id}/delete: operation delete /v1/things/{id} is missing a responses declaration",
+			},
+		},
+		{
+			name:     "unreadable specification loaded with errors",
+			specID:   "missing_api",
+			wantErrs: []string{"/missing_api.json: open "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specification, ok := suite.Specs[tt.specID]
+			if !ok {
+				t.Fatalf("specification %s not loaded: %v", tt.specID, suite.Specs)
+			}
+
+			if len(specification.Errors) != len(tt.wantErrs) {
+				t.Fatalf("Errors = %v, want %d errors", specification.Errors, len(tt.wantErrs))
+			}
+
+			got := make([]string, 0, len(specification.Errors))
+			for _, err := range specification.Errors {
+				got = append(got, err.Error())
+			}
+
+			sort.Strings(got)
+
+			for i, want := range tt.wantErrs {
+				if !strings.HasPrefix(got[i], want) {
+					t.Errorf("Errors[%d] = %q, want %q", i, got[i], want)
+				}
+			}
+		})
+	}
+
+	if got := len(suite.Errors()); got != 6 {
+		t.Errorf("Errors() = %d errors, want 6", got)
+	}
+}
+
+func TestLoadSpecifications_Failures(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.DiscoveryEnabled, true)
+
+	// Without an info object, loading fails before the specifications are identified.
+	d := &fakeDiscoverer{
+		specs: map[string][]byte{
+			"/path/specs/first":  []byte(`{"swagger": "2.0", "paths": {}}`),
+			"/path/specs/second": []byte(`{"swagger": "2.0", "paths": {}}`),
+		},
+	}
+
+	suite, err := LoadSpecifications(cfg, d)
+	if err != nil {
+		t.Fatalf("LoadSpecifications() error = %v", err)
+	}
+
+	if len(suite.Specs) != 2 {
+		t.Fatalf("Specs = %v, want first and second", suite.Specs)
+	}
+
+	for _, id := range []string{"first", "second"} {
+		specification, ok := suite.Specs[id]
+		if !ok {
+			t.Fatalf("specification %s not loaded: %v", id, suite.Specs)
+		}
+
+		if want := "//path/specs/" + id + "/api.json"; specification.URL != want {
+			t.Errorf("%s URL = %q, want %q", id, specification.URL, want)
+		}
+
+		if specification.GroupBy != "default" {
+			t.Errorf("%s GroupBy = %q, want default", id, specification.GroupBy)
+		}
+
+		if len(specification.Errors) != 1 || !strings.Contains(specification.Errors[0].Error(), "unexpected failure") {
+			t.Errorf("%s Errors = %v, want the unexpected failure", id, specification.Errors)
+		}
+	}
+}
+
+func TestLoadSpecifications_Recursion(t *testing.T) {
+	tests := []struct {
+		name      string
+		discovery bool
+	}{
+		{
+			name: "local specification",
+		},
+		{
+			name:      "discovered specification",
+			discovery: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Set(config.SpecDir, testSpecDir)
+			cfg.Set(config.SpecFilename, "recursive_api.json")
+			cfg.Set(config.DiscoveryEnabled, tt.discovery)
+
+			d := &fakeDiscoverer{
+				specs: map[string][]byte{
+					"/path/specs/recursive": specToByteSlice("../fixtures/recursive_api.json"),
+				},
+			}
+
+			suite, err := LoadSpecifications(cfg, d)
+			if err != nil {
+				t.Fatalf("LoadSpecifications() error = %v", err)
+			}
+
+			specification, ok := suite.Specs["discussions"]
+			if !ok {
+				t.Fatalf("specification discussions not loaded: %v", suite.Specs)
+			}
+
+			if len(specification.Errors) != 0 {
+				t.Fatalf("Errors = %v, want none", specification.Errors)
+			}
+
+			resources := specification.ResourceList["latest"]
+
+			for _, want := range []struct {
+				resource, property, recursive string
+			}{
+				{"comment", "replies", "comment"},
+				{"topic", "parent", "topic"},
+				{"person", "team", ""},
+			} {
+				r, ok := resources[want.resource]
+				if !ok {
+					t.Fatalf("resource %s not found", want.resource)
+				}
+
+				p, ok := r.Properties[want.property]
+				if !ok {
+					t.Fatalf("resource %s has no property %s", want.resource, want.property)
+				}
+
+				switch {
+				case want.recursive == "" && p.Recursive != nil:
+					t.Errorf("%s.%s refers back to %s, want no recursion", want.resource, want.property, p.Recursive.ID)
+				case want.recursive != "" && (p.Recursive == nil || p.Recursive.ID != want.recursive):
+					t.Errorf("%s.%s Recursive = %+v, want %s", want.resource, want.property, p.Recursive, want.recursive)
+				}
+			}
+
+			// A cycle through another definition links back to the resource it started from.
+			if lead := resources["person"].Properties["team"].Properties["lead"]; lead.Recursive == nil || lead.Recursive.ID != "person" {
+				t.Errorf("person.team.lead Recursive = %+v, want person", lead.Recursive)
+			}
+
+			// An inline schema sharing the title of a definition does not refer to it.
+			if settings := resources["forum"].Properties["settings"]; settings.Recursive != nil {
+				t.Errorf("forum.settings refers back to %s, want no recursion", settings.Recursive.ID)
+			}
+
+			// The members of an allOf are merged even when they are being compiled already.
+			moderator := resources["person"].Properties["team"].Properties["moderator"]
+			for _, name := range []string{"name", "team", "since"} {
+				if _, ok := moderator.Properties[name]; !ok {
+					t.Errorf("person.team.moderator has no property %s: %v", name, moderator.Properties)
+				}
+			}
+
+			// A definition only found as a property is documented in its own right.
+			if _, ok := resources["topic"].Methods["get-forum"]; !ok {
+				t.Errorf("topic methods = %v, want get-forum", resources["topic"].Methods)
+			}
+
+			var example map[string]interface{}
+			if err := json.Unmarshal([]byte(resources["comment"].Schema), &example); err != nil {
+				t.Fatalf("invalid comment example %s: %v", resources["comment"].Schema, err)
+			}
+
+			depth := 0
+			for replies, ok := example["replies"].([]interface{}); ok && len(replies) > 0; replies, ok = replies[0].(map[string]interface{})["replies"].([]interface{}) {
+				depth++
+			}
+
+			if depth != recursiveExampleDepth {
+				t.Errorf("comment example nests %d replies, want %d: %s", depth, recursiveExampleDepth, resources["comment"].Schema)
+			}
+		})
+	}
+}
+
+func TestLoadSpecifications_Examples(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.SpecDir, testSpecDir)
+	cfg.Set(config.SpecFilename, "examples_api.json")
+
+	suite, err := LoadSpecifications(cfg, nil)
+	if err != nil {
+		t.Fatalf("LoadSpecifications() error = %v", err)
+	}
+
+	specification, ok := suite.Specs["bookings"]
+	if !ok {
+		t.Fatalf("specification bookings not loaded: %v", suite.Specs)
+	}
+
+	booking, ok := specification.ResourceList["latest"]["booking"]
+	if !ok {
+		t.Fatalf("resource booking not found: %v", specification.ResourceList["latest"])
+	}
+
+	var example map[string]interface{}
+	if err := json.Unmarshal([]byte(booking.Schema), &example); err != nil {
+		t.Fatalf("invalid booking example %s: %v", booking.Schema, err)
+	}
+
+	tests := []struct {
+		property string
+		want     interface{}
+	}{
+		{property: "id", want: "3fa85f64-5717-4562-b3fc-2c963f66afa6"},
+		{property: "room", want: float64(100)},
+		{property: "guests", want: float64(1)},
+		{property: "price", want: 129.5},
+		{property: "currency", want: "EUR"},
+		{property: "status", want: "pending"},
+		{property: "arrival", want: "2019-08-24"},
+		{property: "created", want: "2019-08-24T14:15:22Z"},
+		{property: "email", want: "user@example.com"},
+		{property: "website", want: "https://example.com/path"},
+		{property: "reference", want: "stringstri"},
+		{property: "breakfast", want: true},
+		{property: "voucher", want: "<uuid4>"},
+		{property: "floor", want: float64(0)},
+		{property: "codes", want: []interface{}{"<uuid4>"}},
+		{property: "tags", want: []interface{}{"sea view"}},
+		{property: "labels", want: map[string]interface{}{"<key>": "late checkout"}},
+		{property: "address", want: map[string]interface{}{"city": "Lisbon"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.property, func(t *testing.T) {
+			if got := example[tt.property]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("example %s = %#v, want %#v", tt.property, got, tt.want)
+			}
+		})
+	}
+
+	// The schema declares no example, so the one generated from it is shown.
+	if booking.Example != booking.Schema {
+		t.Errorf("booking Example = %s, want the generated example %s", booking.Example, booking.Schema)
+	}
+
+	if len(booking.Examples) != 1 || booking.Examples[0].Name != "family" || booking.Examples[0].Description != "Children are counted as guests." {
+		t.Errorf("booking Examples = %+v, want family example", booking.Examples)
+	}
+}
+
+func TestLoadSpecifications_CodeSamples(t *testing.T) {
+	cfg := config.New()
+	cfg.Set(config.SpecDir, testSpecDir)
+	cfg.Set(config.SpecFilename, "examples_api.json")
+
+	suite, err := LoadSpecifications(cfg, nil)
+	if err != nil {
+		t.Fatalf("LoadSpecifications() error = %v", err)
+	}
+
+	specification, ok := suite.Specs["bookings"]
+	if !ok {
+		t.Fatalf("specification bookings not loaded: %v", suite.Specs)
+	}
+
+	want := []CodeSample{
+		{Lang: "Shell", Label: "cURL", Source: "curl -X POST https://bookings.example.com/v1/bookings -d @booking.json"},
+		{Lang: "Ruby", Source: "Booking.create(room: 204)"},
+	}
+
+	if got := specification.APIs[0].Methods[0].CodeSamples; !reflect.DeepEqual(got, want) {
+		t.Errorf("CodeSamples = %+v, want %+v", got, want)
+	}
+}
@@ -31,55 +31,15 @@ import (
 	"github.com/kenjones-cisco/dapperdox/config"
 )
 
-var (
-	statusMapSplit = regexp.MustCompile(",")
-	statusCodes    map[int]string
-)
-
-// loadStatusCodes loads status code mappings.
-func loadStatusCodes() {
-	var statusfile string
-
-	if viper.GetString(config.AssetsDir) != "" {
-		statusfile = filepath.Join(viper.GetString(config.AssetsDir), "status_codes.csv")
-		log().Tracef("Looking in assets dir for %s", statusfile)
-
-		if _, err := os.Stat(statusfile); os.IsNotExist(err) {
-			statusfile = ""
-		}
-	}
-
-	if statusfile == "" && viper.GetString(config.ThemeDir) != "" {
-		statusfile = filepath.Join(viper.GetString(config.ThemeDir), viper.GetString(config.Theme), "status_codes.csv")
-		log().Tracef("Looking in theme dir for %s", statusfile)
-
-		if _, err := os.Stat(statusfile); os.IsNotExist(err) {
-			statusfile = ""
-		}
-	}
-
-	if statusfile == "" {
-		statusfile = filepath.Join(viper.GetString(config.DefaultAssetsDir), "themes", viper.GetString(config.Theme), "status_codes.csv")
-		log().Tracef("Looking in default theme dir for %s", statusfile)
-
-		if _, err := os.Stat(statusfile); os.IsNotExist(err) {
-			statusfile = ""
-		}
-	}
-
-	if statusfile == "" {
-		statusfile = filepath.Join(viper.GetString(config.DefaultAssetsDir), "themes", "default", "status_codes.csv")
-		log().Tracef("Looking in default theme %s", statusfile)
-
-		if _, err := os.Stat(statusfile); os.IsNotExist(err) {
-			statusfile = ""
-		}
-	}
+var statusMapSplit = regexp.MustCompile(",")
 
+// loadStatusCodes loads the descriptions of the HTTP status codes in a locale.
+func loadStatusCodes(cfg *viper.Viper, locale string) map[int]string {
+	statusfile := statusCodesFile(cfg, locale)
 	if statusfile == "" {
 		log().Trace("No status code map file found.")
 
-		return
+		return nil
 	}
 
 	log().Tracef("Processing HTTP status code file: %s", statusfile)
@@ -88,11 +48,11 @@ func loadStatusCodes() {
 	if err != nil {
 		log().Errorf("Error: %s", err)
 
-		return
+		return nil
 	}
 	defer file.Close()
 
-	statusCodes = make(map[int]string)
+	statusCodes := make(map[int]string)
 
 	scanner := bufio.NewScanner(file)
 
@@ -101,7 +61,7 @@ func loadStatusCodes() {
 
 		indexes := statusMapSplit.FindStringIndex(line)
 		if indexes == nil {
-			return
+			return statusCodes
 		}
 
 		i, err := strconv.Atoi(line[0 : indexes[1]-1])
@@ -117,8 +77,44 @@ func loadStatusCodes() {
 	if err := scanner.Err(); err != nil {
 		log().Errorf("Error: %s", err)
 	}
+
+	return statusCodes
 }
 
-func httpStatusDescription(status int) string {
-	return statusCodes[status]
+// statusCodesFile returns the file describing the HTTP status codes in a locale: status_codes.<locale>.csv
+// when there is one, otherwise status_codes.csv, each being looked for in the assets directory, then the
+// theme, then the default theme. It returns an empty string when there is none.
+func statusCodesFile(cfg *viper.Viper, locale string) string {
+	names := []string{"status_codes.csv"}
+	if locale != "" {
+		names = []string{"status_codes." + locale + ".csv", "status_codes.csv"}
+	}
+
+	var dirs []string
+
+	if cfg.GetString(config.AssetsDir) != "" {
+		dirs = append(dirs, cfg.GetString(config.AssetsDir))
+	}
+
+	if cfg.GetString(config.ThemeDir) != "" {
+		dirs = append(dirs, filepath.Join(cfg.GetString(config.ThemeDir), cfg.GetString(config.Theme)))
+	}
+
+	dirs = append(dirs,
+		filepath.Join(cfg.GetString(config.DefaultAssetsDir), "themes", cfg.GetString(config.Theme)),
+		filepath.Join(cfg.GetString(config.DefaultAssetsDir), "themes", "default"),
+	)
+
+	for _, name := range names {
+		for _, dir := range dirs {
+			statusfile := filepath.Join(dir, name)
+			log().Tracef("Looking for %s", statusfile)
+
+			if _, err := os.Stat(statusfile); err == nil {
+				return statusfile
+			}
+		}
+	}
+
+	return ""
 }